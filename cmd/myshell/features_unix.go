@@ -0,0 +1,9 @@
+//go:build unix
+
+package main
+
+func init() {
+	// CPU times come from rusage and the umask from umask(2), neither of
+	// which exists elsewhere.
+	features = append(features, "cpu-times", "umask")
+}
@@ -1,378 +1,218 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
-	"os/exec"
-	"path/filepath"
-	"slices"
+	"os/signal"
 	"strconv"
-	"strings"
-	"unicode"
+	"time"
 
-	"golang.org/x/term"
+	"github.com/codecrafters-io/shell-starter-go/pkg/builtins"
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+	"github.com/codecrafters-io/shell-starter-go/pkg/lineedit"
+	"github.com/codecrafters-io/shell-starter-go/pkg/parser"
+	"github.com/codecrafters-io/shell-starter-go/pkg/terminal"
 )
 
-var builtinCMDs = []string{
-	"exit",
-	"echo",
-	"type",
-	"pwd",
-	"cd",
-}
-
-type CMD struct {
-	Name       string
-	Args       []string
-	Stdout     io.Writer
-	Stderr     io.Writer
-	childFiles []*os.File
+// editor reads command lines at the prompt, completing and highlighting
+// them with what the interpreter knows.
+var editor = &lineedit.Editor{
+	History:      interp.History,
+	Complete:     interp.Autocomplete,
+	Highlight:    interp.Highlight,
+	Abbreviation: builtins.Abbreviation,
+	Option:       interp.Option,
+	Color:        interp.Color,
+	Getenv:       interp.GetVar,
+	LookPath:     interp.HashedLookPath,
+	Environ:      interp.Environ,
+	Focus:        focusChanged,
 }
 
 func main() {
-	for {
-		fmt.Fprint(os.Stdout, "\r$ ")
-		input := readInput(os.Stdin)
-		cmd, err := parseCMD(input)
-		if err != nil {
-			fmt.Println(err)
-			continue
-		}
-		switch cmd.Name {
-		case "exit":
-			cmd.Exit()
-		case "echo":
-			cmd.Echo()
-		case "type":
-			cmd.Type()
-		case "pwd":
-			cmd.PWD()
-		case "cd":
-			cmd.CD()
-		case "":
-			continue
-		default:
-			command := exec.Command(cmd.Name, cmd.Args...)
-			command.Stdout = cmd.Stdout
-			command.Stderr = cmd.Stderr
-			if err := command.Run(); err != nil {
-				var execErr *exec.ExitError
-				if errors.As(err, &execErr) {
-					continue
-				}
-				fmt.Println(cmd.Name + ": command not found")
-			}
-		}
-	}
-}
-
-func readInput(rd io.Reader) (input string) {
-	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
-	if err != nil {
-		panic(err)
+	interp.MaybeSandboxExec()
+	if len(os.Args) > 1 && handleInfoFlag(os.Args[1]) {
+		return
 	}
-	defer term.Restore(int(os.Stdin.Fd()), oldState)
-	r := bufio.NewReader(rd)
-	wasTab := false
-	autocompleteNames := []string{}
-loop:
-	for {
-		c, _, err := r.ReadRune()
-		if err != nil {
-			fmt.Println(err)
-			continue
+	builtins.Register()
+	args := shellFlags(os.Args[1:])
+	loadPolicy()
+	interp.LoadEnvironment()
+	setVersionVar()
+	interp.InitWorkingDir()
+	interp.DetectAccessibility()
+	interp.DetectASCII()
+	incrementShellLevel()
+	if len(args) > 0 {
+		if err := interp.SourceFile(context.Background(), args[0], args[1:]); err != nil {
+			interp.Exit(interp.Report(os.Stderr, &interp.Error{Msg: args[0] + ": No such file or directory", Status: 127}))
 		}
-		switch c {
-		case '\x03': // Ctrl+C
-			os.Exit(0)
-		case '\r', '\n': // Enter
-			fmt.Fprint(os.Stdout, "\r\n")
-			break loop
-		case '\x7F': // Backspace
-			if length := len(input); length > 0 {
-				input = input[:length-1]
-				fmt.Fprint(os.Stdout, "\b \b")
-			}
-			autocompleteNames = nil
-			wasTab = false
-		case '\t': // Tab
-			if len(autocompleteNames) == 0 {
-				names, found := autocomplete(input)
-				if !found {
-					fmt.Fprint(os.Stdout, "\a")
-					continue
-				}
-				autocompleteNames = names
-			}
-			switch {
-			case len(autocompleteNames) == 1:
-				suffix := strings.TrimPrefix(autocompleteNames[0], input)
-				input += suffix + " "
-				fmt.Fprint(os.Stdout, suffix+" ")
-			case len(autocompleteNames) > 1:
-				longestCommonPrefix, found := findLongestCommonPrefix(autocompleteNames)
-				if found {
-					suffix := strings.TrimPrefix(longestCommonPrefix, input)
-					input += suffix
-					fmt.Fprint(os.Stdout, suffix)
-					autocompleteNames = nil
-					wasTab = false
-					continue
-				}
-				if !wasTab {
-					fmt.Fprint(os.Stdout, "\a")
-					wasTab = true
-					continue
-				}
-				fmt.Fprintf(os.Stdout, "\r\n%s\r\n", strings.Join(autocompleteNames, "  "))
-				fmt.Fprint(os.Stdout, "$ ", input)
-			}
-		default:
-			input += string(c)
-			fmt.Fprint(os.Stdout, string(c))
-			wasTab = false
-			autocompleteNames = nil
+		interp.Exit(interp.LastStatus())
+	}
+	tty := terminal.Std()
+	if !tty.IsTerminal() {
+		interp.RunScript(context.Background(), tty)
+		interp.Exit(interp.LastStatus())
+	}
+	repl(tty)
+}
+
+// shellFlags turns on the options given by letter in the flags at the
+// start of args, like -n, or with --posix the posix option, scrubs the
+// environment with --clean-env, takes the policy file from --policy, and
+// returns the script and arguments after them.
+func shellFlags(args []string) []string {
+	for len(args) > 0 && len(args[0]) > 1 && args[0][0] == '-' {
+		flag := args[0]
+		args = args[1:]
+		if flag == "--" {
+			break
 		}
-	}
-	return
-}
-
-func parseCMD(s string) (*CMD, error) {
-	cmd := CMD{
-		Stdout: os.Stdout,
-		Stderr: os.Stderr,
-	}
-	sanitized := sanitizeInput(s)
-	if len(sanitized) > 0 {
-		cmd.Name = sanitized[0]
-	}
-	if len(sanitized) > 1 {
-		cmd.Args = sanitized[1:]
-	}
-	for i, arg := range cmd.Args {
-		if i >= len(cmd.Args) {
+		switch flag {
+		case "--posix":
+			interp.SetOption("posix", true)
+			interp.FlagOptions["posix"] = true
 			continue
-		}
-		switch arg {
-		case ">", "1>", "2>":
-			f, err := os.OpenFile(cmd.Args[i+1], os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
-			if err != nil {
-				return nil, err
-			}
-			switch arg {
-			case ">", "1>":
-				cmd.Stdout = f
-			case "2>":
-				cmd.Stderr = f
-			}
-			cmd.Args = cmd.Args[:i]
-			cmd.childFiles = append(cmd.childFiles, f)
-		case ">>", "1>>", "2>>":
-			f, err := os.OpenFile(cmd.Args[i+1], os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
-			if err != nil {
-				return nil, err
-			}
-			switch arg {
-			case ">>", "1>>":
-				cmd.Stdout = f
-			case "2>>":
-				cmd.Stderr = f
+		case "--clean-env":
+			cleanEnv()
+			continue
+		case "--policy":
+			if len(args) == 0 {
+				interp.Exit(interp.Report(os.Stderr, &interp.Error{Msg: "--policy: file argument required", Status: 2}))
 			}
-			cmd.Args = cmd.Args[:i]
-			cmd.childFiles = append(cmd.childFiles, f)
+			policyFile, args = args[0], args[1:]
+			continue
 		}
-	}
-	return &cmd, nil
-}
-
-func sanitizeInput(s string) (args []string) {
-	var sb strings.Builder
-	inSingleQuotes := false
-	inDoubleQuotes := false
-	escaped := false
-	for i, c := range s {
-		switch {
-		case escaped:
-			sb.WriteRune(c)
-			escaped = false
-		case c == '\'':
-			if inDoubleQuotes {
-				sb.WriteRune(c)
-				continue
-			}
-			inSingleQuotes = !inSingleQuotes
-		case c == '"':
-			if inSingleQuotes {
-				sb.WriteRune(c)
-				continue
+		for _, letter := range flag[1:] {
+			name, ok := interp.ShortOptions[letter]
+			if !ok {
+				interp.Exit(interp.Report(os.Stderr, &interp.Error{Msg: fmt.Sprintf("-%c: invalid option", letter), Status: 2}))
 			}
-			inDoubleQuotes = !inDoubleQuotes
-		case c == '\\':
-			switch {
-			case inSingleQuotes:
-				sb.WriteRune(c)
-			case inDoubleQuotes:
-				if i+1 >= len(s) {
-					sb.WriteRune(c)
-					continue
-				}
-				nextC := s[i+1]
-				if nextC == '\\' || nextC == '$' || nextC == '"' {
-					escaped = true
-					continue
-				}
-				sb.WriteRune(c)
-			default:
-				escaped = true
-			}
-		case unicode.IsSpace(c):
-			if inSingleQuotes || inDoubleQuotes {
-				sb.WriteRune(c)
-				continue
-			}
-			if sb.Len() > 0 {
-				args = append(args, sb.String())
-				sb.Reset()
-			}
-		default:
-			sb.WriteRune(c)
+			interp.SetOption(name, true)
+			interp.FlagOptions[name] = true
 		}
 	}
-	if sb.Len() > 0 {
-		args = append(args, sb.String())
-	}
-	return
+	return args
 }
 
-func (c *CMD) Exit() {
-	if len(c.Args) == 0 {
-		os.Exit(0)
-	}
-	code, err := strconv.Atoi(c.Args[0])
-	if err != nil {
-		fmt.Println("err convert exit code:", err.Error())
-		os.Exit(0)
-	}
-	os.Exit(code)
-}
-
-func (c *CMD) Echo() {
-	defer c.closeChildFiles()
-	fmt.Fprintln(c.Stdout, strings.Join(c.Args, " "))
-}
+// policyFile is the file of the policy of which commands may run given
+// with --policy, or "" for interp.PolicyFile.
+var policyFile string
 
-func (c *CMD) closeChildFiles() {
-	for _, f := range c.childFiles {
-		f.Close()
-	}
-}
-
-func (c *CMD) Type() {
-	if len(c.Args) == 0 {
-		fmt.Println("missing argument")
-		return
-	}
-	value := c.Args[0]
-	if slices.Contains(builtinCMDs, value) {
-		fmt.Println(value, "is a shell builtin")
-		return
+// loadPolicy loads the policy of which commands may run from policyFile,
+// or from interp.PolicyFile if there is one. The shell exits if the policy
+// can't be read, rather than run commands it may be meant to deny.
+func loadPolicy() {
+	file := policyFile
+	if file == "" {
+		file = interp.PolicyFile
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			return
+		}
 	}
-	path, err := exec.LookPath(value)
-	if err != nil {
-		fmt.Println(value + ": not found")
-		return
+	if err := interp.LoadPolicy(file); err != nil {
+		interp.Exit(interp.Report(os.Stderr, &interp.Error{Msg: err.Error(), Status: 2}))
 	}
-	fmt.Println(value, "is", path)
 }
 
-func (c *CMD) PWD() {
-	dir, err := os.Getwd()
-	if err != nil {
-		fmt.Println(err.Error())
-		return
-	}
-	fmt.Println(dir)
-}
+// cleanEnvVars are the environment variables --clean-env keeps.
+var cleanEnvVars = []string{"PATH", "HOME", "TERM", "LANG"}
 
-func (c *CMD) CD() {
-	if len(c.Args) == 0 {
-		return
-	}
-	dir := c.Args[0]
-	if dir == "~" {
-		dir = os.Getenv("HOME")
-	}
-	if err := os.Chdir(dir); err != nil {
-		fmt.Printf("cd: %s: No such file or directory\n", dir)
+// cleanEnv removes every environment variable but those in cleanEnvVars,
+// so that the shell and the commands it runs start the same wherever they
+// are run from.
+func cleanEnv() {
+	kept := map[string]string{}
+	for _, name := range cleanEnvVars {
+		if value, ok := os.LookupEnv(name); ok {
+			kept[name] = value
+		}
 	}
-}
-
-func autocomplete(prefix string) (names []string, found bool) {
-	if prefix == "" {
-		return
+	os.Clearenv()
+	for name, value := range kept {
+		os.Setenv(name, value)
 	}
-	names = append(names, findBuiltinExecutablesHasPrefix(prefix)...)
-	names = append(names, findExecutablesHasPrefix(prefix)...)
-	names = removeDuplicates(names)
-	slices.Sort(names)
-	found = len(names) > 0
-	return
 }
 
-func removeDuplicates(duplicates []string) (after []string) {
-	dup := map[string]struct{}{}
-	for _, v := range duplicates {
-		if _, ok := dup[v]; !ok {
-			dup[v] = struct{}{}
-			after = append(after, v)
-		}
+// repl reads commands typed on tty and runs them, until the shell exits.
+// Ctrl+C while a command runs stops it instead of the shell.
+func repl(tty terminal.Terminal) {
+	// SIGINT is sent to the shell as well as the commands it runs, and
+	// would end it when it isn't running one.
+	signal.Notify(make(chan os.Signal, 1), os.Interrupt)
+	interp.SetTerminal(tty)
+	interp.SetInteractive()
+	editor.Terminal = tty
+	interp.LoadHistory()
+	if err := builtins.LoadConfig(interp.Session(), builtins.ConfigFile(interp.Session())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		interp.Report(os.Stderr, err)
 	}
-	return
-}
-
-func findBuiltinExecutablesHasPrefix(prefix string) (names []string) {
-	for _, v := range builtinCMDs {
-		if strings.HasPrefix(v, prefix) {
-			names = append(names, v)
+	loadRC()
+	interp.IndexPath(interp.GetVar("PATH"))
+	for {
+		interp.RunJobHooks()
+		interp.RunHooks("precmd")
+		// The terminal is swapped for one recording it while record runs.
+		editor.Terminal = interp.Terminal()
+		input := readCommand()
+		interp.AddHistory(input)
+		interp.RunHooks("preexec", input)
+		runInterruptible(input)
+		noteFinished(input, interp.LastStatus())
+		interp.SaveStats()
+	}
+}
+
+// runInterruptible runs input until it ends or Ctrl+C interrupts it.
+func runInterruptible(input string) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	interp.TimeLine(ctx, input)
+	// The status is also that of a command Ctrl+C killed, stopping the
+	// line before the shell saw the signal.
+	if ctx.Err() != nil || interp.LastStatus() == 130 {
+		// Start the prompt on the line after the ^C the terminal echoed.
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
+// readCommand reads a command at the prompt, reading further lines after
+// the PS2 prompt for as long as the command is incomplete. Ctrl+C and
+// Ctrl+D exit the shell, as does typing nothing for TMOUT seconds, but
+// Ctrl+D after the PS2 prompt gives up the command instead, returning "".
+func readCommand() string {
+	secs, _ := strconv.Atoi(interp.GetVar("TMOUT"))
+	editor.Timeout = time.Duration(max(secs, 0)) * time.Second
+	input, _ := readLine(interp.Prompt("PS1", "$ "), interp.Prompt("RPROMPT", ""), false)
+	for parser.Incomplete(input) {
+		line, ok := readLine(interp.Prompt("PS2", "> "), "", true)
+		if !ok {
+			return ""
 		}
+		input = parser.JoinLines(input, line)
 	}
-	return
+	return input
 }
 
-func findExecutablesHasPrefix(prefix string) (names []string) {
-	path := os.Getenv("PATH")
-	for _, dir := range filepath.SplitList(path) {
-		if dir == "" {
-			dir = "."
-		}
-		_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
-			if err != nil || d.IsDir() || !strings.HasPrefix(d.Name(), prefix) {
-				return err
-			}
-			info, _ := d.Info()
-			if info.Mode()&0111 != 0 {
-				names = append(names, d.Name())
-			}
-			return nil
-		})
+// readLine reads a line with the editor, exiting the shell when the input
+// ends or is interrupted. A line continuing a command is given up with
+// Ctrl+D instead, and then readLine returns false.
+func readLine(prompt, rprompt string, continuation bool) (string, bool) {
+	editor.Continuation = continuation
+	line, err := editor.ReadLine(prompt, rprompt)
+	if err == lineedit.ErrTimeout {
+		interp.Report(os.Stderr, fmt.Errorf("%v: auto-logout", err))
 	}
-	return
-}
-
-func findLongestCommonPrefix(names []string) (longestCommonPrefix string, found bool) {
-	if len(names) == 0 {
-		return
+	if err == io.EOF && continuation {
+		return "", false
 	}
-	slices.Sort(names)
-	longestCommonPrefix = names[0]
-	for _, v := range names[1:] {
-		if !strings.HasPrefix(v, longestCommonPrefix) {
-			return "", false
-		}
+	if err != nil {
+		interp.Exit(0)
 	}
-	return longestCommonPrefix, true
+	interp.EchoInput(line)
+	return line, true
 }
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+)
+
+// notice is the desktop notification for the command line that just
+// finished, if it took longer than NOTIFYTIME, to send once the terminal
+// reports it doesn't have focus.
+var notice struct {
+	title, body string
+}
+
+// noteFinished sets the notice for the command line input if it took
+// longer than NOTIFYTIME seconds, after it finished with status.
+func noteFinished(input string, status int) {
+	notice.title, notice.body = "", ""
+	threshold, err := strconv.ParseFloat(interp.GetVar("NOTIFYTIME"), 64)
+	if err != nil || threshold < 0 {
+		return
+	}
+	ms, _ := strconv.ParseInt(interp.GetVar("CMD_DURATION"), 10, 64)
+	took := time.Duration(ms) * time.Millisecond
+	if took.Seconds() <= threshold {
+		return
+	}
+	notice.title = strings.TrimSpace(input)
+	notice.body = fmt.Sprintf("finished with status %d after %s", status, took.Round(time.Second))
+}
+
+// focusChanged sends the notice, if there is one, when the terminal has
+// lost focus, and drops it either way since the user has been back since
+// the command finished or will see that it did.
+func focusChanged(focused bool) {
+	if notice.title != "" && !focused {
+		notify(notice.title, notice.body)
+	}
+	notice.title, notice.body = "", ""
+}
+
+// notify sends a desktop notification: with notify-send where there is a
+// display to show it on, and otherwise through the terminal, with the OSC
+// 777 sequence the terminals that show notifications understand.
+func notify(title, body string) {
+	if interp.GetVar("DISPLAY") != "" || interp.GetVar("WAYLAND_DISPLAY") != "" {
+		if path, err := interp.HashedLookPath("notify-send"); err == nil {
+			cmd := exec.Command(path, "--", title, body)
+			cmd.Env = interp.Environ()
+			if cmd.Start() == nil {
+				go cmd.Wait()
+				return
+			}
+		}
+	}
+	// The fields of the sequence are separated by semicolons, and it ends
+	// at the first control character.
+	clean := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if r < ' ' || r == 0x7f {
+				return ' '
+			}
+			return r
+		}, s)
+	}
+	fmt.Fprintf(interp.Terminal(), "\x1b]777;notify;%s;%s\a", strings.ReplaceAll(clean(title), ";", ","), clean(body))
+}
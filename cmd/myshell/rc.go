@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+)
+
+// rcGuardVar is set while a shell sources its rc file, to
+// "<nonce>:<SHLVL>" of the shell that started the chain. A shell started
+// while it is set was started from an rc file.
+const rcGuardVar = "MYSHELL_RC_GUARD"
+
+// maxRCDepth is how many shells an rc file may start from within rc files
+// before the deepest one stops sourcing it.
+const maxRCDepth = 4
+
+func rcFile() string {
+	return filepath.Join(interp.GetVar("HOME"), ".myshellrc")
+}
+
+func incrementShellLevel() {
+	level, _ := strconv.Atoi(interp.GetVar("SHLVL"))
+	interp.SetVar("SHLVL", strconv.Itoa(max(level, 0)+1))
+	interp.ExportVar("SHLVL")
+}
+
+// loadRC sources the rc file, unless doing so would recurse once more than
+// maxRCDepth allows: an rc file that starts myshell (directly or through
+// another program) would otherwise start shells until resources run out.
+//
+// The first shell of a chain sets rcGuardVar while sourcing, and each shell
+// started from it measures its depth by how far its SHLVL is above the one
+// recorded there. The nonce makes the guard unique to that chain, so one
+// left behind in an exported environment of an unrelated session, with a
+// SHLVL base it can't have, is recognized as stale and ignored.
+func loadRC() {
+	file := rcFile()
+	if _, err := os.Stat(file); err != nil {
+		return
+	}
+	level, _ := strconv.Atoi(interp.GetVar("SHLVL"))
+	if guard, ok := interp.LookupVar(rcGuardVar); ok {
+		nonce, base, _ := strings.Cut(guard, ":")
+		baseLevel, err := strconv.Atoi(base)
+		if nonce != "" && err == nil && baseLevel < level {
+			if depth := level - baseLevel; depth > maxRCDepth {
+				interp.Report(os.Stderr, &interp.Error{Msg: fmt.Sprintf("%s started myshell recursively %d levels deep; not sourcing it again", file, depth)})
+				return
+			}
+			interp.SourceFile(context.Background(), file, nil)
+			return
+		}
+	}
+	nonce := make([]byte, 8)
+	rand.Read(nonce)
+	interp.SetVar(rcGuardVar, hex.EncodeToString(nonce)+":"+strconv.Itoa(level))
+	interp.ExportVar(rcGuardVar)
+	interp.SourceFile(context.Background(), file, nil)
+	interp.UnsetVar(rcGuardVar)
+}
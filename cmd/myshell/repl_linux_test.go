@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+// update rewrites the screens in the golden files with what the shell
+// shows, for after a change to what it should show.
+var update = flag.Bool("update", false, "rewrite the screens in testdata/repl with the ones rendered")
+
+// Terminal size the shell runs in, and how long it may take to draw its
+// next prompt or exit after a line of keys.
+const (
+	termWidth, termHeight = 80, 24
+	promptTimeout         = 10 * time.Second
+)
+
+// promptMark ends the prompts of the shell under test, for the driver to
+// know when it is ready for more keys: OSC 133;A, which marks a prompt to
+// terminals that know it and is ignored by the rest. questionMark ends the
+// questions the shell asks, which wait for keys as prompts do.
+const (
+	promptMark   = "\x1b]133;A\a"
+	questionMark = "[y/N]? "
+)
+
+// TestMain runs the shell itself instead of the tests when the test binary
+// is started as the shell by runREPL.
+func TestMain(m *testing.M) {
+	if os.Getenv("MYSHELL_TEST_SHELL") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// TestREPL runs the shell on a terminal for each golden file in
+// testdata/repl, types the keys in it and compares the screen rendered with
+// the one the file expects.
+//
+// A golden file is made of a description, a "-- keys --" line followed by
+// the keys to type, one line at a time, and a "-- screen --" line followed
+// by the screen expected at the end. Keys are written the way they are in
+// a Go string: \r for Enter, \t for Tab, \x03 for Ctrl+C, \x1b[A for Up.
+// A line of keys ending with Enter, Ctrl+C or Ctrl+D, or answering a
+// question with a lone y or n, is typed and then waited on: the next isn't
+// typed until the shell has drawn a prompt again, asked a question or
+// exited, and once it has exited no more are. The others are typed right
+// away, since the line editor reads keys in order however fast they come.
+// Go test -update rewrites the screens with the ones rendered.
+func TestREPL(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "repl", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		t.Run(strings.TrimSuffix(filepath.Base(file), ".txt"), func(t *testing.T) {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			head, keys, want, err := parseGolden(string(data))
+			if err != nil {
+				t.Fatalf("%s: %v", file, err)
+			}
+			got := runREPL(t, keys)
+			if *update {
+				out := head + "-- keys --\n" + strings.Join(quoteKeys(keys), "\n") + "\n-- screen --\n" + got + "\n"
+				if err := os.WriteFile(file, []byte(out), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if got != want {
+				t.Errorf("screen after typing %q:\n%s\n\nwant:\n%s", keys, got, want)
+			}
+		})
+	}
+}
+
+// parseGolden splits a golden file into its description, the keys to type
+// and the screen expected.
+func parseGolden(data string) (head string, keys []string, screen string, err error) {
+	head, rest, ok := strings.Cut(data, "-- keys --\n")
+	if !ok {
+		return "", nil, "", fmt.Errorf("no -- keys -- section")
+	}
+	keyText, screen, ok := strings.Cut(rest, "-- screen --\n")
+	if !ok {
+		return "", nil, "", fmt.Errorf("no -- screen -- section")
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(keyText, "\n"), "\n") {
+		k, err := strconv.Unquote(`"` + strings.ReplaceAll(line, `"`, `\"`) + `"`)
+		if err != nil {
+			return "", nil, "", fmt.Errorf("keys %s: %v", line, err)
+		}
+		keys = append(keys, k)
+	}
+	return head, keys, strings.TrimRight(screen, "\n"), nil
+}
+
+// quoteKeys writes keys back the way a golden file has them.
+func quoteKeys(keys []string) []string {
+	lines := make([]string, len(keys))
+	for i, k := range keys {
+		q := strconv.Quote(k)
+		lines[i] = strings.ReplaceAll(q[1:len(q)-1], `\"`, `"`)
+	}
+	return lines
+}
+
+// runREPL starts the shell on a new terminal, in a directory of its own
+// with a few files to complete and an empty home, types the lines of keys
+// as TestREPL describes, and returns the screen it leaves.
+func runREPL(t *testing.T, keys []string) string {
+	dir := t.TempDir()
+	home := filepath.Join(dir, "home")
+	work := filepath.Join(dir, "work")
+	for _, d := range []string{home, filepath.Join(work, "docs")} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range []string{"apple.txt", "apricot.txt", "banana.txt"} {
+		if err := os.WriteFile(filepath.Join(work, f), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	master, slave, err := openPTY(termWidth, termHeight)
+	if err != nil {
+		t.Skipf("no pseudo-terminal: %v", err)
+	}
+	defer master.Close()
+	cmd := exec.Command(os.Args[0])
+	cmd.Dir = work
+	cmd.Env = []string{"MYSHELL_TEST_SHELL=1", "HOME=" + home, "PATH=" + os.Getenv("PATH"), "TERM=xterm", "PS1=$ " + promptMark, "PS2=> " + promptMark}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	slave.Close()
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	out := &output{changed: make(chan struct{}, 1), closed: make(chan struct{})}
+	go out.readFrom(master)
+	if !out.waitPrompt(1) {
+		t.Fatalf("no prompt within %v:\n%s", promptTimeout, out.screen())
+	}
+	for i, k := range keys {
+		if out.exited() {
+			break
+		}
+		prompts := out.prompts()
+		if _, err := master.Write([]byte(k)); err != nil {
+			t.Fatal(err)
+		}
+		if endsLine(k) || i == len(keys)-1 {
+			if !out.waitPrompt(prompts + 1) {
+				t.Fatalf("after typing %q, no prompt within %v:\n%s", k, promptTimeout, out.screen())
+			}
+		}
+	}
+	return out.screen()
+}
+
+// endsLine reports whether the last of keys ends the line being edited,
+// Enter, Ctrl+C or Ctrl+D, or keys answer a question.
+func endsLine(keys string) bool {
+	return strings.HasSuffix(keys, "\r") || strings.HasSuffix(keys, "\x03") || strings.HasSuffix(keys, "\x04") || keys == "y" || keys == "n"
+}
+
+// output collects what the shell writes to its terminal.
+type output struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+	// changed is sent to when more is written, and closed is closed once
+	// the terminal is, which it is when the shell and every process it
+	// started have exited.
+	changed chan struct{}
+	closed  chan struct{}
+}
+
+func (o *output) readFrom(f *os.File) {
+	defer close(o.closed)
+	b := make([]byte, 4096)
+	for {
+		n, err := f.Read(b)
+		o.mu.Lock()
+		o.buf.Write(b[:n])
+		o.mu.Unlock()
+		select {
+		case o.changed <- struct{}{}:
+		default:
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// prompts returns the number of prompts drawn and questions asked so far.
+func (o *output) prompts() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return bytes.Count(o.buf.Bytes(), []byte(promptMark)) + bytes.Count(o.buf.Bytes(), []byte(questionMark))
+}
+
+// exited reports whether the shell has exited and all it wrote was read.
+func (o *output) exited() bool {
+	select {
+	case <-o.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// waitPrompt waits for the shell to have drawn n prompts or to have exited,
+// and reports whether it did within promptTimeout.
+func (o *output) waitPrompt(n int) bool {
+	timeout := time.After(promptTimeout)
+	for o.prompts() < n && !o.exited() {
+		select {
+		case <-o.changed:
+		case <-o.closed:
+		case <-timeout:
+			return false
+		}
+	}
+	return true
+}
+
+// screen returns the screen the output renders.
+func (o *output) screen() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	s := newScreen(termWidth)
+	s.Write(o.buf.Bytes())
+	return s.String()
+}
+
+// openPTY opens a new pseudo-terminal of the given size and returns its
+// master and slave ends.
+func openPTY(width, height int) (master, slave *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	var unlock int32
+	var n uint32
+	ws := struct{ row, col, x, y uint16 }{uint16(height), uint16(width), 0, 0}
+	for _, call := range []struct {
+		req uintptr
+		arg unsafe.Pointer
+	}{
+		{syscall.TIOCSPTLCK, unsafe.Pointer(&unlock)},
+		{syscall.TIOCGPTN, unsafe.Pointer(&n)},
+		{syscall.TIOCSWINSZ, unsafe.Pointer(&ws)},
+	} {
+		if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), call.req, uintptr(call.arg)); errno != 0 {
+			master.Close()
+			return nil, nil, errno
+		}
+	}
+	slave, err = os.OpenFile("/dev/pts/"+strconv.Itoa(int(n)), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	return master, slave, nil
+}
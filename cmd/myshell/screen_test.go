@@ -0,0 +1,174 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// screen renders what a program writes to a terminal into the text it
+// leaves on the screen, following the cursor movement and erase sequences
+// the line editor uses and ignoring colors and other attributes.
+type screen struct {
+	width    int
+	lines    [][]rune
+	row, col int
+	// savedRow and savedCol are the cursor position saved by ESC 7.
+	savedRow, savedCol int
+}
+
+func newScreen(width int) *screen {
+	return &screen{width: width}
+}
+
+// Write renders p, which must not split an escape sequence or character
+// across calls.
+func (s *screen) Write(p []byte) (int, error) {
+	for i := 0; i < len(p); {
+		r, size := utf8.DecodeRune(p[i:])
+		i += size
+		switch r {
+		case '\r':
+			s.col = 0
+		case '\n':
+			s.row++
+		case '\b':
+			s.col = max(s.col-1, 0)
+		case '\t':
+			s.col = min((s.col/8+1)*8, s.width-1)
+		case '\a':
+		case '\x1b':
+			i += s.escape(p[i:])
+		default:
+			if s.col >= s.width {
+				s.row, s.col = s.row+1, 0
+			}
+			s.put(r)
+			s.col++
+		}
+	}
+	return len(p), nil
+}
+
+// escape carries out the escape sequence at the start of p, which follows
+// an ESC, and returns its length.
+func (s *screen) escape(p []byte) int {
+	if len(p) == 0 {
+		return 0
+	}
+	switch p[0] {
+	case '7':
+		s.savedRow, s.savedCol = s.row, s.col
+		return 1
+	case '8':
+		s.row, s.col = s.savedRow, s.savedCol
+		return 1
+	case ']':
+		// An operating system command, such as setting the clipboard, ends
+		// with BEL or ESC \.
+		for i := 1; i < len(p); i++ {
+			if p[i] == '\a' {
+				return i + 1
+			}
+			if p[i] == '\x1b' && i+1 < len(p) && p[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return len(p)
+	case '[':
+	default:
+		return 1
+	}
+	end := 1
+	for end < len(p) && (p[end] < 0x40 || p[end] > 0x7e) {
+		end++
+	}
+	if end == len(p) {
+		return end
+	}
+	params := strings.TrimPrefix(string(p[1:end]), "?")
+	var args []int
+	for _, f := range strings.Split(params, ";") {
+		n, _ := strconv.Atoi(f)
+		args = append(args, n)
+	}
+	// arg returns the first parameter, or def if it is missing or 0.
+	arg := func(def int) int {
+		if args[0] == 0 {
+			return def
+		}
+		return args[0]
+	}
+	switch p[end] {
+	case 'A':
+		s.row = max(s.row-arg(1), 0)
+	case 'B':
+		s.row += arg(1)
+	case 'C':
+		s.col = min(s.col+arg(1), s.width-1)
+	case 'D':
+		s.col = max(s.col-arg(1), 0)
+	case 'G':
+		s.col = arg(1) - 1
+	case 'H':
+		s.row, s.col = arg(1)-1, 0
+		if len(args) > 1 && args[1] > 0 {
+			s.col = args[1] - 1
+		}
+	case 'K':
+		s.eraseLine(args[0])
+	case 'J':
+		if args[0] == 2 {
+			s.lines = nil
+			break
+		}
+		s.eraseLine(0)
+		if s.row+1 < len(s.lines) {
+			s.lines = s.lines[:s.row+1]
+		}
+	}
+	return end + 1
+}
+
+// put writes r at the cursor.
+func (s *screen) put(r rune) {
+	for len(s.lines) <= s.row {
+		s.lines = append(s.lines, nil)
+	}
+	line := s.lines[s.row]
+	for len(line) <= s.col {
+		line = append(line, ' ')
+	}
+	line[s.col] = r
+	s.lines[s.row] = line
+}
+
+// eraseLine erases the line the cursor is on: from the cursor to the end
+// for mode 0, from the start to the cursor for 1, and all of it for 2.
+func (s *screen) eraseLine(mode int) {
+	if s.row >= len(s.lines) {
+		return
+	}
+	line := s.lines[s.row]
+	switch mode {
+	case 0:
+		if s.col < len(line) {
+			s.lines[s.row] = line[:s.col]
+		}
+	case 1:
+		for i := 0; i <= s.col && i < len(line); i++ {
+			line[i] = ' '
+		}
+	case 2:
+		s.lines[s.row] = nil
+	}
+}
+
+// String returns the text on the screen, without trailing blanks.
+func (s *screen) String() string {
+	var lines []string
+	for _, line := range s.lines {
+		lines = append(lines, strings.TrimRight(string(line), " "))
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
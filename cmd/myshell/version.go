@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+	"slices"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+)
+
+// version is the version of the shell, set when building a release with
+// -ldflags "-X main.version=v1.2.3". Otherwise it is taken from the module
+// version the binary was built from, if any.
+var version = ""
+
+// features lists the capabilities compiled into the shell, which
+// myshell --features prints so scripts can check for them. Those that
+// depend on the platform are added by the files implementing them.
+var features = []string{
+	"abbreviations",
+	"autopair",
+	"autosuggestions",
+	"bash-completion",
+	"bind",
+	"bookmarks",
+	"clipboard",
+	"completion-menu",
+	"correct",
+	"directory-stack",
+	"external-completers",
+	"frecency",
+	"git-prompt",
+	"help",
+	"hooks",
+	"syntax-highlighting",
+	"themes",
+}
+
+// shellVersion returns the version of the shell, or devel for a build
+// from a working tree.
+func shellVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "devel"
+}
+
+// handleInfoFlag prints what --version or --features asks for and reports
+// whether arg was one of them.
+func handleInfoFlag(arg string) bool {
+	switch arg {
+	case "--version":
+		fmt.Println("myshell", shellVersion())
+	case "--features":
+		names := slices.Clone(features)
+		slices.Sort(names)
+		for _, name := range names {
+			fmt.Println(name)
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// setVersionVar sets MYSHELL_VERSION, so that rc files and scripts can tell
+// which version runs them. It replaces one inherited from the environment
+// and isn't exported, since the commands the shell runs aren't the shell.
+func setVersionVar() {
+	interp.UnsetVar("MYSHELL_VERSION")
+	interp.SetVar("MYSHELL_VERSION", shellVersion())
+}
@@ -10,7 +10,6 @@ module github.com/codecrafters-io/shell-starter-go
 
 go 1.22
 
-require (
-	golang.org/x/sys v0.30.0 // indirect
-	golang.org/x/term v0.29.0 // indirect
-)
+require golang.org/x/term v0.29.0
+
+require golang.org/x/sys v0.30.0
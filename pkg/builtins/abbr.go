@@ -0,0 +1,59 @@
+package builtins
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+	"github.com/codecrafters-io/shell-starter-go/pkg/lexer"
+)
+
+func Abbr(c *interp.Command) int {
+	abbreviations := c.Shell().Abbreviations
+	args := c.Args
+	if len(args) > 0 && (args[0] == "-a" || args[0] == "--add") {
+		args = args[1:]
+	}
+	switch {
+	case len(args) == 0 || args[0] == "-l" || args[0] == "--list":
+		names := make([]string, 0, len(abbreviations))
+		for name := range abbreviations {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			fmt.Fprintln(c.Stdout, "abbr", lexer.Quote(name), lexer.Quote(abbreviations[name]))
+		}
+	case args[0] == "-e" || args[0] == "--erase":
+		status := 0
+		for _, name := range args[1:] {
+			if _, ok := abbreviations[name]; !ok {
+				status = c.Errorf(1, "%s: no such abbreviation", name)
+				continue
+			}
+			delete(abbreviations, name)
+		}
+		return status
+	case len(args) == 1:
+		return c.Errorf(1, "%s: missing expansion", args[0])
+	default:
+		if strings.ContainsFunc(args[0], unicode.IsSpace) {
+			return c.Errorf(1, "%s: abbreviation cannot contain spaces", args[0])
+		}
+		abbreviations[args[0]] = strings.Join(args[1:], " ")
+	}
+	return 0
+}
+
+// Abbreviation returns the expansion of word if it is an abbreviation of
+// the session, for the line editor to expand it in command position. There
+// are none with the posix option.
+func Abbreviation(word string) (string, bool) {
+	if interp.Option("posix") {
+		return "", false
+	}
+	expansion, ok := interp.Session().Abbreviations[word]
+	return expansion, ok
+}
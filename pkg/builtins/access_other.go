@@ -0,0 +1,15 @@
+//go:build !unix
+
+package builtins
+
+import "os"
+
+// canAccess reports whether path's permission bits allow mode, a mask of 4
+// for read, 2 for write and 1 for execute.
+func canAccess(path string, mode uint32) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return uint32(info.Mode().Perm())>>6&mode == mode
+}
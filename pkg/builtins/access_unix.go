@@ -0,0 +1,11 @@
+//go:build unix
+
+package builtins
+
+import "syscall"
+
+// canAccess reports whether the shell may access path in mode, a mask of 4
+// for read, 2 for write and 1 for execute.
+func canAccess(path string, mode uint32) bool {
+	return syscall.Access(path, mode) == nil
+}
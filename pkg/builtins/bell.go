@@ -0,0 +1,28 @@
+package builtins
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+)
+
+// flashTime is how long bell -v shows the screen in reverse video.
+const flashTime = 100 * time.Millisecond
+
+// Bell rings the terminal's bell, or with -v flashes the screen by showing
+// it in reverse video for a moment.
+func Bell(c *interp.Command) int {
+	switch {
+	case len(c.Args) == 0:
+		fmt.Fprint(c.Stdout, "\a")
+	case len(c.Args) == 1 && c.Args[0] == "-v":
+		fmt.Fprint(c.Stdout, "\x1b[?5h")
+		time.Sleep(flashTime)
+		fmt.Fprint(c.Stdout, "\x1b[?5l")
+	default:
+		fmt.Fprintln(c.Stderr, "usage: bell [-v]")
+		return 2
+	}
+	return 0
+}
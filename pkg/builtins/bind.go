@@ -0,0 +1,73 @@
+package builtins
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+	"github.com/codecrafters-io/shell-starter-go/pkg/lineedit"
+)
+
+// Bind binds key sequences to editing functions or macros, given in the
+// form of readline's inputrc:
+//
+//	bind '"\C-a": beginning-of-line' '"\C-g": "git status\n"'
+//
+// With -p or no arguments it lists the bindings in that form, with -l the
+// editing functions, -r removes the binding of a sequence and -f reads
+// bindings from a file, one per line.
+func Bind(c *interp.Command) int {
+	if len(c.Args) == 0 || c.Args[0] == "-p" {
+		for _, binding := range lineedit.Bindings() {
+			fmt.Fprintln(c.Stdout, binding)
+		}
+		return 0
+	}
+	switch c.Args[0] {
+	case "-l":
+		for _, name := range lineedit.Functions() {
+			fmt.Fprintln(c.Stdout, name)
+		}
+		return 0
+	case "-r":
+		if len(c.Args) != 2 {
+			fmt.Fprintln(c.Stderr, "usage: bind -r keyseq")
+			return 1
+		}
+		if err := lineedit.Unbind(c.Args[1]); err != nil {
+			return c.Errorf(1, "%s: %v", c.Args[1], err)
+		}
+		return 0
+	case "-f":
+		if len(c.Args) != 2 {
+			fmt.Fprintln(c.Stderr, "usage: bind -f file")
+			return 1
+		}
+		f, err := os.Open(c.Shell().Abs(c.Args[1]))
+		if err != nil {
+			return c.Errorf(1, "%s: No such file or directory", c.Args[1])
+		}
+		defer f.Close()
+		status := 0
+		scanner := bufio.NewScanner(f)
+		for n := 1; scanner.Scan(); n++ {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if err := lineedit.Bind(line); err != nil {
+				status = c.Errorf(1, "%s:%d: %v", c.Args[1], n, err)
+			}
+		}
+		return status
+	}
+	status := 0
+	for _, arg := range c.Args {
+		if err := lineedit.Bind(arg); err != nil {
+			status = c.Errorf(1, "%s: %v", arg, err)
+		}
+	}
+	return status
+}
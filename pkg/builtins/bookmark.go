@@ -0,0 +1,131 @@
+package builtins
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+)
+
+func bookmarksFile(sh *interp.Shell) string {
+	return filepath.Join(sh.DataDir(), "bookmarks")
+}
+
+// loadBookmarks reads the bookmarks file, which has a name and a directory
+// separated by a tab on each line.
+func loadBookmarks(sh *interp.Shell) map[string]string {
+	bookmarks := map[string]string{}
+	f, err := os.Open(bookmarksFile(sh))
+	if err != nil {
+		return bookmarks
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if name, dir, ok := strings.Cut(scanner.Text(), "\t"); ok && name != "" {
+			bookmarks[name] = dir
+		}
+	}
+	return bookmarks
+}
+
+func saveBookmarks(sh *interp.Shell, bookmarks map[string]string) error {
+	var sb strings.Builder
+	for _, name := range sortedKeys(bookmarks) {
+		sb.WriteString(name + "\t" + bookmarks[name] + "\n")
+	}
+	file := bookmarksFile(sh)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, []byte(sb.String()), 0600)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// expandBookmark replaces a leading @name in dir with the directory
+// bookmarked as name, so that cd @proj/src works.
+func expandBookmark(sh *interp.Shell, dir string) string {
+	if !strings.HasPrefix(dir, "@") {
+		return dir
+	}
+	name, rest, _ := strings.Cut(dir[1:], "/")
+	target, ok := loadBookmarks(sh)[name]
+	if !ok {
+		return dir
+	}
+	return filepath.Join(target, rest)
+}
+
+// Bookmark manages named directories, which cd reaches as @name:
+//
+//	bookmark add proj ~/src/project
+//	bookmark list
+//	bookmark rm proj
+func Bookmark(c *interp.Command) int {
+	sh := c.Shell()
+	bookmarks := loadBookmarks(sh)
+	if len(c.Args) == 0 || c.Args[0] == "list" {
+		names := sortedKeys(bookmarks)
+		width := 0
+		for _, name := range names {
+			width = max(width, len(name))
+		}
+		for _, name := range names {
+			fmt.Fprintf(c.Stdout, "%-*s  %s\n", width, name, sh.TildePath(bookmarks[name]))
+		}
+		return 0
+	}
+	switch c.Args[0] {
+	case "add":
+		if len(c.Args) < 2 || len(c.Args) > 3 {
+			fmt.Fprintln(c.Stderr, "usage: bookmark add name [dir]")
+			return 1
+		}
+		name := c.Args[1]
+		if strings.ContainsFunc(name, func(r rune) bool { return unicode.IsSpace(r) || r == '/' }) {
+			return c.Errorf(1, "%s: name cannot contain spaces or slashes", name)
+		}
+		dir := sh.WorkingDir()
+		if len(c.Args) == 3 {
+			target := c.Args[2]
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(dir, target)
+			}
+			dir = target
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return c.Errorf(1, "%s: No such file or directory", dir)
+		}
+		bookmarks[name] = filepath.Clean(dir)
+	case "rm":
+		if len(c.Args) < 2 {
+			fmt.Fprintln(c.Stderr, "usage: bookmark rm name...")
+			return 1
+		}
+		for _, name := range c.Args[1:] {
+			if _, ok := bookmarks[name]; !ok {
+				return c.Errorf(1, "%s: no such bookmark", name)
+			}
+			delete(bookmarks, name)
+		}
+	default:
+		return c.Errorf(1, "%s: unknown subcommand", c.Args[0])
+	}
+	if err := saveBookmarks(sh, bookmarks); err != nil {
+		return c.Errorf(1, "%v", err)
+	}
+	return 0
+}
@@ -0,0 +1,228 @@
+// Package builtins implements the commands built into the shell and
+// registers them with the interpreter.
+package builtins
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+)
+
+// Register registers every builtin with the interpreter, and has the
+// working directory recorded in the frecency database j jumps with.
+func Register() {
+	for _, b := range []struct {
+		name string
+		run  func(c *interp.Command) int
+	}{
+		{"exit", Exit},
+		{"echo", Echo},
+		{"type", Type},
+		{"pwd", PWD},
+		{"cd", CD},
+		{"set", Set},
+		{"abbr", Abbr},
+		{"history", History},
+		{"command", Command},
+		{"export", Export},
+		{"unset", Unset},
+		{"vars", Vars},
+		{"which", Which},
+		{"hash", Hash},
+		{"rehash", Rehash},
+		{"in", In},
+		{"source", Source},
+		{".", Source},
+		{"defer", Defer},
+		{"return", Return},
+		{"break", Break},
+		{"continue", Continue},
+		{"local", Local},
+		{"retry", Retry},
+		{"limit", Limit},
+		{"contain", Contain},
+		{"timeout", Timeout},
+		{"nice", Nice},
+		{"jobs", Jobs},
+		{"wait", Wait},
+		{"bell", Bell},
+		{"config", Config},
+		{"[[", Cond},
+		{"times", Times},
+		{"pushd", Pushd},
+		{"popd", Popd},
+		{"dirs", Dirs},
+		{"bookmark", Bookmark},
+		{"j", J},
+		{"hook", Hook},
+		{"theme", Theme},
+		{"bind", Bind},
+		{"complete", Complete},
+		{"compgen", Compgen},
+		{"record", Record},
+		{"stats", Stats},
+		{"help", Help},
+	} {
+		interp.Register(&interp.Builtin{Name: b.name, Run: b.run, Flags: flagsOf(b.name)})
+	}
+	interp.ChdirFuncs = append(interp.ChdirFuncs, recordVisit)
+}
+
+// Exit exits the shell with the status given, or that of the last command,
+// once the user agrees to if commands still run in the background.
+func Exit(c *interp.Command) int {
+	sh := c.Shell()
+	if !c.ConfirmExit() {
+		return 1
+	}
+	if len(c.Args) == 0 {
+		sh.Exit(sh.LastStatus())
+	}
+	code, err := strconv.Atoi(c.Args[0])
+	if err != nil {
+		sh.Exit(c.Errorf(2, "%s: numeric argument required", c.Args[0]))
+	}
+	sh.Exit(code)
+	return code
+}
+
+func Echo(c *interp.Command) int {
+	fmt.Fprintln(c.Stdout, strings.Join(c.Args, " "))
+	return 0
+}
+
+// Type describes how each name would be resolved. -a lists every match,
+// -t prints only the kind of the first and -p only its path.
+func Type(c *interp.Command) int {
+	sh := c.Shell()
+	all, kindOnly, pathOnly := false, false, false
+	names := c.Args
+	for len(names) > 0 && strings.HasPrefix(names[0], "-") && len(names[0]) > 1 {
+		for _, flag := range names[0][1:] {
+			switch flag {
+			case 'a':
+				all = true
+			case 't':
+				kindOnly = true
+			case 'p':
+				pathOnly = true
+			default:
+				return c.Errorf(1, "-%c: invalid option", flag)
+			}
+		}
+		names = names[1:]
+	}
+	if len(names) == 0 {
+		return c.Errorf(1, "missing argument")
+	}
+	status := 0
+	for _, name := range names {
+		res := sh.Resolve(name, all)
+		if len(res) == 0 {
+			if !kindOnly && !pathOnly {
+				sh.Report(c.Stderr, &interp.Error{Cmd: name, Msg: "not found", Status: 1})
+			}
+			status = 1
+			continue
+		}
+		for _, r := range res {
+			switch {
+			case kindOnly:
+				fmt.Fprintln(c.Stdout, r.Kind)
+			case pathOnly:
+				if r.Kind == interp.KindFile {
+					fmt.Fprintln(c.Stdout, r.Path)
+				}
+			case r.Kind == interp.KindFunction:
+				fmt.Fprintln(c.Stdout, name, "is a function")
+			case r.Kind == interp.KindBuiltin:
+				fmt.Fprintln(c.Stdout, name, "is a shell builtin")
+			default:
+				fmt.Fprintln(c.Stdout, name, "is", r.Path)
+			}
+		}
+	}
+	return status
+}
+
+// PWD prints the working directory: with -L, the default, the logical one
+// kept in PWD, and with -P the physical one with symlinks resolved.
+func PWD(c *interp.Command) int {
+	sh := c.Shell()
+	physical := false
+	for _, arg := range c.Args {
+		switch arg {
+		case "-L":
+			physical = false
+		case "-P":
+			physical = true
+		default:
+			return c.Errorf(1, "%s: invalid option", arg)
+		}
+	}
+	dir := sh.WorkingDir()
+	if physical {
+		var err error
+		if dir, err = sh.PhysicalDir(); err != nil {
+			return c.Errorf(1, "%v", err)
+		}
+	}
+	fmt.Fprintln(c.Stdout, dir)
+	return 0
+}
+
+// CD changes the working directory. With -L, the default, PWD keeps the
+// symlinks the directory was reached through, and with -P they are
+// resolved.
+func CD(c *interp.Command) int {
+	args, physical := c.Args, false
+	for len(args) > 0 && (args[0] == "-L" || args[0] == "-P") {
+		physical = args[0] == "-P"
+		args = args[1:]
+	}
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return 0
+	}
+	sh := c.Shell()
+	dir := expandBookmark(sh, args[0])
+	if dir == "~" {
+		dir = sh.GetVar("HOME")
+	}
+	if err := sh.ChangeDir(dir, physical); err != nil {
+		found, ok := searchCDPath(sh, dir)
+		if !ok || sh.ChangeDir(found, physical) != nil {
+			if fix, ok := c.CorrectDir(dir); ok && sh.ChangeDir(fix, physical) == nil {
+				return 0
+			}
+			return c.Errorf(1, "%s: No such file or directory", dir)
+		}
+		fmt.Fprintln(c.Stdout, sh.GetVar("PWD"))
+	}
+	return 0
+}
+
+// searchCDPath looks for the directory dir under each directory listed in
+// $CDPATH of sh and returns the first match. Only plain relative names are
+// searched for, not absolute ones or those starting with . or ..
+func searchCDPath(sh *interp.Shell, dir string) (string, bool) {
+	if filepath.IsAbs(dir) || dir == "." || dir == ".." || strings.HasPrefix(dir, "./") || strings.HasPrefix(dir, "../") {
+		return "", false
+	}
+	for _, root := range filepath.SplitList(sh.GetVar("CDPATH")) {
+		if root == "" {
+			continue
+		}
+		path := filepath.Join(sh.Abs(root), dir)
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			return path, true
+		}
+	}
+	return "", false
+}
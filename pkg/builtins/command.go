@@ -0,0 +1,79 @@
+package builtins
+
+import (
+	"fmt"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+)
+
+// Command runs a builtin or PATH executable directly. Abbreviations are
+// only expanded in command position, so `command name` never sees them.
+// With -v it prints how each name would be resolved instead, and with -V
+// it describes it the way type does.
+func Command(c *interp.Command) int {
+	args := c.Args
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return 0
+	}
+	switch args[0] {
+	case "-v", "-V":
+		status := 0
+		for _, name := range args[1:] {
+			res := c.Shell().Resolve(name, false)
+			if len(res) == 0 {
+				status = 1
+			}
+			switch {
+			case len(res) == 0 && args[0] == "-V":
+				c.Errorf(1, "%s: not found", name)
+			case len(res) == 0:
+			case args[0] == "-v" && res[0].Kind != interp.KindFile:
+				fmt.Fprintln(c.Stdout, name)
+			case args[0] == "-v":
+				fmt.Fprintln(c.Stdout, res[0].Path)
+			case res[0].Kind == interp.KindFunction:
+				fmt.Fprintln(c.Stdout, name, "is a function")
+			case res[0].Kind == interp.KindBuiltin:
+				fmt.Fprintln(c.Stdout, name, "is a shell builtin")
+			default:
+				fmt.Fprintln(c.Stdout, name, "is", res[0].Path)
+			}
+		}
+		return status
+	}
+	inner := *c
+	inner.Name, inner.Args = args[0], args[1:]
+	return inner.Exec()
+}
+
+// Which prints what each name runs as: the path of an executable or a note
+// that it is a builtin. With -a it prints every match rather than the
+// first.
+func Which(c *interp.Command) int {
+	names, all := c.Args, false
+	if len(names) > 0 && names[0] == "-a" {
+		names, all = names[1:], true
+	}
+	status := 0
+	for _, name := range names {
+		res := c.Shell().Resolve(name, all)
+		if len(res) == 0 {
+			status = c.Errorf(1, "%s: not found", name)
+			continue
+		}
+		for _, r := range res {
+			switch r.Kind {
+			case interp.KindFunction:
+				fmt.Fprintln(c.Stdout, name+": shell function")
+			case interp.KindBuiltin:
+				fmt.Fprintln(c.Stdout, name+": shell built-in command")
+			default:
+				fmt.Fprintln(c.Stdout, r.Path)
+			}
+		}
+	}
+	return status
+}
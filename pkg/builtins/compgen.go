@@ -0,0 +1,132 @@
+package builtins
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+	"github.com/codecrafters-io/shell-starter-go/pkg/lexer"
+)
+
+// Complete registers how the arguments of commands are completed: to a list
+// of words with -W, to the lines a command prints with -F, or to the JSON
+// candidates an external completer prints with -J:
+//
+//	complete -W 'start stop status' svc
+//	complete -F 'ls $HOME/src' proj
+//	complete -J 'svc-completer --json' svc
+//
+// -p lists the registrations, of the commands given or all of them, and -r
+// removes them.
+func Complete(c *interp.Command) int {
+	sh := c.Shell()
+	args := c.Args
+	if len(args) == 0 || args[0] == "-p" {
+		names := args
+		if len(names) > 0 {
+			names = names[1:]
+		}
+		if len(names) == 0 {
+			names = sortedKeys(sh.CompletionSpecs)
+		}
+		status := 0
+		for _, name := range names {
+			spec, ok := sh.CompletionSpecs[name]
+			if !ok {
+				status = c.Errorf(1, "%s: no completion specification", name)
+				continue
+			}
+			def := []string{"complete"}
+			if spec.Words != nil {
+				def = append(def, "-W", lexer.Quote(strings.Join(spec.Words, " ")))
+			}
+			if spec.Function != "" {
+				def = append(def, "-F", lexer.Quote(spec.Function))
+			}
+			if spec.Program != "" {
+				def = append(def, "-J", lexer.Quote(spec.Program))
+			}
+			fmt.Fprintln(c.Stdout, strings.Join(append(def, lexer.Quote(name)), " "))
+		}
+		return status
+	}
+	if args[0] == "-r" {
+		status := 0
+		for _, name := range args[1:] {
+			if _, ok := sh.CompletionSpecs[name]; !ok {
+				status = c.Errorf(1, "%s: no completion specification", name)
+				continue
+			}
+			delete(sh.CompletionSpecs, name)
+		}
+		return status
+	}
+	spec := &interp.CompletionSpec{}
+	for len(args) > 1 && (args[0] == "-W" || args[0] == "-F" || args[0] == "-J") {
+		switch args[0] {
+		case "-W":
+			spec.Words = strings.Fields(args[1])
+		case "-F":
+			spec.Function = args[1]
+		case "-J":
+			spec.Program = args[1]
+		}
+		args = args[2:]
+	}
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+	if len(args) == 0 || (spec.Words == nil && spec.Function == "" && spec.Program == "") {
+		fmt.Fprintln(c.Stderr, "usage: complete [-W wordlist] [-F command] [-J completer] name... | complete -p [name...] | complete -r name...")
+		return 2
+	}
+	for _, name := range args {
+		sh.CompletionSpecs[name] = spec
+	}
+	return 0
+}
+
+// Compgen prints the completions of a word, one per line, from the sources
+// its flags select: -W a list of words, -b builtins, -c commands, -d
+// directories, -f files and directories, -u users and -v variables.
+func Compgen(c *interp.Command) int {
+	args := c.Args
+	var words []string
+	sources := ""
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") && args[0] != "--" {
+		if args[0] == "-W" {
+			if len(args) < 2 {
+				return c.Errorf(2, "-W: option requires an argument")
+			}
+			words = append(words, strings.Fields(args[1])...)
+			args = args[2:]
+			continue
+		}
+		for _, flag := range args[0][1:] {
+			if !strings.ContainsRune("bcdfuv", flag) {
+				return c.Errorf(2, "-%c: invalid option", flag)
+			}
+		}
+		sources += args[0][1:]
+		args = args[1:]
+	}
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+	if (sources == "" && words == nil) || len(args) > 1 {
+		fmt.Fprintln(c.Stderr, "usage: compgen [-bcdfuv] [-W wordlist] [--] [word]")
+		return 2
+	}
+	word := ""
+	if len(args) == 1 {
+		word = args[0]
+	}
+	names := c.Shell().Compgen(sources, words, word)
+	if len(names) == 0 {
+		return 1
+	}
+	for _, name := range names {
+		fmt.Fprintln(c.Stdout, name)
+	}
+	return 0
+}
@@ -0,0 +1,299 @@
+package builtins
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+	"github.com/codecrafters-io/shell-starter-go/pkg/lexer"
+)
+
+// Cond evaluates a [[ ]] conditional expression, returning 0 if it holds,
+// 1 if it doesn't and 2 if it can't be evaluated:
+//
+//	[[ -f go.mod && $GOOS == linux* ]]
+//	[[ $line =~ ^([a-z]+)=(.*)$ ]] && echo ${BASH_REMATCH[1]}
+//
+// Quoted characters keep a backslash in front of them, so the right of ==
+// and != is a pattern and the right of =~ a regular expression only where
+// unquoted. Operators, parentheses and ]] must be separate words.
+func Cond(c *interp.Command) int {
+	args := c.Args
+	if len(args) == 0 || args[len(args)-1] != "]]" {
+		return c.Errorf(2, "missing `]]'")
+	}
+	p := &condParser{sh: c.Shell(), args: args[:len(args)-1]}
+	expr, err := p.or()
+	if err == nil && p.pos < len(p.args) {
+		err = fmt.Errorf("%s: unexpected argument", unescape(p.args[p.pos]))
+	}
+	if err != nil {
+		return c.Errorf(2, "%v", err)
+	}
+	ok, err := expr()
+	if err != nil {
+		return c.Errorf(2, "%v", err)
+	}
+	if !ok {
+		return 1
+	}
+	return 0
+}
+
+// condExpr evaluates a parsed conditional expression.
+type condExpr func() (bool, error)
+
+// condParser parses the arguments of [[ ]] into a condExpr, with ! binding
+// tighter than &&, and && tighter than ||, for them to be evaluated in sh.
+type condParser struct {
+	sh   *interp.Shell
+	args []string
+	pos  int
+}
+
+var errCondEnd = errors.New("unexpected end of expression")
+
+func (p *condParser) peek() string {
+	if p.pos < len(p.args) {
+		return p.args[p.pos]
+	}
+	return ""
+}
+
+func (p *condParser) or() (condExpr, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.pos++
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func() (bool, error) {
+			if ok, err := l(); ok || err != nil {
+				return ok, err
+			}
+			return right()
+		}
+	}
+	return left, nil
+}
+
+func (p *condParser) and() (condExpr, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.pos++
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func() (bool, error) {
+			if ok, err := l(); !ok || err != nil {
+				return ok, err
+			}
+			return right()
+		}
+	}
+	return left, nil
+}
+
+func (p *condParser) not() (condExpr, error) {
+	if p.peek() != "!" {
+		return p.primary()
+	}
+	p.pos++
+	expr, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	return func() (bool, error) {
+		ok, err := expr()
+		return !ok, err
+	}, nil
+}
+
+func (p *condParser) primary() (condExpr, error) {
+	if p.pos >= len(p.args) {
+		return nil, errCondEnd
+	}
+	tok := p.args[p.pos]
+	switch {
+	case tok == "(":
+		p.pos++
+		expr, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, errors.New("expected `)'")
+		}
+		p.pos++
+		return expr, nil
+	case tok == ")" || tok == "&&" || tok == "||":
+		return nil, fmt.Errorf("%s: unexpected operator", tok)
+	case p.pos+1 < len(p.args) && condBinary[p.args[p.pos+1]]:
+		if p.pos+2 >= len(p.args) {
+			return nil, fmt.Errorf("%s: missing operand", p.args[p.pos+1])
+		}
+		left, op, right := tok, p.args[p.pos+1], p.args[p.pos+2]
+		p.pos += 3
+		return func() (bool, error) { return condCompare(p.sh, left, op, right) }, nil
+	case condUnary[tok] && p.pos+1 < len(p.args):
+		operand := p.args[p.pos+1]
+		p.pos += 2
+		return func() (bool, error) { return condTest(p.sh, tok, unescape(operand)), nil }, nil
+	}
+	p.pos++
+	return func() (bool, error) { return unescape(tok) != "", nil }, nil
+}
+
+// condUnary and condBinary hold the operators [[ ]] understands.
+var (
+	condUnary = map[string]bool{
+		"-z": true, "-n": true, "-e": true, "-f": true, "-d": true, "-r": true,
+		"-w": true, "-x": true, "-s": true, "-L": true, "-h": true,
+	}
+	condBinary = map[string]bool{
+		"==": true, "=": true, "!=": true, "=~": true, "<": true, ">": true,
+		"-eq": true, "-ne": true, "-lt": true, "-le": true, "-gt": true, "-ge": true,
+		"-nt": true, "-ot": true,
+	}
+)
+
+// condTest applies the unary operator op to operand, taking files relative
+// to the working directory of sh.
+func condTest(sh *interp.Shell, op, operand string) bool {
+	switch op {
+	case "-z":
+		return operand == ""
+	case "-n":
+		return operand != ""
+	}
+	path := sh.Abs(operand)
+	switch op {
+	case "-L", "-h":
+		info, err := os.Lstat(path)
+		return err == nil && info.Mode()&os.ModeSymlink != 0
+	case "-r":
+		return canAccess(path, 4)
+	case "-w":
+		return canAccess(path, 2)
+	case "-x":
+		return canAccess(path, 1)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	switch op {
+	case "-f":
+		return info.Mode().IsRegular()
+	case "-d":
+		return info.IsDir()
+	case "-s":
+		return info.Size() > 0
+	}
+	return true
+}
+
+// condCompare applies the binary operator op to left and right, which may
+// hold backslash-escaped quoted characters, in sh.
+func condCompare(sh *interp.Shell, left, op, right string) (bool, error) {
+	switch op {
+	case "==", "=":
+		return lexer.MatchPattern(right, unescape(left)), nil
+	case "!=":
+		return !lexer.MatchPattern(right, unescape(left)), nil
+	case "=~":
+		return matchRegexp(sh, right, unescape(left))
+	case "<":
+		return unescape(left) < unescape(right), nil
+	case ">":
+		return unescape(left) > unescape(right), nil
+	case "-nt", "-ot":
+		l, lerr := os.Stat(sh.Abs(unescape(left)))
+		r, rerr := os.Stat(sh.Abs(unescape(right)))
+		if op == "-ot" {
+			l, lerr, r, rerr = r, rerr, l, lerr
+		}
+		return lerr == nil && (rerr != nil || l.ModTime().After(r.ModTime())), nil
+	}
+	l, err := strconv.ParseInt(strings.TrimSpace(unescape(left)), 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("%s: integer expression expected", unescape(left))
+	}
+	r, err := strconv.ParseInt(strings.TrimSpace(unescape(right)), 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("%s: integer expression expected", unescape(right))
+	}
+	switch op {
+	case "-eq":
+		return l == r, nil
+	case "-ne":
+		return l != r, nil
+	case "-lt":
+		return l < r, nil
+	case "-le":
+		return l <= r, nil
+	case "-gt":
+		return l > r, nil
+	}
+	return l >= r, nil
+}
+
+// matchRegexp reports whether the regular expression pattern matches s and
+// stores the match and its groups in BASH_REMATCH of sh. Escaped characters
+// in pattern match literally.
+func matchRegexp(sh *interp.Shell, pattern, s string) (bool, error) {
+	var sb strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] == '\\' && i+1 < len(pattern) {
+			_, n := utf8.DecodeRuneInString(pattern[i+1:])
+			sb.WriteString(regexp.QuoteMeta(pattern[i+1 : i+1+n]))
+			i += n
+			continue
+		}
+		sb.WriteByte(pattern[i])
+	}
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return false, fmt.Errorf("%s: invalid regular expression", unescape(pattern))
+	}
+	m := re.FindStringSubmatch(s)
+	if m == nil {
+		m = []string{}
+	}
+	sh.SetArray("BASH_REMATCH", m)
+	return len(m) > 0, nil
+}
+
+// unescape removes the backslashes lexer.Split keeps in front of quoted
+// characters.
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	escaped := false
+	for _, c := range s {
+		if c == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		escaped = false
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
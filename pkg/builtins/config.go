@@ -0,0 +1,254 @@
+package builtins
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/config"
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+	"github.com/codecrafters-io/shell-starter-go/pkg/lineedit"
+)
+
+// ConfigFile returns the path of the config file of sh: MYSHELL_CONFIG if
+// set, or config.toml in the shell's config directory.
+func ConfigFile(sh *interp.Shell) string {
+	if file := sh.GetVar("MYSHELL_CONFIG"); file != "" {
+		return file
+	}
+	dir := sh.GetVar("XDG_CONFIG_HOME")
+	if dir == "" {
+		dir = filepath.Join(sh.GetVar("HOME"), ".config")
+	}
+	return filepath.Join(dir, "myshell", "config.toml")
+}
+
+// LoadConfig applies the settings of the config file, a declarative
+// alternative to setting them in the rc file, which is read after it and
+// so overrides it. Options given as flags to the shell override both.
+//
+//	[options]
+//	syntax-highlighting = true
+//
+//	[prompt]
+//	theme = "powerline"       # or ps1, ps2 and rprompt
+//
+//	[keys]
+//	'\C-x\C-e' = "edit-command-line"
+//
+//	[aliases]
+//	gs = "git status"         # abbreviations, as abbr defines
+//
+//	[completion]
+//	make = ["build", "test"]  # candidates, as complete -W
+//	[completion.git]
+//	function = "_git"         # or words and program, as -W and -J
+//
+//	[hooks]
+//	chpwd = ["~/bin/on-cd"]   # executables run when the event happens
+//
+// The settings are applied to sh. A setting that can't be applied doesn't
+// keep the others from being; the error returned lists each.
+func LoadConfig(sh *interp.Shell, file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	root, err := config.Parse(string(data))
+	if err != nil {
+		return fmt.Errorf("%s: %v", file, err)
+	}
+	// Plugins are only declared here, so the file's are all there are.
+	sh.HookPlugins = map[string][]string{}
+	var errs []error
+	for _, section := range sortedKeys(root) {
+		apply, ok := configSections[section]
+		t, isTable := root[section].(config.Table)
+		if !ok || !isTable {
+			errs = append(errs, fmt.Errorf("%s: %s: unknown section", file, section))
+			continue
+		}
+		for _, key := range sortedKeys(t) {
+			if err := apply(sh, key, t[key]); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s.%s: %v", file, section, key, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// configSections maps each section of the config file to the function
+// applying a setting in it.
+var configSections = map[string]func(sh *interp.Shell, key string, value any) error{
+	"options":    configOption,
+	"prompt":     configPrompt,
+	"keys":       configKey,
+	"aliases":    configAlias,
+	"completion": configCompletion,
+	"hooks":      configHook,
+}
+
+// promptVars maps the keys of the prompt section to the variables they
+// set.
+var promptVars = map[string]string{"ps1": "PS1", "ps2": "PS2", "rprompt": "RPROMPT"}
+
+func configOption(sh *interp.Shell, name string, value any) error {
+	on, ok := value.(bool)
+	if !ok {
+		return errors.New("expected true or false")
+	}
+	if _, given := interp.FlagOptions[name]; given {
+		return nil
+	}
+	if !sh.SetOption(name, on) {
+		return errors.New("no such option")
+	}
+	return nil
+}
+
+func configPrompt(sh *interp.Shell, key string, value any) error {
+	s, ok := value.(string)
+	if !ok {
+		return errors.New("expected a string")
+	}
+	if key == "theme" {
+		if _, ok := sh.Themes[s]; !ok && s != "" {
+			return fmt.Errorf("%s: no such theme", s)
+		}
+		sh.CurrentTheme = s
+		return nil
+	}
+	name, ok := promptVars[key]
+	if !ok {
+		return errors.New("unknown setting; expected theme, ps1, ps2 or rprompt")
+	}
+	sh.SetVar(name, s)
+	return nil
+}
+
+func configKey(sh *interp.Shell, seq string, value any) error {
+	function, ok := value.(string)
+	if !ok || !slices.Contains(lineedit.Functions(), function) {
+		return errors.New("expected the name of an editing function, as bind -l lists them")
+	}
+	return lineedit.Bind(`"` + seq + `": ` + function)
+}
+
+func configAlias(sh *interp.Shell, name string, value any) error {
+	expansion, ok := value.(string)
+	if !ok {
+		return errors.New("expected a string")
+	}
+	if strings.ContainsFunc(name, unicode.IsSpace) {
+		return errors.New("abbreviation cannot contain spaces")
+	}
+	sh.Abbreviations[name] = expansion
+	return nil
+}
+
+func configCompletion(sh *interp.Shell, command string, value any) error {
+	spec := &interp.CompletionSpec{}
+	switch v := value.(type) {
+	case []any:
+		words, err := stringList(v)
+		if err != nil {
+			return err
+		}
+		spec.Words = words
+	case config.Table:
+		for key, value := range v {
+			var err error
+			switch key {
+			case "words":
+				list, _ := value.([]any)
+				spec.Words, err = stringList(list)
+			case "function", "program":
+				s, ok := value.(string)
+				if !ok {
+					return fmt.Errorf("%s: expected a string", key)
+				}
+				if key == "function" {
+					spec.Function = s
+				} else {
+					spec.Program = s
+				}
+			default:
+				return fmt.Errorf("%s: unknown setting; expected words, function or program", key)
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %v", key, err)
+			}
+		}
+	default:
+		return errors.New("expected a list of words or a table")
+	}
+	sh.CompletionSpecs[command] = spec
+	return nil
+}
+
+func configHook(sh *interp.Shell, event string, value any) error {
+	if !slices.Contains(interp.HookEvents, event) {
+		return fmt.Errorf("unknown event; expected one of %s", strings.Join(interp.HookEvents, ", "))
+	}
+	list, _ := value.([]any)
+	plugins, err := stringList(list)
+	if err != nil {
+		return err
+	}
+	for i, plugin := range plugins {
+		if rest, ok := strings.CutPrefix(plugin, "~/"); ok {
+			plugins[i] = filepath.Join(sh.GetVar("HOME"), rest)
+		}
+	}
+	sh.HookPlugins[event] = plugins
+	return nil
+}
+
+// stringList returns the strings of a config file's array, which must
+// have nothing else.
+func stringList(values []any) ([]string, error) {
+	if values == nil {
+		return nil, errors.New("expected a list of strings")
+	}
+	list := make([]string, len(values))
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			return nil, errors.New("expected a list of strings")
+		}
+		list[i] = s
+	}
+	return list, nil
+}
+
+// Config reloads the config file, or prints its path:
+//
+//	config reload
+//	config path
+func Config(c *interp.Command) int {
+	sh := c.Shell()
+	if len(c.Args) != 1 {
+		fmt.Fprintln(c.Stderr, "usage: config reload | path")
+		return 2
+	}
+	file := ConfigFile(sh)
+	switch c.Args[0] {
+	case "reload":
+		if err := LoadConfig(sh, file); err != nil {
+			if os.IsNotExist(err) {
+				return c.Errorf(1, "%s: No such file or directory", file)
+			}
+			fmt.Fprintln(c.Stderr, err)
+			return 1
+		}
+	case "path":
+		fmt.Fprintln(c.Stdout, file)
+	default:
+		return c.Errorf(2, "%s: unknown subcommand; expected reload or path", c.Args[0])
+	}
+	return 0
+}
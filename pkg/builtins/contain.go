@@ -0,0 +1,27 @@
+package builtins
+
+import (
+	"fmt"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+)
+
+// Contain runs a command contained, for trying one that isn't trusted: the
+// executables it runs get namespaces of their own, with no network, no
+// other processes to see and a read-only root filesystem but for a tmpfs
+// on /tmp.
+//
+//	contain -- ./install.sh
+func Contain(c *interp.Command) int {
+	args := c.Args
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		fmt.Fprintln(c.Stderr, "usage: contain [--] command [args...]")
+		return 2
+	}
+	inner := c.WithContext(interp.Contain(c.Context()))
+	inner.Name, inner.Args, inner.Assignments = args[0], args[1:], nil
+	return inner.Run()
+}
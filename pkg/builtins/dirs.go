@@ -0,0 +1,162 @@
+package builtins
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+)
+
+// fullDirStack returns the directory stack of sh with the working
+// directory on top, as dirs lists it. The working directory is always the
+// top of the stack and isn't stored in sh.DirStack.
+func fullDirStack(sh *interp.Shell) []string {
+	return append([]string{sh.WorkingDir()}, sh.DirStack...)
+}
+
+// setDirStack changes sh to the first directory of stack and saves the
+// rest, updating DIRSTACK to match.
+func setDirStack(sh *interp.Shell, stack []string) error {
+	if err := sh.ChangeDir(stack[0], false); err != nil {
+		return err
+	}
+	sh.DirStack = append([]string(nil), stack[1:]...)
+	sh.SetArray("DIRSTACK", stack)
+	return nil
+}
+
+// stackIndex parses a +N or -N argument, counting from the top or the
+// bottom of a stack of n directories, and reports whether it is one.
+func stackIndex(arg string, n int) (i int, ok bool) {
+	if len(arg) < 2 || (arg[0] != '+' && arg[0] != '-') {
+		return 0, false
+	}
+	i, err := strconv.Atoi(arg[1:])
+	if err != nil || i < 0 {
+		return 0, false
+	}
+	if arg[0] == '-' {
+		i = n - 1 - i
+	}
+	return i, true
+}
+
+// Pushd saves the working directory on the directory stack and changes to
+// dir. Without arguments it swaps the top two directories, and with +N or
+// -N it rotates the Nth directory from the top or bottom to the top.
+func Pushd(c *interp.Command) int {
+	sh := c.Shell()
+	stack := fullDirStack(sh)
+	switch {
+	case len(c.Args) == 0:
+		if len(stack) < 2 {
+			return c.Errorf(1, "no other directory")
+		}
+		stack[0], stack[1] = stack[1], stack[0]
+	default:
+		if i, ok := stackIndex(c.Args[0], len(stack)); ok {
+			if i < 0 || i >= len(stack) {
+				return c.Errorf(1, "%s: directory stack index out of range", c.Args[0])
+			}
+			stack = append(stack[i:], stack[:i]...)
+			break
+		}
+		dir := c.Args[0]
+		if dir == "~" {
+			dir = sh.GetVar("HOME")
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(stack[0], dir)
+		}
+		stack = append([]string{filepath.Clean(dir)}, stack...)
+	}
+	if err := setDirStack(sh, stack); err != nil {
+		return c.Errorf(1, "%s: No such file or directory", stack[0])
+	}
+	printDirStack(c, stack, false, false, false)
+	return 0
+}
+
+// Popd removes the top directory from the directory stack and changes to
+// the new top. With +N or -N it removes the Nth directory from the top or
+// bottom instead.
+func Popd(c *interp.Command) int {
+	sh := c.Shell()
+	stack := fullDirStack(sh)
+	if len(stack) < 2 {
+		return c.Errorf(1, "directory stack empty")
+	}
+	i := 0
+	if len(c.Args) > 0 {
+		var ok bool
+		if i, ok = stackIndex(c.Args[0], len(stack)); !ok || i < 0 || i >= len(stack) {
+			return c.Errorf(1, "%s: directory stack index out of range", c.Args[0])
+		}
+	}
+	stack = append(stack[:i], stack[i+1:]...)
+	if err := setDirStack(sh, stack); err != nil {
+		return c.Errorf(1, "%s: No such file or directory", stack[0])
+	}
+	printDirStack(c, stack, false, false, false)
+	return 0
+}
+
+// Dirs lists the directory stack, top first. -c clears it, -l prints full
+// paths without ~, -p prints one directory per line and -v numbers them.
+// +N or -N prints only the Nth directory from the top or bottom.
+func Dirs(c *interp.Command) int {
+	sh := c.Shell()
+	stack := fullDirStack(sh)
+	long, perLine, numbered := false, false, false
+	for _, arg := range c.Args {
+		if i, ok := stackIndex(arg, len(stack)); ok {
+			if i < 0 || i >= len(stack) {
+				return c.Errorf(1, "%s: directory stack index out of range", arg)
+			}
+			stack = stack[i : i+1]
+			continue
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			return c.Errorf(1, "%s: invalid argument", arg)
+		}
+		for _, flag := range arg[1:] {
+			switch flag {
+			case 'c':
+				sh.DirStack = nil
+				sh.SetArray("DIRSTACK", stack[:1])
+				return 0
+			case 'l':
+				long = true
+			case 'p':
+				perLine = true
+			case 'v':
+				perLine, numbered = true, true
+			default:
+				return c.Errorf(1, "-%c: invalid option", flag)
+			}
+		}
+	}
+	printDirStack(c, stack, long, perLine, numbered)
+	return 0
+}
+
+// printDirStack writes stack to c's standard output the way dirs does.
+func printDirStack(c *interp.Command, stack []string, long, perLine, numbered bool) {
+	dirs := make([]string, len(stack))
+	for i, dir := range stack {
+		if !long {
+			dir = c.Shell().TildePath(dir)
+		}
+		if numbered {
+			dir = fmt.Sprintf("%2d  %s", i, dir)
+		}
+		dirs[i] = dir
+	}
+	if perLine {
+		fmt.Fprintln(c.Stdout, strings.Join(dirs, "\n"))
+		return
+	}
+	fmt.Fprintln(c.Stdout, strings.Join(dirs, " "))
+}
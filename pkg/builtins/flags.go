@@ -0,0 +1,123 @@
+package builtins
+
+import "github.com/codecrafters-io/shell-starter-go/pkg/interp"
+
+// builtinFlag is a flag a builtin accepts, with a short description of what
+// it does.
+type builtinFlag struct {
+	name, description string
+}
+
+// builtinFlags lists the flags each builtin accepts, for completion.
+var builtinFlags = map[string][]builtinFlag{
+	"abbr": {
+		{"-a", "add an abbreviation"},
+		{"--add", "add an abbreviation"},
+		{"-e", "erase abbreviations"},
+		{"--erase", "erase abbreviations"},
+		{"-l", "list abbreviations"},
+		{"--list", "list abbreviations"},
+	},
+	"bell": {
+		{"-v", "flash the screen instead"},
+	},
+	"bind": {
+		{"-f", "read bindings from a file"},
+		{"-l", "list editing functions"},
+		{"-p", "list bindings"},
+		{"-r", "remove a binding"},
+	},
+	"cd": {
+		{"-L", "keep symbolic links in the path"},
+		{"-P", "resolve symbolic links"},
+	},
+	"command": {
+		{"-v", "print the command a name runs"},
+		{"-V", "describe the command a name runs"},
+	},
+	"compgen": {
+		{"-W", "complete from a list of words"},
+		{"-b", "complete builtin names"},
+		{"-c", "complete command names"},
+		{"-d", "complete directories"},
+		{"-f", "complete files and directories"},
+		{"-u", "complete user names"},
+		{"-v", "complete variable names"},
+	},
+	"complete": {
+		{"-F", "complete from the lines a command prints"},
+		{"-J", "complete from the JSON an external completer prints"},
+		{"-W", "complete from a list of words"},
+		{"-p", "list the completions registered"},
+		{"-r", "remove registered completions"},
+	},
+	"hash": {
+		{"-d", "forget the locations of commands"},
+		{"-r", "forget every location"},
+	},
+	"help": {
+		{"-m", "print the reference page of a builtin"},
+	},
+	"history": {
+		{"-c", "clear the history"},
+		{"-d", "delete an entry"},
+	},
+	"in": {
+		{"--dir", "run in a directory"},
+		{"--env", "set an environment variable"},
+		{"--umask", "run with a umask"},
+	},
+	"j": {
+		{"-l", "list the matching directories"},
+	},
+	"jobs": {
+		{"-l", "list the process IDs of the jobs"},
+	},
+	"limit": {
+		{"--cpu", "limit the CPU time in seconds"},
+		{"--mem", "limit the memory"},
+		{"--files", "limit the open files"},
+		{"--time", "limit the wall-clock time"},
+	},
+	"nice": {
+		{"-n", "add to the niceness"},
+		{"--io", "set the I/O scheduling class"},
+	},
+	"pwd": {
+		{"-L", "print the path with symbolic links"},
+		{"-P", "print the path with symbolic links resolved"},
+	},
+	"retry": {
+		{"-n", "number of attempts"},
+		{"--backoff", "delay before the first retry"},
+	},
+	"set": {
+		{"-o", "turn an option on"},
+		{"+o", "turn an option off"},
+		{"-n", "check scripts without running them"},
+		{"+n", "run scripts again"},
+		{"-v", "echo input lines as they are read"},
+		{"+v", "stop echoing input lines"},
+	},
+	"stats": {
+		{"-c", "forget every command run"},
+		{"-n", "how many commands to show"},
+	},
+	"vars": {
+		{"--filter", "list the variables matching a pattern"},
+		{"--json", "print as JSON"},
+	},
+	"which": {
+		{"-a", "print every match"},
+	},
+}
+
+// flagsOf returns the flags builtin name accepts, as the interpreter
+// completes them.
+func flagsOf(name string) []interp.Flag {
+	var flags []interp.Flag
+	for _, f := range builtinFlags[name] {
+		flags = append(flags, interp.Flag{Name: f.name, Description: f.description})
+	}
+	return flags
+}
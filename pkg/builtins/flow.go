@@ -0,0 +1,64 @@
+package builtins
+
+import (
+	"strconv"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+)
+
+// Return leaves the function or sourced file running, with status, or the
+// status of the last command without one.
+func Return(c *interp.Command) int {
+	sh := c.Shell()
+	status := sh.LastStatus()
+	if len(c.Args) > 0 {
+		n, err := strconv.Atoi(c.Args[0])
+		if err != nil {
+			return c.Errorf(2, "%s: numeric argument required", c.Args[0])
+		}
+		status = n
+	}
+	if err := sh.Return(); err != nil {
+		return c.Errorf(1, "%v", err)
+	}
+	return status
+}
+
+// Break leaves the innermost loop, or with a count, that many loops.
+func Break(c *interp.Command) int {
+	n, ok := loopCount(c)
+	if !ok {
+		return 1
+	}
+	if err := c.Shell().Break(n); err != nil {
+		return c.Errorf(1, "%v", err)
+	}
+	return 0
+}
+
+// Continue starts the next iteration of the innermost loop, or with a
+// count, of the loop that many loops out.
+func Continue(c *interp.Command) int {
+	n, ok := loopCount(c)
+	if !ok {
+		return 1
+	}
+	if err := c.Shell().Continue(n); err != nil {
+		return c.Errorf(1, "%v", err)
+	}
+	return 0
+}
+
+// loopCount returns the count of loops break or continue was given, 1 by
+// default, reporting it and returning false if it isn't a positive number.
+func loopCount(c *interp.Command) (int, bool) {
+	if len(c.Args) == 0 {
+		return 1, true
+	}
+	n, err := strconv.Atoi(c.Args[0])
+	if err != nil || n < 1 {
+		c.Errorf(1, "%s: loop count out of range", c.Args[0])
+		return 0, false
+	}
+	return n, true
+}
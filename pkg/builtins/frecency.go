@@ -0,0 +1,172 @@
+package builtins
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+)
+
+// visitedDir is a directory recorded in the frecency database with how
+// often and how recently it was visited.
+type visitedDir struct {
+	path string
+	rank float64
+	last time.Time
+}
+
+// maxTotalRank is the total rank above which every rank is aged, so that
+// directories no longer visited eventually drop out.
+const maxTotalRank = 9000
+
+func frecencyFile(sh *interp.Shell) string {
+	return filepath.Join(sh.DataDir(), "dirs")
+}
+
+// score weights the rank of d by how recently it was visited.
+func (d visitedDir) score(now time.Time) float64 {
+	switch age := now.Sub(d.last); {
+	case age < time.Hour:
+		return d.rank * 4
+	case age < 24*time.Hour:
+		return d.rank * 2
+	case age < 7*24*time.Hour:
+		return d.rank / 2
+	}
+	return d.rank / 4
+}
+
+// loadVisitedDirs reads the frecency database, which has a path, a rank and
+// a Unix time separated by tabs on each line.
+func loadVisitedDirs(sh *interp.Shell) []visitedDir {
+	f, err := os.Open(frecencyFile(sh))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var dirs []visitedDir
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != 3 {
+			continue
+		}
+		rank, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			continue
+		}
+		last, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		dirs = append(dirs, visitedDir{path: fields[0], rank: rank, last: time.Unix(last, 0)})
+	}
+	return dirs
+}
+
+func saveVisitedDirs(sh *interp.Shell, dirs []visitedDir) error {
+	var sb strings.Builder
+	for _, d := range dirs {
+		fmt.Fprintf(&sb, "%s\t%g\t%d\n", d.path, d.rank, d.last.Unix())
+	}
+	file := frecencyFile(sh)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, []byte(sb.String()), 0600)
+}
+
+// recordVisit adds a visit of sh to dir to the frecency database.
+func recordVisit(sh *interp.Shell, dir string) {
+	if dir == sh.GetVar("HOME") {
+		return
+	}
+	dirs := loadVisitedDirs(sh)
+	i := slices.IndexFunc(dirs, func(d visitedDir) bool { return d.path == dir })
+	if i < 0 {
+		dirs = append(dirs, visitedDir{path: dir})
+		i = len(dirs) - 1
+	}
+	dirs[i].rank++
+	dirs[i].last = time.Now()
+	total := 0.0
+	for _, d := range dirs {
+		total += d.rank
+	}
+	if total > maxTotalRank {
+		aged := dirs[:0]
+		for _, d := range dirs {
+			if d.rank *= 0.99; d.rank >= 1 {
+				aged = append(aged, d)
+			}
+		}
+		dirs = aged
+	}
+	saveVisitedDirs(sh, dirs)
+}
+
+// matchesFragments reports whether path contains every fragment in order,
+// ignoring case.
+func matchesFragments(path string, fragments []string) bool {
+	path = strings.ToLower(path)
+	for _, f := range fragments {
+		i := strings.Index(path, strings.ToLower(f))
+		if i < 0 {
+			return false
+		}
+		path = path[i+len(f):]
+	}
+	return true
+}
+
+// J jumps to the most frecent visited directory matching every fragment,
+// in order. Without fragments, or with -l, it lists the matching
+// directories with their scores, best last.
+func J(c *interp.Command) int {
+	fragments, list := c.Args, len(c.Args) == 0
+	if len(fragments) > 0 && fragments[0] == "-l" {
+		fragments, list = fragments[1:], true
+	}
+	now := time.Now()
+	var matches []visitedDir
+	sh := c.Shell()
+	for _, d := range loadVisitedDirs(sh) {
+		if !matchesFragments(d.path, fragments) {
+			continue
+		}
+		if info, err := os.Stat(d.path); err != nil || !info.IsDir() {
+			continue
+		}
+		matches = append(matches, d)
+	}
+	slices.SortStableFunc(matches, func(a, b visitedDir) int {
+		switch sa, sb := a.score(now), b.score(now); {
+		case sa < sb:
+			return -1
+		case sa > sb:
+			return 1
+		}
+		return 0
+	})
+	if list {
+		for _, d := range matches {
+			fmt.Fprintf(c.Stdout, "%-10.1f %s\n", d.score(now), sh.TildePath(d.path))
+		}
+		return 0
+	}
+	if len(matches) == 0 {
+		return c.Errorf(1, "%s: no matching directory", strings.Join(fragments, " "))
+	}
+	dir := matches[len(matches)-1].path
+	if err := sh.ChangeDir(dir, false); err != nil {
+		return c.Errorf(1, "%s: No such file or directory", dir)
+	}
+	fmt.Fprintln(c.Stdout, sh.TildePath(dir))
+	return 0
+}
@@ -0,0 +1,62 @@
+package builtins
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+)
+
+// Rehash forgets cached command lookups and the executables indexed for
+// completion, so that executables installed since they were cached are
+// found.
+func Rehash(c *interp.Command) int {
+	c.Shell().Rehash()
+	return 0
+}
+
+// Hash lists the hash table, or with -r empties it. -d forgets the given
+// names, and names without a flag are looked up and remembered.
+func Hash(c *interp.Command) int {
+	sh := c.Shell()
+	args := c.Args
+	if len(args) == 0 {
+		table := sh.HashedCommands()
+		if len(table) == 0 {
+			fmt.Fprintln(c.Stdout, "hash: hash table empty")
+			return 0
+		}
+		names := make([]string, 0, len(table))
+		for name := range table {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		fmt.Fprintln(c.Stdout, "hits\tcommand")
+		for _, name := range names {
+			fmt.Fprintf(c.Stdout, "%4d\t%s\n", table[name].Hits, table[name].Path)
+		}
+		return 0
+	}
+	status := 0
+	switch args[0] {
+	case "-r":
+		sh.Rehash()
+		return 0
+	case "-d":
+		for _, name := range args[1:] {
+			if !sh.Unhash(name) {
+				status = c.Errorf(1, "%s: not found", name)
+			}
+		}
+		return status
+	}
+	for _, name := range args {
+		if _, ok := interp.LookupBuiltin(name); ok {
+			continue
+		}
+		if err := sh.Hash(name); err != nil {
+			status = c.Errorf(1, "%s: not found", name)
+		}
+	}
+	return status
+}
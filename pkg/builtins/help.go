@@ -0,0 +1,561 @@
+package builtins
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+	"golang.org/x/term"
+)
+
+// builtinDoc documents a builtin for help.
+type builtinDoc struct {
+	// synopsis holds its usage lines.
+	synopsis []string
+	// summary says in a line what it does.
+	summary string
+	// description is the longer account help -m gives, in paragraphs.
+	description string
+	// examples are command lines showing it in use.
+	examples []string
+}
+
+// builtinDocs documents each builtin Register registers. The options of a
+// builtin are listed from builtinFlags.
+var builtinDocs = map[string]builtinDoc{
+	".": {
+		synopsis:    []string{". file [args...]"},
+		summary:     "run the commands in a file in this shell",
+		description: `. is another name for source.`,
+		examples:    []string{". ~/.myshellrc"},
+	},
+	"[[": {
+		synopsis: []string{"[[ expression ]]"},
+		summary:  "evaluate a conditional expression",
+		description: `Evaluates the expression and returns 0 if it is true and 1 if it is false.
+Expressions test files (-e, -f, -d, -L, -r, -w, -x, -s), strings (-z, -n, ==,
+!=, <, >), numbers (-eq, -ne, -lt, -le, -gt, -ge), patterns (== and != with
+glob patterns on the right) and regular expressions (=~), combined with !,
+&&, || and parentheses.
+
+Quoted characters on the right of ==, != and =~ match literally. Operators,
+parentheses and ]] must be separate words.`,
+		examples: []string{`[[ -d build && $CI == true ]]`, `[[ $file == *.go ]]`},
+	},
+	"abbr": {
+		synopsis: []string{"abbr [-a] name expansion...", "abbr -e name...", "abbr [-l]"},
+		summary:  "define abbreviations expanded as they are typed",
+		description: `Defines name as an abbreviation of expansion. When name is typed as the first
+word of a line and followed by a space or Enter, the line editor replaces it
+with the expansion, which can then be edited.
+
+Without arguments, or with -l, the abbreviations are listed in a form that
+can be read back in. -e erases them.`,
+		examples: []string{"abbr gco git checkout", "abbr -e gco"},
+	},
+	"bell": {
+		synopsis: []string{"bell [-v]"},
+		summary:  "ring the terminal's bell",
+		description: `Rings the terminal's bell, or with -v flashes the screen instead, for
+hooks to signal with that a command finished.`,
+		examples: []string{"hook add job-done bell", "hook add long-command 'bell -v'"},
+	},
+	"bind": {
+		synopsis: []string{"bind [-p]", "bind -l", "bind -r keyseq", "bind -f file", `bind '"keyseq": function-name' | '"keyseq": "macro"'...`},
+		summary:  "bind keys to editing functions and macros",
+		description: `Binds key sequences of the line editor to editing functions, or to macros
+whose keys are replayed as if typed. Bindings are written as in readline's
+inputrc, with \C-x for Ctrl+X, \M-x or \ex for Alt+X and the usual escapes
+such as \t and \n.`,
+		examples: []string{`bind '"\C-g": "git status\n"'`, `bind '"\ew": unix-word-rubout'`, "bind -f ~/.inputrc"},
+	},
+	"bookmark": {
+		synopsis: []string{"bookmark add name [dir]", "bookmark rm name...", "bookmark list"},
+		summary:  "name directories for cd to reach as @name",
+		description: `Manages bookmarks, names for directories that cd, pushd and completion accept
+as @name. add bookmarks dir, or the working directory, as name; rm removes
+bookmarks and list prints them. Bookmarks are kept across sessions.`,
+		examples: []string{"bookmark add proj ~/src/project", "cd @proj"},
+	},
+	"break": {
+		synopsis: []string{"break [n]"},
+		summary:  "leave a loop",
+		description: `Leaves the innermost for, while or until loop, or with n, the n innermost
+ones. The rest of the loop's body is skipped.`,
+		examples: []string{"for f in *; do [[ -d $f ]] && break; done"},
+	},
+	"cd": {
+		synopsis: []string{"cd [-L | -P] dir"},
+		summary:  "change the working directory",
+		description: `Changes the working directory to dir. ~ changes to HOME and @name to a
+bookmark, and relative names not found are looked for in the directories of
+CDPATH.
+
+With -L, the default, PWD keeps the symbolic links dir was reached through,
+and with -P they are resolved.`,
+		examples: []string{"cd ~/src", "cd @proj"},
+	},
+	"command": {
+		synopsis: []string{"command name [args...]", "command -v name...", "command -V name..."},
+		summary:  "run a command without abbreviations, or describe it",
+		description: `Runs a builtin or PATH executable directly. Abbreviations are only expanded
+in command position, so command name never sees them.
+
+With -v it prints how each name would be resolved instead, and with -V it
+describes it the way type does.`,
+		examples: []string{"command -v git"},
+	},
+	"compgen": {
+		synopsis: []string{"compgen [-bcdfuv] [-W wordlist] [--] [word]"},
+		summary:  "print the completions of a word",
+		description: `Prints the completions of word, one per line, from the sources its flags
+select. It returns 1 if there are none. It is meant for the commands given to
+complete -F.`,
+		examples: []string{"compgen -d src/", "complete -F 'compgen -d -- $2' cdx"},
+	},
+	"complete": {
+		synopsis: []string{"complete [-W wordlist] [-F command] [-J completer] name...", "complete -p [name...]", "complete -r name..."},
+		summary:  "register how the arguments of commands are completed",
+		description: `Registers how the arguments of the named commands are completed: to the words
+of a list with -W, to the lines a command prints with -F, or to the JSON an
+external completer prints with -J.
+
+The command given with -F runs with the command name, the word being
+completed and the word before it as $1, $2 and $3, and COMP_WORDS,
+COMP_CWORD, COMP_LINE and COMP_POINT set as in bash. Each line it prints is a
+candidate, optionally followed by a tab and a description.
+
+The completer given with -J runs with the words of the command line after
+its own arguments and prints a JSON array of objects with a value and an
+optional description.
+
+-p lists the registrations in a form that can be read back in, and -r
+removes them.`,
+		examples: []string{"complete -W 'start stop status' svc", "complete -J 'svc-completer --json' svc"},
+	},
+	"config": {
+		synopsis: []string{"config reload", "config path"},
+		summary:  "reload the config file",
+		description: `Applies the settings of the config file again, or prints its path:
+MYSHELL_CONFIG, or config.toml in XDG_CONFIG_HOME/myshell, ~/.config/myshell
+by default. The file is TOML, with the sections options, of options on or
+off; prompt, with theme, ps1, ps2 and rprompt; keys, binding key sequences
+to editing functions; aliases, of abbreviations; and completion, of a
+list of words for a command or a table of its words, function and
+program, as complete takes them; and hooks, of the executables to run
+when events happen, as help hook describes. It is read before the rc file, which
+overrides it, and options given as flags to the shell override both.`,
+		examples: []string{"config reload", "$EDITOR $(config path)"},
+	},
+	"contain": {
+		synopsis: []string{"contain [--] command [args...]"},
+		summary:  "run a command isolated in namespaces of its own",
+		description: `Runs a command for trying it out when it isn't trusted: the executables it
+runs start in new user, mount, PID and network namespaces, with no
+network, no other processes to see, and every filesystem read-only but for
+an empty tmpfs on /tmp, and without capabilities. It needs Linux with user
+namespaces.`,
+		examples: []string{"contain -- ./install.sh", "contain ps ax"},
+	},
+	"continue": {
+		synopsis: []string{"continue [n]"},
+		summary:  "start the next iteration of a loop",
+		description: `Skips the rest of the body of the innermost for, while or until loop and
+starts its next iteration, or with n, that of the nth loop out.`,
+		examples: []string{"for f in *; do [[ -d $f ]] && continue; echo $f; done"},
+	},
+	"defer": {
+		synopsis: []string{"defer command [args...]", "defer"},
+		summary:  "run a command when the script exits",
+		description: `Registers a command to run when the enclosing script exits, after those
+registered later. At the prompt it runs when the shell exits. Without
+arguments it lists the pending commands, next to run first.`,
+		examples: []string{"defer rm -rf $tmp"},
+	},
+	"dirs": {
+		synopsis: []string{"dirs [-clpv] [+N | -N]"},
+		summary:  "list the directory stack",
+		description: `Lists the directory stack, top first. -c clears it, -l prints full paths
+without ~, -p prints one directory per line and -v numbers them. +N or -N
+prints only the Nth directory from the top or bottom.`,
+		examples: []string{"dirs -v"},
+	},
+	"echo": {
+		synopsis:    []string{"echo [args...]"},
+		summary:     "print the arguments",
+		description: `Prints the arguments separated by spaces and followed by a newline.`,
+		examples:    []string{"echo hello $USER"},
+	},
+	"exit": {
+		synopsis: []string{"exit [status]"},
+		summary:  "exit the shell",
+		description: `Exits the shell with status, or with the status of the last command without
+one. Commands registered with defer run first. At the prompt, while commands
+run in the background, it asks first, and returns 1 if told not to.`,
+		examples: []string{"exit 1"},
+	},
+	"export": {
+		synopsis: []string{"export [name[=value]...]"},
+		summary:  "export variables to the commands run",
+		description: `Marks each variable as exported, so that the commands the shell runs see it
+in their environment, setting it first when given a value. Without arguments
+the exported variables are listed.`,
+		examples: []string{"export EDITOR=vim"},
+	},
+	"hash": {
+		synopsis: []string{"hash [name...]", "hash -d name...", "hash -r"},
+		summary:  "remember where commands are found",
+		description: `Lists the hash table, which remembers where commands were found in PATH so
+running them again doesn't search PATH again. Names given are looked up and
+remembered; -d forgets them and -r empties the table.`,
+		examples: []string{"hash -r"},
+	},
+	"help": {
+		synopsis: []string{"help [name...]", "help -m name"},
+		summary:  "describe the builtins",
+		description: `Without arguments, lists the builtins with a line on each. With names, prints
+their usage and what they do, and with -m the full reference page of one,
+through PAGER (less by default) when the output is a terminal.`,
+		examples: []string{"help cd", "help -m complete"},
+	},
+	"history": {
+		synopsis: []string{"history [n]", "history -c", "history -d offset"},
+		summary:  "list or edit the command history",
+		description: `Lists the command history, numbered, or only its last n lines. -c clears it
+and -d deletes the line at offset. The history is kept in HISTFILE, by
+default history in the shell's data directory.`,
+		examples: []string{"history 20", "history -d 42"},
+	},
+	"hook": {
+		synopsis: []string{"hook add event command [args...]", "hook list [event]", "hook rm event [n]"},
+		summary:  "run commands when shell events happen",
+		description: `Adds, lists and removes the commands run when shell events happen:
+
+    chpwd     after every change of working directory
+    precmd    before each prompt is printed
+    preexec   after a line is entered, before it runs, with the line as $1
+    command-not-found
+              instead of the error for a command that isn't found, with its
+              name and arguments as the positional parameters
+    job-done  before the prompt after a command run in the background
+              finished, with its status as $1
+    long-command
+              after a line entered at the prompt ran for longer than
+              REPORTTIME, with the line as $1 and its status as $2
+
+The hooks section of the config file hooks plugins, executables, to
+events too, run after the commands with the same arguments,
+MYSHELL_HOOK_EVENT set to the event, and on their standard input a JSON
+object of the event, args, cwd, the status $? had and the shell's pid.
+Hook list shows them as comments.`,
+		examples: []string{"hook add chpwd 'ls'", "hook add job-done bell", "hook rm chpwd 1"},
+	},
+	"in": {
+		synopsis: []string{"in [--dir dir] [--umask mode] [--env name=value]... [--] command [args...]"},
+		summary:  "run a command with a one-shot directory, umask and environment",
+		description: `Runs a command with a working directory, umask and extra environment that
+are all restored once it finishes.`,
+		examples: []string{"in --dir build --umask 022 --env FOO=1 -- make"},
+	},
+	"j": {
+		synopsis: []string{"j [-l] fragment..."},
+		summary:  "jump to a frequently visited directory",
+		description: `Changes to the most frecent visited directory whose path matches every
+fragment, in order. Frecency weighs how often and how recently a directory
+was visited. Without fragments, or with -l, it lists the matching
+directories with their scores, best last.`,
+		examples: []string{"j proj src", "j -l"},
+	},
+	"jobs": {
+		synopsis: []string{"jobs [-l]"},
+		summary:  "list the commands running in the background",
+		description: `Lists the commands run in the background with &, each with the number %n
+names it by in nice and wait, whether it is running or done with its
+status, and the niceness and I/O class its executables run at, as nice
+sets them. -l lists the process IDs of the executables running too. Jobs
+that are done are listed once, then forgotten.`,
+		examples: []string{"jobs", "jobs -l"},
+	},
+	"limit": {
+		synopsis: []string{"limit [--cpu seconds] [--mem size] [--files count] [--time duration] [--] command [args...]"},
+		summary:  "run a command with resource limits",
+		description: `Runs a command with limits on the CPU time, memory and open files of each
+executable it runs, set with setrlimit before they start, and on the
+wall-clock time it takes, in seconds or a duration such as 1m30s, after
+which it is killed and the status is 124. Sizes take a K, M, G or T suffix.
+It reports when a command is killed for going over the CPU or wall-clock
+time.`,
+		examples: []string{"limit --time 30s -- curl -sS https://example.com", "limit --cpu 60 --mem 2G make"},
+	},
+	"local": {
+		synopsis: []string{"local name[=value]..."},
+		summary:  "make variables local to a function",
+		description: `Makes each variable local to the function running: it is unset until assigned
+and set back to what it was when the function returns. It can only be used
+in a function.`,
+		examples: []string{"local dir=$1 tmp"},
+	},
+	"nice": {
+		synopsis: []string{"nice [-n adjustment] [--io class[:level]] [--] command [args...]", "nice [-n adjustment] [--io class[:level]] [--] %job..."},
+		summary:  "run a command or change a job at another priority",
+		description: `Runs a command with adjustment added to the niceness of each executable it
+runs, 10 if neither -n nor --io is given, and with --io in the I/O
+scheduling class realtime, best-effort or idle, at a level from 0, the
+highest, to 7 for the first two. Lowering the niceness and the realtime
+class take privileges. I/O classes need Linux.
+
+Given %n job specs, as jobs lists them, it changes the priority of the
+executables those jobs run, and of those they start after, the way it
+would start them. jobs shows the priority of each job.`,
+		examples: []string{"nice make -j8", "nice -n 19 --io idle -- tar czf backup.tgz ~", "nice -n 5 %1"},
+	},
+	"popd": {
+		synopsis: []string{"popd [+N | -N]"},
+		summary:  "leave the directory at the top of the directory stack",
+		description: `Removes the top directory from the directory stack and changes to the new
+top. With +N or -N it removes the Nth directory from the top or bottom
+instead.`,
+		examples: []string{"popd", "popd +2"},
+	},
+	"pushd": {
+		synopsis: []string{"pushd [dir | +N | -N]"},
+		summary:  "change directory, saving the old one on the directory stack",
+		description: `Saves the working directory on the directory stack and changes to dir.
+Without arguments it swaps the top two directories, and with +N or -N it
+rotates the Nth directory from the top or bottom to the top.`,
+		examples: []string{"pushd /tmp", "pushd +1"},
+	},
+	"pwd": {
+		synopsis: []string{"pwd [-L | -P]"},
+		summary:  "print the working directory",
+		description: `Prints the working directory: with -L, the default, the logical one kept in
+PWD, and with -P the physical one with symbolic links resolved.`,
+		examples: []string{"pwd -P"},
+	},
+	"record": {
+		synopsis: []string{"record [start [file] | stop]"},
+		summary:  "record the session on the terminal",
+		description: `Starts and stops recording what is typed and shown on the terminal, with
+when, to a file asciinema can play back: file, or by default one named after
+the time in the recordings directory of the shell's data directory. Without
+arguments, prints the file being recorded to. Commands run while recording
+write to the terminal through a pipe, for their output to be recorded too,
+so they don't see a terminal: ls shows no colors or columns, and full-screen
+programs such as less and vim misbehave or refuse to run until the
+recording stops.`,
+		examples: []string{"record start", "record start demo.cast", "record stop", "asciinema play demo.cast"},
+	},
+	"rehash": {
+		synopsis: []string{"rehash"},
+		summary:  "forget cached command locations",
+		description: `Forgets the cached command lookups and the executables indexed for
+completion, so that executables installed since they were cached are found.`,
+		examples: []string{"rehash"},
+	},
+	"retry": {
+		synopsis: []string{"retry [-n attempts] [--backoff duration] [--] command [args...]"},
+		summary:  "run a command until it succeeds",
+		description: `Runs a command until it succeeds or the attempts run out, waiting between
+attempts for the backoff delay, which doubles after each failure, and
+returns the status of the last attempt.`,
+		examples: []string{"retry -n 5 --backoff 2s -- curl -fsS https://example.com"},
+	},
+	"return": {
+		synopsis: []string{"return [status]"},
+		summary:  "return from a function or sourced file",
+		description: `Leaves the function or sourced file running, skipping the rest of it, with
+status, or with the status of the last command without one.`,
+		examples: []string{"[[ -n $1 ]] || return 1"},
+	},
+	"set": {
+		synopsis: []string{"set [-o name | +o name | -letters | +letters]...", "set -o", "set +o"},
+		summary:  "turn shell options on and off",
+		description: `Turns options on with -o and off with +o. Some options also have a letter to
+turn them on and off with: -n for noexec, which has scripts checked for
+syntax errors without running their commands, and -v for verbose, which
+echoes each line of input to standard error as it is read. With the audit
+option, each command run is logged as a line of JSON with the time, working
+directory, arguments, exit status and duration, to AUDITLOG, or to the
+system log if it is syslog, by default audit.log in the shell's data
+directory. The posix option makes the shell behave more like a POSIX sh,
+for running scripts written for one: it turns abbreviations and spelling
+correction off, splits the values of parameters outside quotes into words
+at the characters in IFS, and runs special builtins like export and set
+rather than functions of the same name. Without arguments it prints every
+variable as an assignment and every function as its definition, which can
+be read back in. With just -o it lists every option, whether it is on and
+what it does, and with just +o prints the set commands that turn them on
+and off as they are, to read back in.`,
+		examples: []string{"set -o autosuggestions", "set +o completion-fuzzy", "set -n", "set -v", "AUDITLOG=syslog; set -o audit", "set -o", "set +o > options.sh"},
+	},
+	"source": {
+		synopsis: []string{"source file [args...]"},
+		summary:  "run the commands in a file in this shell",
+		description: `Runs the commands in a file in this shell, with any further arguments as its
+positional parameters, so variables it sets and directories it changes to
+last.`,
+		examples: []string{"source ~/.myshellrc"},
+	},
+	"stats": {
+		synopsis: []string{"stats [-n count]", "stats -c"},
+		summary:  "show the most used and slowest commands",
+		description: `Prints the commands run most often, with how many times, and those slowest
+on average, with their average time: with -n, count of each, 10 by default.
+The counts are kept with the history in the shell's data directory, and -c
+clears them.`,
+		examples: []string{"stats", "stats -n 3"},
+	},
+	"theme": {
+		synopsis: []string{"theme list", "theme use name", "theme off", "theme new name [--separator text] [--powerline] [--end text]", "theme add name [--right] template [fg [bg]]", "theme show name"},
+		summary:  "switch between and define prompt themes",
+		description: `Switches between prompt themes and defines new ones. A theme is a list of
+segments, each a prompt template with colors, drawn left to right, or on the
+right with --right, between separators.`,
+		examples: []string{"theme use powerline", "theme new mine --separator ' | ' --end '\\$ '", "theme add mine '\\w' blue"},
+	},
+	"times": {
+		synopsis: []string{"times"},
+		summary:  "print the CPU time used",
+		description: `Prints the user and system CPU time used by the shell on the first line and
+by its children on the second.`,
+		examples: []string{"times"},
+	},
+	"timeout": {
+		synopsis: []string{"timeout [--] duration command [args...]"},
+		summary:  "run a command, killing it if it takes too long",
+		description: `Runs a command and kills it if it is still running after duration, in
+seconds or a duration such as 1m30s, with the status then 124.`,
+		examples: []string{"timeout 30s curl -sS https://example.com", "timeout 5 make test"},
+	},
+	"type": {
+		synopsis: []string{"type [-a | -t | -p] name..."},
+		summary:  "describe how names are resolved",
+		description: `Describes how each name would be resolved when run as a command. -a lists
+every match, -t prints only the kind of the first and -p only its path.`,
+		examples: []string{"type -a echo"},
+	},
+	"unset": {
+		synopsis:    []string{"unset name..."},
+		summary:     "remove variables",
+		description: `Removes each variable, from the environment too if it was exported.`,
+		examples:    []string{"unset TMP"},
+	},
+	"vars": {
+		synopsis: []string{"vars [--filter pattern] [--json]"},
+		summary:  "list variables",
+		description: `Prints the variables, optionally only those whose names match the glob
+given with --filter, either as assignments or, with --json, as a JSON array.`,
+		examples: []string{"vars --filter 'GO*'", "vars --json"},
+	},
+	"wait": {
+		synopsis: []string{"wait [%job...]"},
+		summary:  "wait for commands running in the background",
+		description: `Waits for the jobs named by %n, as jobs lists them, to finish, and returns
+the status of the last, or 127 if it names no job. Without arguments it
+waits for every job and returns 0. Ctrl+C stops waiting.`,
+		examples: []string{"wait", "wait %1"},
+	},
+	"which": {
+		synopsis: []string{"which [-a] name..."},
+		summary:  "print the commands names run",
+		description: `Prints what each name runs as: the path of an executable or a note that it
+is a builtin. With -a it prints every match rather than the first.`,
+		examples: []string{"which -a python3"},
+	},
+}
+
+// Help lists the builtins with what they do, or prints the usage of those
+// named, or with -m the reference page of one:
+//
+//	help
+//	help cd pwd
+//	help -m complete
+func Help(c *interp.Command) int {
+	if len(c.Args) == 0 {
+		for _, name := range sortedKeys(builtinDocs) {
+			fmt.Fprintf(c.Stdout, "%-10s %s\n", name, builtinDocs[name].summary)
+		}
+		return 0
+	}
+	if c.Args[0] == "-m" {
+		if len(c.Args) != 2 {
+			fmt.Fprintln(c.Stderr, "usage: help -m name")
+			return 2
+		}
+		doc, ok := builtinDocs[c.Args[1]]
+		if !ok {
+			return c.Errorf(1, "%s: no help topics match", c.Args[1])
+		}
+		page(c, referencePage(c.Args[1], doc))
+		return 0
+	}
+	status := 0
+	for _, name := range c.Args {
+		doc, ok := builtinDocs[name]
+		if !ok {
+			status = c.Errorf(1, "%s: no help topics match", name)
+			continue
+		}
+		for _, line := range doc.synopsis {
+			fmt.Fprintln(c.Stdout, "usage: "+line)
+		}
+		fmt.Fprintf(c.Stdout, "    %s\n", doc.summary)
+	}
+	return status
+}
+
+// referencePage returns the reference page of builtin name, in the sections
+// of a man page, with its options from builtinFlags.
+func referencePage(name string, doc builtinDoc) string {
+	var b strings.Builder
+	indent := func(text string) {
+		for _, line := range strings.Split(text, "\n") {
+			if line == "" {
+				b.WriteString("\n")
+				continue
+			}
+			b.WriteString("    " + line + "\n")
+		}
+	}
+	b.WriteString("NAME\n")
+	indent(name + " - " + doc.summary)
+	b.WriteString("\nSYNOPSIS\n")
+	indent(strings.Join(doc.synopsis, "\n"))
+	b.WriteString("\nDESCRIPTION\n")
+	indent(doc.description)
+	if flags := builtinFlags[name]; len(flags) > 0 {
+		b.WriteString("\nOPTIONS\n")
+		for _, flag := range flags {
+			indent(fmt.Sprintf("%-10s %s", flag.name, flag.description))
+		}
+	}
+	if len(doc.examples) > 0 {
+		b.WriteString("\nEXAMPLES\n")
+		indent(strings.Join(doc.examples, "\n"))
+	}
+	return b.String()
+}
+
+// page writes text to the standard output of c, through the pager in PAGER,
+// or less, when that is the terminal.
+func page(c *interp.Command, text string) {
+	sh := c.Shell()
+	if f, ok := c.Stdout.(*os.File); !ok || !term.IsTerminal(int(f.Fd())) {
+		io.WriteString(c.Stdout, text)
+		return
+	}
+	pager := sh.Split(sh.GetVar("PAGER"))
+	if len(pager) == 0 {
+		pager = []string{"less"}
+	}
+	cmd := exec.Command(pager[0], pager[1:]...)
+	cmd.Env = sh.Environ()
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stdout, cmd.Stderr = c.Stdout, c.Stderr
+	if cmd.Run() != nil {
+		io.WriteString(c.Stdout, text)
+	}
+}
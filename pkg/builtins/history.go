@@ -0,0 +1,44 @@
+package builtins
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+)
+
+func History(c *interp.Command) int {
+	sh := c.Shell()
+	history := interp.History()
+	switch {
+	case len(c.Args) > 0 && c.Args[0] == "-c":
+		if err := sh.SetHistory(nil); err != nil {
+			return c.Errorf(1, "%v", err)
+		}
+	case len(c.Args) > 0 && c.Args[0] == "-d":
+		if len(c.Args) < 2 {
+			return c.Errorf(1, "-d: option requires an argument")
+		}
+		n, err := strconv.Atoi(c.Args[1])
+		if err != nil || n < 1 || n > len(history) {
+			return c.Errorf(1, "%s: history position out of range", c.Args[1])
+		}
+		if err := sh.SetHistory(slices.Delete(history, n-1, n)); err != nil {
+			return c.Errorf(1, "%v", err)
+		}
+	default:
+		start := 0
+		if len(c.Args) > 0 {
+			n, err := strconv.Atoi(c.Args[0])
+			if err != nil || n < 0 {
+				return c.Errorf(1, "%s: numeric argument required", c.Args[0])
+			}
+			start = max(len(history)-n, 0)
+		}
+		for i := start; i < len(history); i++ {
+			fmt.Fprintf(c.Stdout, "%5d  %s\n", i+1, history[i])
+		}
+	}
+	return 0
+}
@@ -0,0 +1,66 @@
+package builtins
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+	"github.com/codecrafters-io/shell-starter-go/pkg/lexer"
+)
+
+// Hook adds, lists and removes the commands run when shell events happen,
+// and lists the plugins the config file hooks to them:
+//
+//	hook add chpwd 'ls'
+//	hook list
+//	hook rm chpwd 1
+func Hook(c *interp.Command) int {
+	sh := c.Shell()
+	if len(c.Args) == 0 || c.Args[0] == "list" {
+		events := interp.HookEvents
+		if len(c.Args) > 1 {
+			events = c.Args[1:]
+		}
+		for _, event := range events {
+			for _, line := range sh.Hooks[event] {
+				fmt.Fprintln(c.Stdout, "hook add", event, lexer.Quote(line))
+			}
+			// Plugins come from the config file, so aren't listed as
+			// commands to add them.
+			for _, plugin := range sh.HookPlugins[event] {
+				fmt.Fprintf(c.Stdout, "# %s plugin %s\n", event, lexer.Quote(plugin))
+			}
+		}
+		return 0
+	}
+	if len(c.Args) < 2 {
+		return c.Errorf(1, "%s: event name required", c.Args[0])
+	}
+	event := c.Args[1]
+	if !slices.Contains(interp.HookEvents, event) {
+		return c.Errorf(1, "%s: unknown event; expected one of %s", event, strings.Join(interp.HookEvents, ", "))
+	}
+	switch c.Args[0] {
+	case "add":
+		if len(c.Args) < 3 {
+			fmt.Fprintln(c.Stderr, "usage: hook add event command [args...]")
+			return 1
+		}
+		sh.Hooks[event] = append(sh.Hooks[event], strings.Join(c.Args[2:], " "))
+	case "rm":
+		if len(c.Args) < 3 {
+			delete(sh.Hooks, event)
+			return 0
+		}
+		n, err := strconv.Atoi(c.Args[2])
+		if err != nil || n < 1 || n > len(sh.Hooks[event]) {
+			return c.Errorf(1, "%s: no such %s hook", c.Args[2], event)
+		}
+		sh.Hooks[event] = slices.Delete(sh.Hooks[event], n-1, n)
+	default:
+		return c.Errorf(1, "%s: unknown subcommand", c.Args[0])
+	}
+	return 0
+}
@@ -0,0 +1,67 @@
+package builtins
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+	"github.com/codecrafters-io/shell-starter-go/pkg/parser"
+)
+
+// In runs a command with a one-shot working directory, umask and extra
+// environment, which are all restored once it finishes:
+//
+//	in --dir build --umask 022 --env FOO=1 -- make
+func In(c *interp.Command) int {
+	var dir string
+	var envs []string
+	mask := -1
+	args := c.Args
+	for len(args) > 0 {
+		if args[0] == "--" {
+			args = args[1:]
+			break
+		}
+		if len(args) < 2 || (args[0] != "--dir" && args[0] != "--umask" && args[0] != "--env") {
+			break
+		}
+		switch args[0] {
+		case "--dir":
+			dir = args[1]
+		case "--umask":
+			m, err := strconv.ParseUint(args[1], 8, 32)
+			if err != nil || m > 0777 {
+				return c.Errorf(1, "%s: invalid umask", args[1])
+			}
+			mask = int(m)
+		case "--env":
+			if _, _, ok := parser.ParseAssignment(args[1]); !ok {
+				return c.Errorf(1, "%s: not a NAME=value assignment", args[1])
+			}
+			envs = append(envs, args[1])
+		}
+		args = args[2:]
+	}
+	if len(args) == 0 {
+		fmt.Fprintln(c.Stderr, "usage: in [--dir DIR] [--umask MODE] [--env NAME=VALUE]... [--] command [args...]")
+		return 1
+	}
+	if mask >= 0 {
+		old, err := setUmask(mask)
+		if err != nil {
+			return c.Errorf(1, "%v", err)
+		}
+		defer setUmask(old)
+	}
+	sh := c.Shell()
+	inner := *c
+	inner.Name, inner.Args, inner.Assignments = args[0], args[1:], nil
+	var status int
+	run := func() { sh.WithVars(envs, func() { status = inner.Run() }) }
+	if dir == "" {
+		run()
+	} else if err := sh.WithDir(dir, run); err != nil {
+		return c.Errorf(1, "%s: No such file or directory", dir)
+	}
+	return status
+}
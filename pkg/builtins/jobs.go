@@ -0,0 +1,63 @@
+package builtins
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+)
+
+// Jobs lists the commands run in the background, numbered for %n to name
+// them, with the niceness and I/O class their executables run at, and with
+// -l the process IDs of those running. Those done are listed once.
+func Jobs(c *interp.Command) int {
+	long := false
+	for _, arg := range c.Args {
+		if arg != "-l" {
+			fmt.Fprintln(c.Stderr, "usage: jobs [-l]")
+			return 2
+		}
+		long = true
+	}
+	for _, j := range c.Shell().Jobs() {
+		state := "Running"
+		if j.Done && j.Status == 0 {
+			state = "Done"
+		} else if j.Done {
+			state = "Exit " + strconv.Itoa(j.Status)
+		}
+		fmt.Fprintf(c.Stdout, "[%d]  ", j.ID)
+		if long {
+			pids := make([]string, len(j.PIDs))
+			for i, pid := range j.PIDs {
+				pids[i] = strconv.Itoa(pid)
+			}
+			fmt.Fprintf(c.Stdout, "%-8s", strings.Join(pids, ","))
+		}
+		fmt.Fprintf(c.Stdout, "%-8s  %-20s  %s\n", state, j.Priority, j.Command)
+	}
+	return 0
+}
+
+// Wait waits for the jobs named by %n to finish, or for every job without
+// arguments, and returns the status of the last named, 127 for a job spec
+// naming none.
+func Wait(c *interp.Command) int {
+	sh := c.Shell()
+	if len(c.Args) == 0 {
+		return sh.WaitJobs(c.Context())
+	}
+	status := 0
+	for _, spec := range c.Args {
+		var err error
+		status, err = sh.WaitJob(c.Context(), spec)
+		if err != nil {
+			c.Errorf(status, "%v", err)
+		}
+		if c.Context().Err() != nil {
+			return status
+		}
+	}
+	return status
+}
@@ -0,0 +1,61 @@
+package builtins
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+)
+
+// Limit runs a command with limits on the CPU time, memory and open files
+// of the executables it runs, and on the wall-clock time it takes, and
+// reports when it is killed for going over one:
+//
+//	limit --cpu 10 --mem 512M --files 64 --time 1m -- make
+func Limit(c *interp.Command) int {
+	var limits interp.Limits
+	var timeout time.Duration
+	args := c.Args
+	for len(args) > 0 {
+		if args[0] == "--" {
+			args = args[1:]
+			break
+		}
+		if len(args) < 2 || (args[0] != "--cpu" && args[0] != "--mem" && args[0] != "--files" && args[0] != "--time") {
+			break
+		}
+		switch args[0] {
+		case "--cpu":
+			secs, err := strconv.ParseUint(args[1], 10, 32)
+			if err != nil || secs == 0 {
+				return c.Errorf(2, "%s: invalid number of seconds", args[1])
+			}
+			limits.CPU = time.Duration(secs) * time.Second
+		case "--mem":
+			n, err := interp.ParseSize(args[1])
+			if err != nil || n == 0 {
+				return c.Errorf(2, "%s: invalid size", args[1])
+			}
+			limits.Memory = n
+		case "--files":
+			n, err := strconv.ParseUint(args[1], 10, 32)
+			if err != nil || n == 0 {
+				return c.Errorf(2, "%s: invalid number of files", args[1])
+			}
+			limits.Files = n
+		case "--time":
+			d, err := parseTimeout(args[1])
+			if err != nil {
+				return c.Errorf(2, "%v", err)
+			}
+			timeout = d
+		}
+		args = args[2:]
+	}
+	if len(args) == 0 {
+		fmt.Fprintln(c.Stderr, "usage: limit [--cpu seconds] [--mem size] [--files count] [--time duration] [--] command [args...]")
+		return 2
+	}
+	return runTimed(interp.WithLimits(c.Context(), limits), c, timeout, args)
+}
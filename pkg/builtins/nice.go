@@ -0,0 +1,67 @@
+package builtins
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+)
+
+// Nice runs a command with its executables at a lower or higher priority:
+// with -n, a niceness added to theirs, 10 without -n or --io, and with
+// --io, an I/O scheduling class and level, as nice(1) and ionice(1) do.
+// Given %n job specs instead, it changes the priority of those jobs, as
+// renice(1) does:
+//
+//	nice -n 19 --io idle -- tar czf backup.tgz ~
+//	nice -n 5 %1
+func Nice(c *interp.Command) int {
+	p := interp.Priority{Nice: 10}
+	niceSet := false
+	args := c.Args
+	for len(args) > 0 {
+		if args[0] == "--" {
+			args = args[1:]
+			break
+		}
+		if len(args) < 2 || (args[0] != "-n" && args[0] != "--io") {
+			break
+		}
+		switch args[0] {
+		case "-n":
+			n, err := strconv.Atoi(args[1])
+			if err != nil {
+				return c.Errorf(2, "%s: invalid adjustment", args[1])
+			}
+			p.Nice, niceSet = n, true
+		case "--io":
+			class, level, err := interp.ParseIOPriority(args[1])
+			if err != nil {
+				return c.Errorf(2, "%v", err)
+			}
+			p.IOClass, p.IOLevel = class, level
+			if !niceSet {
+				p.Nice = 0
+			}
+		}
+		args = args[2:]
+	}
+	if len(args) == 0 {
+		fmt.Fprintln(c.Stderr, "usage: nice [-n adjustment] [--io class[:level]] [--] command [args...]")
+		fmt.Fprintln(c.Stderr, "       nice [-n adjustment] [--io class[:level]] [--] %job...")
+		return 2
+	}
+	if strings.HasPrefix(args[0], "%") {
+		status := 0
+		for _, spec := range args {
+			if err := c.Shell().ReniceJob(spec, p); err != nil {
+				status = c.Errorf(1, "%v", err)
+			}
+		}
+		return status
+	}
+	inner := c.WithContext(interp.WithPriority(c.Context(), p))
+	inner.Name, inner.Args, inner.Assignments = args[0], args[1:], nil
+	return inner.Run()
+}
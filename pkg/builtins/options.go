@@ -0,0 +1,80 @@
+package builtins
+
+import (
+	"fmt"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+	"github.com/codecrafters-io/shell-starter-go/pkg/parser"
+)
+
+// Set turns options on with -o and off with +o, or by letter with flags
+// like -n and +n. Without arguments it prints every variable as an
+// assignment and every function as its definition, which can be read back
+// in; with just -o it lists the options, whether each is on and what it
+// does, and with just +o prints them as set commands that can be read back
+// in.
+func Set(c *interp.Command) int {
+	sh := c.Shell()
+	if len(c.Args) == 0 {
+		for _, name := range sh.VarNames("") {
+			fmt.Fprintf(c.Stdout, "%s=%s\n", name, sh.FormatVar(name))
+		}
+		for _, f := range sh.Functions() {
+			fmt.Fprintln(c.Stdout, parser.Format(f))
+		}
+		return 0
+	}
+	if len(c.Args) == 1 && (c.Args[0] == "-o" || c.Args[0] == "+o") {
+		listOptions(c, c.Args[0] == "+o")
+		return 0
+	}
+	for i := 0; i < len(c.Args); i++ {
+		flag := c.Args[i]
+		if len(flag) < 2 || (flag[0] != '-' && flag[0] != '+') {
+			return c.Errorf(1, "%s: invalid option", flag)
+		}
+		on := flag[0] == '-'
+		if flag[1:] != "o" {
+			for _, letter := range flag[1:] {
+				name, ok := interp.ShortOptions[letter]
+				if !ok {
+					return c.Errorf(1, "%c%c: invalid option", flag[0], letter)
+				}
+				sh.SetOption(name, on)
+			}
+			continue
+		}
+		if i+1 >= len(c.Args) {
+			return c.Errorf(1, "%s: option name required", flag)
+		}
+		i++
+		name := c.Args[i]
+		if !sh.SetOption(name, on) {
+			return c.Errorf(1, "%s: invalid option name", name)
+		}
+	}
+	return 0
+}
+
+// listOptions prints the options, sorted by name: as a table of whether
+// each is on and what it does, or as the set commands that restore them.
+func listOptions(c *interp.Command, commands bool) {
+	opts := interp.Options()
+	width := 0
+	for _, o := range opts {
+		width = max(width, len(o.Name))
+	}
+	for _, o := range opts {
+		on := c.Shell().Option(o.Name)
+		switch {
+		case commands && on:
+			fmt.Fprintln(c.Stdout, "set -o", o.Name)
+		case commands:
+			fmt.Fprintln(c.Stdout, "set +o", o.Name)
+		case on:
+			fmt.Fprintf(c.Stdout, "%-*s  on   %s\n", width, o.Name, o.Description)
+		default:
+			fmt.Fprintf(c.Stdout, "%-*s  off  %s\n", width, o.Name, o.Description)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package builtins
+
+import (
+	"fmt"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+)
+
+// Record starts and stops recording the session on the terminal, keys and
+// output, to a file asciinema can play back:
+//
+//	record start
+//	record start demo.cast
+//	record stop
+//
+// Without arguments it prints the file being recorded to. Commands write
+// through a pipe while it records, so programs needing a terminal don't
+// get one.
+func Record(c *interp.Command) int {
+	sh := c.Shell()
+	if len(c.Args) == 0 {
+		file := sh.RecordingFile()
+		if file == "" {
+			return c.Errorf(1, "not recording")
+		}
+		fmt.Fprintln(c.Stdout, file)
+		return 0
+	}
+	switch {
+	case c.Args[0] == "start" && len(c.Args) <= 2:
+		file := ""
+		if len(c.Args) == 2 {
+			file = c.Args[1]
+		}
+		file, err := sh.StartRecording(file)
+		if err != nil {
+			return c.Errorf(1, "%v", err)
+		}
+		fmt.Fprintln(c.Stderr, "recording to", file)
+	case c.Args[0] == "stop" && len(c.Args) == 1:
+		file, err := sh.StopRecording()
+		if err != nil {
+			return c.Errorf(1, "%v", err)
+		}
+		fmt.Fprintln(c.Stderr, "recorded to", file)
+	case c.Args[0] == "start" || c.Args[0] == "stop":
+		fmt.Fprintln(c.Stderr, "usage: record [start [file] | stop]")
+		return 2
+	default:
+		return c.Errorf(1, "%s: unknown subcommand", c.Args[0])
+	}
+	return 0
+}
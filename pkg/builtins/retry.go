@@ -0,0 +1,68 @@
+package builtins
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+)
+
+// Retry runs a command until it succeeds or the attempts run out, waiting
+// between attempts for the backoff delay, which doubles after each
+// failure, and returns the status of the last attempt. It gives up early if
+// it is interrupted:
+//
+//	retry -n 5 --backoff 2s -- curl -fsS https://example.com
+func Retry(c *interp.Command) int {
+	attempts, backoff := 3, time.Second
+	args := c.Args
+	for len(args) > 0 {
+		if args[0] == "--" {
+			args = args[1:]
+			break
+		}
+		if len(args) < 2 || (args[0] != "-n" && args[0] != "--backoff") {
+			break
+		}
+		switch args[0] {
+		case "-n":
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return c.Errorf(2, "%s: invalid number of attempts", args[1])
+			}
+			attempts = n
+		case "--backoff":
+			d, err := time.ParseDuration(args[1])
+			if err != nil || d < 0 {
+				return c.Errorf(2, "%s: invalid duration", args[1])
+			}
+			backoff = d
+		}
+		args = args[2:]
+	}
+	if len(args) == 0 {
+		fmt.Fprintln(c.Stderr, "usage: retry [-n attempts] [--backoff duration] [--] command [args...]")
+		return 2
+	}
+	inner := *c
+	inner.Name, inner.Args, inner.Assignments = args[0], args[1:], nil
+	var status int
+	for attempt := 1; ; attempt++ {
+		if status = inner.Run(); status == 0 {
+			return 0
+		}
+		if attempt == attempts {
+			break
+		}
+		fmt.Fprintf(c.Stderr, "retry: attempt %d/%d failed with status %d; retrying in %s\n", attempt, attempts, status, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-c.Context().Done():
+			return status
+		}
+		backoff *= 2
+	}
+	fmt.Fprintf(c.Stderr, "retry: giving up after %d attempts; last status %d\n", attempts, status)
+	return status
+}
@@ -0,0 +1,37 @@
+package builtins
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+)
+
+// Source runs the commands in a file, with any further arguments as its
+// positional parameters.
+func Source(c *interp.Command) int {
+	sh := c.Shell()
+	if len(c.Args) == 0 {
+		return c.Errorf(1, "filename argument required")
+	}
+	if err := sh.SourceFile(c.Context(), c.Args[0], c.Args[1:]); err != nil {
+		return c.Errorf(1, "%s: No such file or directory", c.Args[0])
+	}
+	return sh.LastStatus()
+}
+
+// Defer registers a command to run when the enclosing script exits, after
+// those registered later. At the prompt it runs when the shell exits.
+// Without arguments it lists the pending commands, next to run first.
+func Defer(c *interp.Command) int {
+	sh := c.Shell()
+	if len(c.Args) == 0 {
+		deferred := sh.Deferred()
+		for i := len(deferred) - 1; i >= 0; i-- {
+			fmt.Fprintln(c.Stdout, deferred[i])
+		}
+		return 0
+	}
+	sh.DeferCommand(strings.Join(c.Args, " "))
+	return 0
+}
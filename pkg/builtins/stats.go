@@ -0,0 +1,45 @@
+package builtins
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+)
+
+// Stats prints the commands run most often and those slowest on average,
+// with -n how many of each, 10 by default. -c forgets every command run.
+func Stats(c *interp.Command) int {
+	sh := c.Shell()
+	n := 10
+	switch {
+	case len(c.Args) == 1 && c.Args[0] == "-c":
+		if err := sh.ClearStats(); err != nil {
+			return c.Errorf(1, "%v", err)
+		}
+		return 0
+	case len(c.Args) == 2 && c.Args[0] == "-n":
+		var err error
+		if n, err = strconv.Atoi(c.Args[1]); err != nil || n < 1 {
+			return c.Errorf(2, "%s: invalid number of commands", c.Args[1])
+		}
+	case len(c.Args) > 0:
+		fmt.Fprintln(c.Stderr, "usage: stats [-n count | -c]")
+		return 2
+	}
+	stats := sh.Stats()
+	slices.SortStableFunc(stats, func(a, b interp.CommandStats) int { return cmp.Compare(b.Count, a.Count) })
+	fmt.Fprintln(c.Stdout, "most used:")
+	for _, s := range stats[:min(n, len(stats))] {
+		fmt.Fprintf(c.Stdout, "%8d  %s\n", s.Count, s.Name)
+	}
+	slices.SortStableFunc(stats, func(a, b interp.CommandStats) int { return cmp.Compare(b.Average(), a.Average()) })
+	fmt.Fprintln(c.Stdout, "slowest on average:")
+	for _, s := range stats[:min(n, len(stats))] {
+		fmt.Fprintf(c.Stdout, "%8s  %s\n", s.Average().Round(time.Millisecond), s.Name)
+	}
+	return 0
+}
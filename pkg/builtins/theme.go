@@ -0,0 +1,140 @@
+package builtins
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+	"github.com/codecrafters-io/shell-starter-go/pkg/lexer"
+)
+
+// Theme switches between prompt themes and defines new ones:
+//
+//	theme list
+//	theme use powerline
+//	theme new mine --separator ' | ' --end '\$ '
+//	theme add mine '\w' blue
+//	theme add mine --right '\t' white black
+//	theme show mine
+//	theme off
+func Theme(c *interp.Command) int {
+	sh := c.Shell()
+	args := c.Args
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	switch args[0] {
+	case "list":
+		names := make([]string, 0, len(sh.Themes))
+		for name := range sh.Themes {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			if name == sh.CurrentTheme {
+				fmt.Fprintln(c.Stdout, "*", name)
+			} else {
+				fmt.Fprintln(c.Stdout, " ", name)
+			}
+		}
+	case "use":
+		if len(args) != 2 {
+			fmt.Fprintln(c.Stderr, "usage: theme use name")
+			return 1
+		}
+		if _, ok := sh.Themes[args[1]]; !ok {
+			return c.Errorf(1, "%s: no such theme", args[1])
+		}
+		sh.CurrentTheme = args[1]
+	case "off":
+		sh.CurrentTheme = ""
+	case "new":
+		if len(args) < 2 {
+			fmt.Fprintln(c.Stderr, "usage: theme new name [--separator text] [--powerline] [--end text]")
+			return 1
+		}
+		t := &interp.Theme{Separator: " ", End: `\$ `}
+		for opts := args[2:]; len(opts) > 0; opts = opts[1:] {
+			switch {
+			case opts[0] == "--powerline":
+				t.Powerline = true
+			case (opts[0] == "--separator" || opts[0] == "--end") && len(opts) > 1:
+				if opts[0] == "--separator" {
+					t.Separator = opts[1]
+				} else {
+					t.End = opts[1]
+				}
+				opts = opts[1:]
+			default:
+				return c.Errorf(1, "%s: invalid option", opts[0])
+			}
+		}
+		sh.Themes[args[1]] = t
+	case "add":
+		if len(args) < 3 {
+			fmt.Fprintln(c.Stderr, "usage: theme add name [--right] template [fg [bg]]")
+			return 1
+		}
+		t, ok := sh.Themes[args[1]]
+		if !ok {
+			return c.Errorf(1, "%s: no such theme", args[1])
+		}
+		rest, right := args[2:], false
+		if rest[0] == "--right" {
+			rest, right = rest[1:], true
+		}
+		if len(rest) == 0 || len(rest) > 3 {
+			fmt.Fprintln(c.Stderr, "usage: theme add name [--right] template [fg [bg]]")
+			return 1
+		}
+		seg := interp.PromptSegment{Template: rest[0]}
+		for i, color := range rest[1:] {
+			if !interp.ValidColor(color) {
+				return c.Errorf(1, "%s: unknown color", color)
+			}
+			if i == 0 {
+				seg.FG = color
+			} else {
+				seg.BG = color
+			}
+		}
+		if right {
+			t.Right = append(t.Right, seg)
+		} else {
+			t.Left = append(t.Left, seg)
+		}
+	case "show":
+		name := sh.CurrentTheme
+		if len(args) > 1 {
+			name = args[1]
+		}
+		t, ok := sh.Themes[name]
+		if !ok {
+			return c.Errorf(1, "%s: no such theme", name)
+		}
+		def := []string{"theme new", lexer.Quote(name), "--separator", lexer.Quote(t.Separator), "--end", lexer.Quote(t.End)}
+		if t.Powerline {
+			def = append(def, "--powerline")
+		}
+		fmt.Fprintln(c.Stdout, strings.Join(def, " "))
+		for _, side := range []struct {
+			flag string
+			segs []interp.PromptSegment
+		}{{"", t.Left}, {"--right ", t.Right}} {
+			for _, seg := range side.segs {
+				line := "theme add " + lexer.Quote(name) + " " + side.flag + lexer.Quote(seg.Template)
+				if seg.FG != "" || seg.BG != "" {
+					line += " " + lexer.Quote(seg.FG)
+				}
+				if seg.BG != "" {
+					line += " " + lexer.Quote(seg.BG)
+				}
+				fmt.Fprintln(c.Stdout, line)
+			}
+		}
+	default:
+		return c.Errorf(1, "%s: unknown subcommand", args[0])
+	}
+	return 0
+}
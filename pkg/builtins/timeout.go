@@ -0,0 +1,61 @@
+package builtins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+)
+
+// Timeout runs a command, killing it if it is still running after a
+// duration, as timeout(1) does:
+//
+//	timeout 30s curl -sS https://example.com
+func Timeout(c *interp.Command) int {
+	args := c.Args
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+	if len(args) < 2 {
+		fmt.Fprintln(c.Stderr, "usage: timeout [--] duration command [args...]")
+		return 2
+	}
+	d, err := parseTimeout(args[0])
+	if err != nil {
+		return c.Errorf(2, "%v", err)
+	}
+	return runTimed(c.Context(), c, d, args[1:])
+}
+
+// parseTimeout parses a duration such as 1m30s or, like timeout(1), a
+// bare number of seconds.
+func parseTimeout(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if secs, serr := strconv.ParseFloat(s, 64); serr == nil {
+		d, err = time.Duration(secs*float64(time.Second)), nil
+	}
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s: invalid duration", s)
+	}
+	return d, nil
+}
+
+// runTimed runs args as a command in ctx, killing it once it has run for
+// d unless d is zero, and returns its status, 124 if it was killed.
+func runTimed(ctx context.Context, c *interp.Command, d time.Duration, args []string) int {
+	if d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+	inner := c.WithContext(ctx)
+	inner.Name, inner.Args, inner.Assignments = args[0], args[1:], nil
+	status := inner.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) && c.Context().Err() == nil {
+		return inner.Errorf(124, "timed out after %s", d)
+	}
+	return status
+}
@@ -0,0 +1,25 @@
+package builtins
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+)
+
+// Times prints the user and system CPU time used by the shell on the first
+// line and by its children on the second.
+func Times(c *interp.Command) int {
+	user, sys := interp.ShellTimes()
+	fmt.Fprintln(c.Stdout, formatCPUTime(user), formatCPUTime(sys))
+	user, sys = interp.ChildTimes()
+	fmt.Fprintln(c.Stdout, formatCPUTime(user), formatCPUTime(sys))
+	return 0
+}
+
+// formatCPUTime formats d in minutes and seconds, like 0m0.012s.
+func formatCPUTime(d time.Duration) string {
+	d = d.Round(time.Millisecond)
+	m := d / time.Minute
+	return fmt.Sprintf("%dm%.3fs", m, (d - m*time.Minute).Seconds())
+}
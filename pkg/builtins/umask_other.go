@@ -0,0 +1,10 @@
+//go:build !unix
+
+package builtins
+
+import "errors"
+
+// setUmask reports that the file mode creation mask isn't supported.
+func setUmask(mask int) (old int, err error) {
+	return 0, errors.New("umask is not supported on this platform")
+}
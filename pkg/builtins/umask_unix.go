@@ -0,0 +1,10 @@
+//go:build unix
+
+package builtins
+
+import "syscall"
+
+// setUmask sets the file mode creation mask and returns the previous one.
+func setUmask(mask int) (old int, err error) {
+	return syscall.Umask(mask), nil
+}
@@ -0,0 +1,119 @@
+package builtins
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/interp"
+	"github.com/codecrafters-io/shell-starter-go/pkg/lexer"
+	"github.com/codecrafters-io/shell-starter-go/pkg/parser"
+)
+
+func Export(c *interp.Command) int {
+	sh := c.Shell()
+	if len(c.Args) == 0 {
+		for _, name := range sh.VarNames("") {
+			if v, _ := sh.Var(name); v.Exported {
+				fmt.Fprintf(c.Stdout, "export %s=%s\n", name, lexer.Quote(v.Value))
+			}
+		}
+		return 0
+	}
+	status := 0
+	for _, arg := range c.Args {
+		if name, value, ok := parser.ParseAssignment(arg); ok {
+			sh.SetVar(name, value)
+			sh.ExportVar(name)
+			continue
+		}
+		if !lexer.ValidName(arg) {
+			status = c.Errorf(1, "%s: not a valid identifier", arg)
+			continue
+		}
+		sh.ExportVar(arg)
+	}
+	return status
+}
+
+// Local makes variables local to the function running, restored once it
+// returns, assigning those given a value:
+//
+//	local dir=$1 tmp
+func Local(c *interp.Command) int {
+	sh := c.Shell()
+	status := 0
+	for _, arg := range c.Args {
+		name, value, hasValue := parser.ParseAssignment(arg)
+		if !hasValue {
+			name = arg
+		}
+		if !lexer.ValidName(name) {
+			status = c.Errorf(1, "%s: not a valid identifier", arg)
+			continue
+		}
+		if err := sh.Local(name); err != nil {
+			return c.Errorf(1, "%v", err)
+		}
+		if hasValue {
+			sh.SetVar(name, value)
+		}
+	}
+	return status
+}
+
+func Unset(c *interp.Command) int {
+	for _, name := range c.Args {
+		c.Shell().UnsetVar(name)
+	}
+	return 0
+}
+
+// Vars prints the variables, optionally only those whose names match the
+// glob given with --filter, either as assignments or, with --json, as a
+// JSON array.
+func Vars(c *interp.Command) int {
+	sh := c.Shell()
+	pattern, asJSON := "", false
+	for i := 0; i < len(c.Args); i++ {
+		switch arg := c.Args[i]; {
+		case arg == "--json":
+			asJSON = true
+		case arg == "--filter" && i+1 < len(c.Args):
+			i++
+			pattern = c.Args[i]
+		case strings.HasPrefix(arg, "--filter="):
+			pattern = strings.TrimPrefix(arg, "--filter=")
+		default:
+			return c.Errorf(1, "%s: invalid option", arg)
+		}
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return c.Errorf(1, "%s: bad pattern", pattern)
+	}
+	names := sh.VarNames(pattern)
+	if !asJSON {
+		for _, name := range names {
+			fmt.Fprintf(c.Stdout, "%s=%s\n", name, sh.FormatVar(name))
+		}
+		return 0
+	}
+	type jsonVar struct {
+		Name     string   `json:"name"`
+		Value    string   `json:"value"`
+		Array    []string `json:"array,omitempty"`
+		Exported bool     `json:"exported"`
+	}
+	out := make([]jsonVar, 0, len(names))
+	for _, name := range names {
+		v, _ := sh.Var(name)
+		out = append(out, jsonVar{Name: name, Value: v.Value, Array: v.Array, Exported: v.Exported})
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return c.Errorf(1, "%v", err)
+	}
+	fmt.Fprintln(c.Stdout, string(data))
+	return 0
+}
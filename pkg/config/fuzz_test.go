@@ -0,0 +1,38 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// FuzzParse checks that parsing any text either gives an *Error or a
+// table, and that a literal string reads back as it was written.
+func FuzzParse(f *testing.F) {
+	for _, s := range []string{
+		"",
+		"[options]\nsyntax-highlighting = true # on\n",
+		"[prompt]\nps1 = '\\w \\$ '\nrprompt = \"\\u2192 \\e[1m\"\n",
+		"[completion]\nmake = [\"build\",\n  \"test\", ]\n[completion.git]\nwords = []\n",
+		"a.'b c'.\"d\" = -1_000\n[a]\n",
+		"x = [1, [true, 'y']]",
+		"[x\n",
+		"x = \"unterminated\n",
+		"x = 1\nx = 2\n",
+	} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		if _, err := Parse(src); err != nil {
+			if _, ok := err.(*Error); !ok {
+				t.Fatalf("Parse(%q) error %T, want *Error", src, err)
+			}
+		}
+		if strings.ContainsAny(src, "'\n\r") {
+			return
+		}
+		table, err := Parse("s = '" + src + "'")
+		if err != nil || table["s"] != src {
+			t.Fatalf("Parse of the literal string %q = %v, %v", src, table, err)
+		}
+	})
+}
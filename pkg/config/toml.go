@@ -0,0 +1,290 @@
+// Package config reads the shell's config file, written in the subset of
+// TOML it needs: tables, dotted and quoted keys, and values that are
+// strings, integers, booleans or arrays of them.
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Table is a TOML table. Its values are strings, int64s, bools, []anys of
+// them, and Tables.
+type Table map[string]any
+
+// Error is an error in a config file, at a line counting from 1.
+type Error struct {
+	Line int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// Parse parses the text of a config file into its root table.
+func Parse(src string) (Table, error) {
+	p := &parser{src: src, line: 1}
+	root := Table{}
+	current := root
+	// defined holds the tables given a header, which can't be given
+	// another.
+	defined := map[string]bool{}
+	for {
+		p.skipSpace(true)
+		if p.done() {
+			return root, nil
+		}
+		if p.peek() == '[' {
+			p.pos++
+			keys, err := p.key()
+			if err != nil {
+				return nil, err
+			}
+			if !p.consume(']') {
+				return nil, p.errorf("expected ] after the table name")
+			}
+			name := strings.Join(keys, ".")
+			if defined[name] {
+				return nil, p.errorf("table %s defined twice", name)
+			}
+			defined[name] = true
+			if current, err = p.table(root, keys); err != nil {
+				return nil, err
+			}
+		} else {
+			keys, err := p.key()
+			if err != nil {
+				return nil, err
+			}
+			if !p.consume('=') {
+				return nil, p.errorf("expected = after the key")
+			}
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			t, err := p.table(current, keys[:len(keys)-1])
+			if err != nil {
+				return nil, err
+			}
+			last := keys[len(keys)-1]
+			if _, ok := t[last]; ok {
+				return nil, p.errorf("key %s defined twice", strings.Join(keys, "."))
+			}
+			t[last] = value
+		}
+		p.skipSpace(false)
+		if !p.done() && !p.consume('\n') {
+			return nil, p.errorf("expected the end of the line")
+		}
+	}
+}
+
+// parser reads a config file, at pos on line.
+type parser struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return &Error{Line: p.line, Msg: fmt.Sprintf(format, args...)}
+}
+
+// skipSpace skips blanks and a comment, and with newlines the newlines and
+// the blank and comment lines after them too.
+func (p *parser) skipSpace(newlines bool) {
+	for !p.done() {
+		switch c := p.peek(); {
+		case c == ' ' || c == '\t' || c == '\r':
+			p.pos++
+		case c == '#':
+			for !p.done() && p.peek() != '\n' {
+				p.pos++
+			}
+		case c == '\n' && newlines:
+			p.pos++
+			p.line++
+		default:
+			return
+		}
+	}
+}
+
+// consume skips blanks and moves past c if it is next, reporting whether
+// it was.
+func (p *parser) consume(c byte) bool {
+	p.skipSpace(false)
+	if p.peek() != c {
+		return false
+	}
+	p.pos++
+	if c == '\n' {
+		p.line++
+	}
+	return true
+}
+
+// table returns the table the keys lead to from t, making the tables on
+// the way that don't exist yet.
+func (p *parser) table(t Table, keys []string) (Table, error) {
+	for i, k := range keys {
+		switch v := t[k].(type) {
+		case nil:
+			next := Table{}
+			t[k] = next
+			t = next
+		case Table:
+			t = v
+		default:
+			return nil, p.errorf("%s isn't a table", strings.Join(keys[:i+1], "."))
+		}
+	}
+	return t, nil
+}
+
+// key reads a key made of bare or quoted parts separated by dots.
+func (p *parser) key() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpace(false)
+		var k string
+		switch c := p.peek(); {
+		case c == '"' || c == '\'':
+			s, err := p.str()
+			if err != nil {
+				return nil, err
+			}
+			k = s
+		default:
+			start := p.pos
+			for isBareKeyChar(p.peek()) {
+				p.pos++
+			}
+			if p.pos == start {
+				return nil, p.errorf("expected a key")
+			}
+			k = p.src[start:p.pos]
+		}
+		keys = append(keys, k)
+		if !p.consume('.') {
+			return keys, nil
+		}
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// value reads a string, integer, boolean or array.
+func (p *parser) value() (any, error) {
+	p.skipSpace(false)
+	switch c := p.peek(); {
+	case c == '"' || c == '\'':
+		return p.str()
+	case c == '[':
+		p.pos++
+		values := []any{}
+		for {
+			p.skipSpace(true)
+			if p.peek() == ']' {
+				p.pos++
+				return values, nil
+			}
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+			p.skipSpace(true)
+			if p.peek() == ',' {
+				p.pos++
+			} else if p.peek() != ']' {
+				return nil, p.errorf("expected , or ] in the array")
+			}
+		}
+	}
+	start := p.pos
+	for isBareKeyChar(p.peek()) || p.peek() == '+' {
+		p.pos++
+	}
+	word := p.src[start:p.pos]
+	switch word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	n, err := strconv.ParseInt(strings.ReplaceAll(word, "_", ""), 0, 64)
+	if err != nil || word == "" {
+		return nil, p.errorf("invalid value %q", word)
+	}
+	return n, nil
+}
+
+// str reads a basic string in double quotes, with backslash escapes, or a
+// literal string in single quotes, without.
+func (p *parser) str() (string, error) {
+	quote := p.peek()
+	p.pos++
+	var sb strings.Builder
+	for {
+		if p.done() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.src[p.pos]
+		p.pos++
+		switch {
+		case c == quote:
+			return sb.String(), nil
+		case c == '\\' && quote == '"':
+			r, err := p.escape()
+			if err != nil {
+				return "", err
+			}
+			sb.WriteRune(r)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+}
+
+// escapes maps the characters after a backslash in a basic string to the
+// characters they stand for.
+var escapes = map[byte]rune{
+	'b': '\b', 't': '\t', 'n': '\n', 'f': '\f', 'r': '\r', 'e': '\x1b', '"': '"', '\\': '\\',
+}
+
+// escape reads the rest of an escape sequence after its backslash.
+func (p *parser) escape() (rune, error) {
+	c := p.peek()
+	p.pos++
+	if r, ok := escapes[c]; ok {
+		return r, nil
+	}
+	digits := map[byte]int{'u': 4, 'U': 8}[c]
+	if digits == 0 || p.pos+digits > len(p.src) {
+		return 0, p.errorf("invalid escape \\%c", c)
+	}
+	n, err := strconv.ParseUint(p.src[p.pos:p.pos+digits], 16, 32)
+	if err != nil || !utf8.ValidRune(rune(n)) {
+		return 0, p.errorf("invalid escape \\%c%s", c, p.src[p.pos:p.pos+digits])
+	}
+	p.pos += digits
+	return rune(n), nil
+}
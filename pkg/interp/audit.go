@@ -0,0 +1,70 @@
+package interp
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// auditRecord is what the audit option logs about each command run, as a
+// line of JSON.
+type auditRecord struct {
+	Time   time.Time `json:"time"`
+	Dir    string    `json:"cwd"`
+	Args   []string  `json:"argv"`
+	Status int       `json:"status"`
+	// Duration is the wall time the command took, in seconds.
+	Duration float64 `json:"duration"`
+	// Denied is set for a command the policy refused to run.
+	Denied bool `json:"denied,omitempty"`
+}
+
+// auditFile returns where the audit option of sh logs commands: AUDITLOG,
+// which is "syslog" for the system log, or by default audit.log in the
+// shell's data directory.
+func auditFile(sh *Shell) string {
+	if file := sh.GetVar("AUDITLOG"); file != "" {
+		return sh.Abs(file)
+	}
+	return filepath.Join(sh.DataDir(), "audit.log")
+}
+
+// audit logs c, which started at start and exited with status, with the
+// audit option. Commands that can't be logged still run.
+func audit(c *Command, start time.Time, status int) {
+	sh := c.Shell()
+	if !sh.options["audit"] || c.Name == "" {
+		return
+	}
+	writeAudit(sh, auditRecord{
+		Time:     start,
+		Dir:      sh.dir,
+		Args:     append([]string{c.Name}, c.Args...),
+		Status:   status,
+		Duration: time.Since(start).Seconds(),
+	})
+}
+
+// writeAudit logs rec to the audit log of sh. Records that can't be logged
+// are dropped.
+func writeAudit(sh *Shell, rec auditRecord) {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	file := auditFile(sh)
+	if file == "syslog" {
+		writeSyslog(string(line))
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.Write(append(line, '\n'))
+}
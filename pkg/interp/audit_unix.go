@@ -0,0 +1,29 @@
+//go:build unix
+
+package interp
+
+import (
+	"log/syslog"
+	"sync"
+)
+
+// syslogWriter is the connection to the system log audit records are sent
+// over, made with the first.
+var syslogWriter struct {
+	sync.Mutex
+	w *syslog.Writer
+}
+
+// writeSyslog sends line to the system log as an informational message.
+func writeSyslog(line string) {
+	syslogWriter.Lock()
+	defer syslogWriter.Unlock()
+	if syslogWriter.w == nil {
+		w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_USER, "myshell")
+		if err != nil {
+			return
+		}
+		syslogWriter.w = w
+	}
+	syslogWriter.w.Info(line)
+}
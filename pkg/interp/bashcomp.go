@@ -0,0 +1,100 @@
+package interp
+
+import (
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/lineedit"
+)
+
+// bashCompletionScripts are where the bash-completion package installs the
+// script that loads the completions of other commands, tried in order.
+var bashCompletionScripts = []string{
+	"/usr/share/bash-completion/bash_completion",
+	"/usr/local/share/bash-completion/bash_completion",
+	"/opt/homebrew/share/bash-completion/bash_completion",
+	"/etc/bash_completion",
+}
+
+// bashCompleter is run by bash with the mode, the bash-completion script,
+// the line and its words as arguments. It loads the completion of the
+// command, failing if there is none, and in the complete mode calls it as
+// bash does on Tab, printing the candidates one per line.
+const bashCompleter = `
+mode=$1 script=$2 COMP_LINE=$3 COMP_POINT=${#3} COMP_TYPE=9 COMP_KEY=9
+shift 3
+COMP_WORDS=("$@") COMP_CWORD=$(($# - 1))
+cmd=$1 cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}
+[[ -n $script ]] && source "$script" >/dev/null 2>&1
+if ! complete -p "$cmd" >/dev/null 2>&1; then
+	if declare -F __load_completion >/dev/null; then
+		__load_completion "$cmd"
+	elif declare -F _completion_loader >/dev/null; then
+		_completion_loader "$cmd"
+	fi
+fi >/dev/null 2>&1
+spec=$(complete -p "$cmd" 2>/dev/null) || exit 1
+[[ $mode == check ]] && exit 0
+spec=${spec#complete } spec=${spec% *}
+if [[ $spec =~ -F\ ([^ ]+) ]]; then
+	"${BASH_REMATCH[1]}" "$cmd" "$cur" "$prev" >/dev/null 2>&1
+	printf '%s\n' "${COMPREPLY[@]}"
+else
+	eval "compgen $spec -- \"\$cur\"" 2>/dev/null
+fi
+`
+
+// bashCompletions caches whether bash has a completion for each command
+// asked about.
+var bashCompletions = map[string]bool{}
+
+// hasBashCompletion reports whether, with the bash-completion option on,
+// bash has a completion for the command name, as the bash-completion
+// package loads it.
+func hasBashCompletion(name string) bool {
+	if !Option("bash-completion") {
+		return false
+	}
+	if ok, seen := bashCompletions[name]; seen {
+		return ok
+	}
+	err := runBashCompleter("check", name, []string{name}).Run()
+	bashCompletions[name] = err == nil
+	return err == nil
+}
+
+// completeBash completes the word in ctx to the candidates the bash
+// completion of its command gives.
+func completeBash(ctx completionContext) (comp lineedit.Completion) {
+	comp.Word = ctx.word
+	out, err := runBashCompleter("complete", ctx.line, append(slices.Clone(ctx.args), ctx.word)).Output()
+	if err != nil {
+		return
+	}
+	for _, name := range strings.Split(string(out), "\n") {
+		if name = strings.TrimSuffix(name, " "); name != "" {
+			comp.Names = append(comp.Names, name)
+		}
+	}
+	comp.Names = removeDuplicates(comp.Names)
+	slices.Sort(comp.Names)
+	return
+}
+
+// runBashCompleter returns the command running bashCompleter for line,
+// split into words, with the first bash-completion script found.
+func runBashCompleter(mode, line string, words []string) *exec.Cmd {
+	script := ""
+	for _, path := range bashCompletionScripts {
+		if _, err := os.Stat(path); err == nil {
+			script = path
+			break
+		}
+	}
+	args := append([]string{"-c", bashCompleter, "bash", mode, script, line}, words...)
+	cmd := exec.Command("bash", args...)
+	cmd.Env = Environ()
+	return cmd
+}
@@ -0,0 +1,375 @@
+package interp
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/lexer"
+	"github.com/codecrafters-io/shell-starter-go/pkg/lineedit"
+	"github.com/codecrafters-io/shell-starter-go/pkg/parser"
+)
+
+// completionContext is what the completer knows of the command the word
+// before the cursor is part of.
+type completionContext struct {
+	// args holds the words of the command before the one being completed,
+	// starting with the command name, with quotes removed.
+	args []string
+	// word is the text of the word being completed, with quotes removed.
+	word string
+	// raw is the word as typed, and quote the quote left open in it, if any.
+	raw   string
+	quote rune
+	// redirect is set when the word follows a redirection operator, and
+	// so names a file rather than being an argument.
+	redirect bool
+	// line is the line up to the cursor.
+	line string
+}
+
+// argCompleters complete the arguments of the commands they are keyed by,
+// in place of the file and directory paths other commands are given.
+var argCompleters = map[string]func(ctx completionContext) lineedit.Completion{
+	"cd":    func(ctx completionContext) lineedit.Completion { return completeDirectories(ctx.word) },
+	"pushd": func(ctx completionContext) lineedit.Completion { return completeDirectories(ctx.word) },
+}
+
+// Autocomplete returns the candidates for completing the word that line
+// ends with, and whether there are any.
+func Autocomplete(line string) (comp lineedit.Completion, found bool) {
+	if line == "" {
+		return
+	}
+	ctx := parseCompletionContext(line)
+	ctx.line = line
+	if comp, ok := completeVariables(ctx.word); ok {
+		return comp, len(comp.Names) > 0
+	}
+	if strings.HasPrefix(ctx.word, "~") && !strings.Contains(ctx.word, "/") {
+		comp = completeUsers(ctx.word)
+		return comp, len(comp.Names) > 0
+	}
+	switch {
+	case ctx.redirect:
+		comp = completePaths(ctx.word)
+	case len(ctx.args) > 0 && session.CompletionSpecs[ctx.args[0]] != nil:
+		comp = session.completeSpec(session.CompletionSpecs[ctx.args[0]], ctx)
+	case len(ctx.args) > 0 && hasBashCompletion(ctx.args[0]):
+		comp = completeBash(ctx)
+	case len(ctx.args) > 0 && (strings.HasPrefix(ctx.word, "-") || strings.HasPrefix(ctx.word, "+")) && hasFlags(ctx.args[0]):
+		comp = completeFlags(ctx.args[0], ctx.word)
+	case len(ctx.args) == 0 && !strings.Contains(ctx.word, "/"):
+		comp.Word = ctx.word
+		comp.Names = append(comp.Names, findBuiltinExecutablesHasPrefix(ctx.word)...)
+		comp.Names = append(comp.Names, findExecutablesHasPrefix(ctx.word)...)
+		comp.Names = removeDuplicates(comp.Names)
+		slices.Sort(comp.Names)
+	case len(ctx.args) > 0 && argCompleters[ctx.args[0]] != nil:
+		comp = argCompleters[ctx.args[0]](ctx)
+	default:
+		comp = completePaths(ctx.word)
+	}
+	if len(comp.Names) == 0 && len(ctx.args) > 0 && !ctx.redirect {
+		comp = completeHistoryArgs(ctx)
+	}
+	comp.Raw, comp.Quote = ctx.raw, ctx.quote
+	if Option("completion-fuzzy") {
+		rankCompletion(comp)
+	}
+	found = len(comp.Names) > 0
+	return
+}
+
+// parseCompletionContext splits the last command of line into its words.
+// Commands end at ;, | and &, and assignments before the command name and
+// redirections, such as > file or 2>> file, are left out.
+func parseCompletionContext(line string) (ctx completionContext) {
+	var sb strings.Builder
+	inSingleQuotes, inDoubleQuotes, escaped := false, false, false
+	quoted := false
+	// start is where the word being read starts in the line.
+	start := 0
+	endWord := func() {
+		if sb.Len() == 0 && !quoted {
+			return
+		}
+		word := sb.String()
+		sb.Reset()
+		quoted = false
+		if ctx.redirect {
+			ctx.redirect = false
+			return
+		}
+		if _, _, ok := parser.ParseAssignment(word); !ok || len(ctx.args) > 0 {
+			ctx.args = append(ctx.args, word)
+		}
+	}
+	rs := []rune(line)
+	for i, c := range rs {
+		switch {
+		case escaped:
+			escaped = false
+			sb.WriteRune(c)
+		case c == '\\' && !inSingleQuotes:
+			escaped = true
+		case c == '\'' && !inDoubleQuotes:
+			inSingleQuotes = !inSingleQuotes
+			quoted = true
+		case c == '"' && !inSingleQuotes:
+			inDoubleQuotes = !inDoubleQuotes
+			quoted = true
+		case inSingleQuotes || inDoubleQuotes:
+			sb.WriteRune(c)
+		case unicode.IsSpace(c):
+			endWord()
+			start = i + 1
+		case c == '<' || c == '>' || (c == '&' && ((i > 0 && rs[i-1] == '>') || (i+1 < len(rs) && rs[i+1] == '>'))):
+			if !quoted && sb.Len() > 0 && strings.Trim(sb.String(), "0123456789") == "" {
+				// A file descriptor number, as in 2>.
+				sb.Reset()
+			} else {
+				endWord()
+			}
+			ctx.redirect = true
+			start = i + 1
+		case c == ';' || c == '|' || c == '&':
+			endWord()
+			ctx.args, ctx.redirect = nil, false
+			start = i + 1
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	ctx.word, ctx.raw = sb.String(), string(rs[start:])
+	switch {
+	case inSingleQuotes:
+		ctx.quote = '\''
+	case inDoubleQuotes:
+		ctx.quote = '"'
+	}
+	return
+}
+
+// completeVariables completes the name of the variable word ends with, as
+// in $PA or ${PA, closing the brace of the latter, and reports whether
+// word ends with one.
+func completeVariables(word string) (comp lineedit.Completion, ok bool) {
+	i := strings.LastIndex(word, "$")
+	if i < 0 {
+		return
+	}
+	prefix, name := word[:i+1], word[i+1:]
+	brace := strings.HasPrefix(name, "{")
+	if brace {
+		prefix, name = prefix+"{", name[1:]
+	}
+	if strings.IndexFunc(name, func(c rune) bool { return !lexer.IsNameChar(c) }) >= 0 {
+		return
+	}
+	comp.Word, comp.Verbatim = word, true
+	for _, v := range VarNames("") {
+		if !completionMatches(v, name) {
+			continue
+		}
+		if brace {
+			v += "}"
+		}
+		comp.Names = append(comp.Names, prefix+v)
+	}
+	return comp, true
+}
+
+// completeUsers completes ~name to the user accounts in the passwd database
+// whose names start with name, and expands the one accepted to its home
+// directory, since the shell doesn't expand ~ itself.
+func completeUsers(word string) (comp lineedit.Completion) {
+	comp.Word, comp.Verbatim = word, true
+	data, err := os.ReadFile("/etc/passwd")
+	if err != nil {
+		return
+	}
+	homes := map[string]string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Split(line, ":")
+		if len(fields) < 6 || !completionMatches(fields[0], word[1:]) {
+			continue
+		}
+		if _, ok := homes["~"+fields[0]]; !ok {
+			homes["~"+fields[0]] = fields[5]
+			comp.Names = append(comp.Names, "~"+fields[0])
+		}
+	}
+	slices.Sort(comp.Names)
+	comp.Expand = func(name string) string {
+		return strings.TrimSuffix(homes[name], "/") + "/"
+	}
+	return
+}
+
+// completeHistoryArgs completes the word in ctx to the arguments given to
+// the same command in the history, most recently used first.
+func completeHistoryArgs(ctx completionContext) (comp lineedit.Completion) {
+	comp.Word = ctx.word
+	history := History()
+	for i := len(history) - 1; i >= 0; i-- {
+		args := parseCompletionContext(history[i] + " ").args
+		if len(args) == 0 || args[0] != ctx.args[0] {
+			continue
+		}
+		for _, arg := range args[1:] {
+			if arg != "" && completionMatches(arg, ctx.word) && !slices.Contains(comp.Names, arg) {
+				comp.Names = append(comp.Names, arg)
+			}
+		}
+	}
+	return
+}
+
+// completePaths completes word to the files and directories it may name,
+// with a / after directories. Hidden entries are only offered when word
+// starts their name with a dot.
+func completePaths(word string) (comp lineedit.Completion) {
+	comp.Word = word
+	dir, base := filepath.Split(word)
+	search := dir
+	if search == "" {
+		search = "."
+	}
+	entries, err := os.ReadDir(search)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !completionMatches(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		candidate := dir + name
+		if info, err := os.Stat(filepath.Join(search, name)); err == nil && info.IsDir() {
+			candidate += "/"
+		}
+		comp.Names = append(comp.Names, candidate)
+	}
+	slices.Sort(comp.Names)
+	return
+}
+
+// completionMatches reports whether name is a candidate for the word being
+// completed: whether it starts with word, or with the completion-fuzzy
+// option, contains the characters of word in order. With the
+// completion-ignore-case option, case is ignored and hyphens and
+// underscores match each other.
+func completionMatches(name, word string) bool {
+	return matchScore(name, word) >= 0
+}
+
+// matchScore rates how well name matches word, lower being better, or
+// returns -1 if it doesn't. Names starting with word come first, then those
+// containing it, the earlier the better, then those containing its
+// characters in order, the closer together the better.
+func matchScore(name, word string) int {
+	if Option("completion-ignore-case") {
+		name, word = foldCompletion(name), foldCompletion(word)
+	}
+	if strings.HasPrefix(name, word) {
+		return 0
+	}
+	if !Option("completion-fuzzy") {
+		return -1
+	}
+	if i := strings.Index(name, word); i >= 0 {
+		return 1 + i
+	}
+	first, last := -1, 0
+	rest := word
+	for i, c := range name {
+		if rest == "" {
+			break
+		}
+		if r, size := utf8.DecodeRuneInString(rest); c == r {
+			if first < 0 {
+				first = i
+			}
+			last = i
+			rest = rest[size:]
+		}
+	}
+	if rest != "" {
+		return -1
+	}
+	return len(name) + 1 + last - first
+}
+
+// foldCompletion folds s for completion-ignore-case, to lower case and with
+// underscores as hyphens.
+func foldCompletion(s string) string {
+	return strings.ReplaceAll(strings.ToLower(s), "_", "-")
+}
+
+// rankCompletion sorts the candidates of comp by how well they match its
+// word, best first, keeping the order of those that match as well.
+func rankCompletion(comp lineedit.Completion) {
+	slices.SortStableFunc(comp.Names, func(a, b string) int {
+		return matchScore(a, comp.Word) - matchScore(b, comp.Word)
+	})
+}
+
+func removeDuplicates(duplicates []string) (after []string) {
+	dup := map[string]struct{}{}
+	for _, v := range duplicates {
+		if _, ok := dup[v]; !ok {
+			dup[v] = struct{}{}
+			after = append(after, v)
+		}
+	}
+	return
+}
+
+func findBuiltinExecutablesHasPrefix(prefix string) (names []string) {
+	for _, v := range Builtins() {
+		if completionMatches(v, prefix) {
+			names = append(names, v)
+		}
+	}
+	return
+}
+
+func findExecutablesHasPrefix(prefix string) (names []string) {
+	for _, dir := range filepath.SplitList(GetVar("PATH")) {
+		for _, name := range pathExecutables(dir) {
+			if completionMatches(name, prefix) {
+				names = append(names, name)
+			}
+		}
+	}
+	return
+}
+
+// completeFlags completes word to the flags of builtin name that start
+// with it, with their descriptions.
+func completeFlags(name, word string) (comp lineedit.Completion) {
+	comp.Word = word
+	comp.Descriptions = map[string]string{}
+	b, ok := LookupBuiltin(name)
+	if !ok {
+		return
+	}
+	for _, flag := range b.Flags {
+		if completionMatches(flag.Name, word) {
+			comp.Names = append(comp.Names, flag.Name)
+			comp.Descriptions[flag.Name] = flag.Description
+		}
+	}
+	return
+}
+
+// hasFlags reports whether name is a builtin that lists the flags it
+// accepts.
+func hasFlags(name string) bool {
+	b, ok := LookupBuiltin(name)
+	return ok && len(b.Flags) > 0
+}
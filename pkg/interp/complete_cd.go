@@ -0,0 +1,110 @@
+package interp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/lineedit"
+)
+
+// candidateDecorator annotates a directory offered as a completion
+// candidate, e.g. with its number of entries.
+type candidateDecorator interface {
+	// decorate returns a short note about the directory at path, or "" if
+	// it has nothing to say about it.
+	decorate(path string) string
+}
+
+// cdDecorators are consulted in order when listing cd candidates; the first
+// one with a note for a candidate wins.
+var cdDecorators = []candidateDecorator{
+	gitBranchDecorator{},
+	entryCountDecorator{},
+}
+
+// gitBranchDecorator notes the checked out branch of git repositories.
+type gitBranchDecorator struct{}
+
+func (gitBranchDecorator) decorate(path string) string {
+	head, err := os.ReadFile(filepath.Join(path, ".git", "HEAD"))
+	if err != nil {
+		return ""
+	}
+	ref := strings.TrimSpace(string(head))
+	if branch, ok := strings.CutPrefix(ref, "ref: refs/heads/"); ok {
+		return glyphBranch.String() + branch
+	}
+	if len(ref) > 7 {
+		ref = ref[:7]
+	}
+	return glyphBranch.String() + ref
+}
+
+// entryCountDecorator notes how many entries a directory holds.
+type entryCountDecorator struct{}
+
+func (entryCountDecorator) decorate(path string) string {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return ""
+	}
+	if len(entries) == 1 {
+		return "1 entry"
+	}
+	return fmt.Sprintf("%d entries", len(entries))
+}
+
+// completeDirectories completes word to the directories it may name as an
+// argument to cd: those relative to the working directory and, for plain
+// relative words, those found under the directories listed in $CDPATH.
+func completeDirectories(word string) (comp lineedit.Completion) {
+	comp.Word = word
+	dir, base := filepath.Split(word)
+	roots := []string{""}
+	if !filepath.IsAbs(word) && !strings.HasPrefix(word, "./") && !strings.HasPrefix(word, "../") {
+		for _, root := range filepath.SplitList(GetVar("CDPATH")) {
+			if root != "" {
+				roots = append(roots, root)
+			}
+		}
+	}
+	paths := map[string]string{}
+	for _, root := range roots {
+		search := filepath.Join(root, dir)
+		if search == "" {
+			search = "."
+		}
+		entries, err := os.ReadDir(search)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if !completionMatches(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+				continue
+			}
+			path := filepath.Join(search, name)
+			if info, err := os.Stat(path); err != nil || !info.IsDir() {
+				continue
+			}
+			candidate := dir + name + "/"
+			if _, ok := paths[candidate]; !ok {
+				paths[candidate] = path
+				comp.Names = append(comp.Names, candidate)
+			}
+		}
+	}
+	slices.Sort(comp.Names)
+	comp.Annotate = func(name string) string {
+		for _, d := range cdDecorators {
+			if note := d.decorate(paths[name]); note != "" {
+				return note
+			}
+		}
+		return ""
+	}
+	return
+}
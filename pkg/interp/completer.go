@@ -0,0 +1,60 @@
+package interp
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/lineedit"
+)
+
+// completerTimeout is how long an external completer may take before its
+// candidates are given up on, so a stuck one doesn't hang the prompt.
+const completerTimeout = 2 * time.Second
+
+// completerCandidate is a candidate as an external completer prints it.
+type completerCandidate struct {
+	Value       string `json:"value"`
+	Description string `json:"description"`
+}
+
+// runCompleter runs the external completer program, registered with
+// complete -J, to complete the word in ctx. The program is run with the
+// words of the command, up to and including the one being completed, after
+// the arguments it was registered with, and COMP_LINE and COMP_POINT in its
+// environment. It prints a JSON array of the candidates:
+//
+//	[{"value": "start", "description": "start the service"}, {"value": "stop"}]
+//
+// Candidates that don't match the word are left out.
+func runCompleter(program string, ctx completionContext) (comp lineedit.Completion) {
+	comp.Word = ctx.word
+	args := session.Split(program)
+	if len(args) == 0 {
+		return
+	}
+	args = append(args, ctx.args...)
+	args = append(args, ctx.word)
+	c, cancel := context.WithTimeout(context.Background(), completerTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(c, args[0], args[1:]...)
+	cmd.Env = append(Environ(), "COMP_LINE="+ctx.line, "COMP_POINT="+strconv.Itoa(len(ctx.line)))
+	out, err := cmd.Output()
+	if err != nil {
+		return
+	}
+	var candidates []completerCandidate
+	if json.Unmarshal(out, &candidates) != nil {
+		return
+	}
+	comp.Descriptions = map[string]string{}
+	for _, candidate := range candidates {
+		if candidate.Value != "" && completionMatches(candidate.Value, ctx.word) {
+			comp.Names = append(comp.Names, candidate.Value)
+			comp.Descriptions[candidate.Value] = candidate.Description
+		}
+	}
+	return
+}
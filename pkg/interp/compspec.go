@@ -0,0 +1,119 @@
+package interp
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/lineedit"
+)
+
+// CompletionSpec is how the arguments of a command are completed, as
+// registered with complete.
+type CompletionSpec struct {
+	// Words holds the candidates given with -W.
+	Words []string
+	// Function is the command line given with -F, run to print candidates
+	// one per line, each optionally followed by a tab and a description.
+	Function string
+	// Program is the external completer given with -J, which prints the
+	// candidates as JSON; see runCompleter.
+	Program string
+}
+
+// completeSpec completes the word in ctx as spec says: to the words of the
+// list that start with it, to the lines printed by the function, run with
+// the command name, the word and the word before it as its positional
+// parameters and COMP_WORDS, COMP_CWORD, COMP_LINE and COMP_POINT set as
+// in bash, and to the candidates of the external completer.
+func (sh *Shell) completeSpec(spec *CompletionSpec, ctx completionContext) (comp lineedit.Completion) {
+	comp.Word = ctx.word
+	comp.Descriptions = map[string]string{}
+	for _, w := range spec.Words {
+		if completionMatches(w, ctx.word) {
+			comp.Names = append(comp.Names, w)
+		}
+	}
+	if spec.Function != "" {
+		prev := ctx.args[len(ctx.args)-1]
+		sh.SetArray("COMP_WORDS", append(slices.Clone(ctx.args), ctx.word))
+		sh.SetVar("COMP_CWORD", strconv.Itoa(len(ctx.args)))
+		sh.SetVar("COMP_LINE", ctx.line)
+		sh.SetVar("COMP_POINT", strconv.Itoa(len(ctx.line)))
+		saved := sh.lastStatus
+		sh.pushScope("complete", []string{ctx.args[0], ctx.word, prev})
+		out := sh.captureOutput(func() { sh.RunLine(spec.Function) })
+		sh.popScope()
+		sh.lastStatus = saved
+		for _, name := range []string{"COMP_WORDS", "COMP_CWORD", "COMP_LINE", "COMP_POINT"} {
+			sh.UnsetVar(name)
+		}
+		for _, line := range strings.Split(out, "\n") {
+			name, desc, _ := strings.Cut(line, "\t")
+			if name != "" && completionMatches(name, ctx.word) {
+				comp.Names = append(comp.Names, name)
+				comp.Descriptions[name] = desc
+			}
+		}
+	}
+	if spec.Program != "" {
+		external := runCompleter(spec.Program, ctx)
+		comp.Names = append(comp.Names, external.Names...)
+		for name, desc := range external.Descriptions {
+			comp.Descriptions[name] = desc
+		}
+	}
+	comp.Names = removeDuplicates(comp.Names)
+	slices.Sort(comp.Names)
+	return
+}
+
+// captureOutput runs f with the standard output of the shell, and of the
+// commands it starts, captured, and returns what was written to it.
+func (sh *Shell) captureOutput(f func()) string {
+	var out strings.Builder
+	saved := sh.stdout
+	sh.stdout = &out
+	f()
+	sh.stdout = saved
+	return out.String()
+}
+
+// Compgen returns the completions of word from the given words and the
+// sources named by the letters of sources, as compgen's flags select them:
+// b builtins, c commands, d directories, f files and directories, u users
+// and v variables.
+func (sh *Shell) Compgen(sources string, words []string, word string) []string {
+	var names []string
+	for _, w := range words {
+		if completionMatches(w, word) {
+			names = append(names, w)
+		}
+	}
+	for _, source := range sources {
+		switch source {
+		case 'b':
+			names = append(names, findBuiltinExecutablesHasPrefix(word)...)
+		case 'c':
+			names = append(names, findBuiltinExecutablesHasPrefix(word)...)
+			names = append(names, findExecutablesHasPrefix(word)...)
+		case 'd', 'f':
+			for _, name := range completePaths(word).Names {
+				if dir, ok := strings.CutSuffix(name, "/"); ok || source == 'f' {
+					names = append(names, dir)
+				}
+			}
+		case 'u':
+			for _, name := range completeUsers("~" + word).Names {
+				names = append(names, name[1:])
+			}
+		case 'v':
+			for _, name := range sh.VarNames("") {
+				if completionMatches(name, word) {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	return removeDuplicates(names)
+}
@@ -0,0 +1,169 @@
+package interp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/lexer"
+)
+
+// editDistance returns the number of single-character insertions,
+// deletions, substitutions and transpositions of adjacent characters it
+// takes to turn a into b.
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	// d[i][j] is the distance between s[:i] and t[:j].
+	d := make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(s)][len(t)]
+}
+
+// closestMatch returns the candidate nearest to word, if one is close
+// enough to be a likely typo: one edit away for short words, two for
+// longer ones.
+func closestMatch(word string, candidates []string) (best string, ok bool) {
+	limit := 1
+	if len(word) > 4 {
+		limit = 2
+	}
+	bestDist := limit + 1
+	for _, cand := range candidates {
+		if cand == word {
+			continue
+		}
+		if dist := editDistance(word, cand); dist < bestDist {
+			best, bestDist = cand, dist
+		}
+	}
+	return best, bestDist <= limit
+}
+
+// correcting reports whether spell correction is on in sh.
+func (sh *Shell) correcting() bool {
+	return (sh.options["correct"] || sh.options["correct-auto"]) && !sh.options["posix"]
+}
+
+// offerCorrection reports whether wrong should be replaced with right. With
+// the correct-auto option it always is; otherwise the user is asked when
+// the shell is interactive, and only told of the suggestion when not.
+func (c *Command) offerCorrection(wrong, right string) bool {
+	sh := c.Shell()
+	if sh.options["correct-auto"] {
+		fmt.Fprintf(c.Stderr, "myshell: correcting %s to %s\n", lexer.Quote(wrong), lexer.Quote(right))
+		return true
+	}
+	if sh.terminal == nil || !sh.terminal.IsTerminal() {
+		fmt.Fprintf(c.Stderr, "myshell: did you mean %s?\n", lexer.Quote(right))
+		return false
+	}
+	return c.ask(fmt.Sprintf("correct %s to %s", lexer.Quote(wrong), lexer.Quote(right)))
+}
+
+// ask asks question on the terminal of the shell and reports whether the
+// user answered yes, with a y. There must be a terminal.
+func (c *Command) ask(question string) bool {
+	sh := c.Shell()
+	// The terminal is made raw first, for an answer typed as soon as the
+	// question shows not to be echoed.
+	restore, err := sh.terminal.MakeRaw()
+	fmt.Fprintf(c.Stderr, "myshell: %s [y/N]? ", question)
+	if err != nil {
+		fmt.Fprintln(c.Stderr)
+		return false
+	}
+	var b [1]byte
+	_, err = sh.terminal.Read(b[:])
+	restore()
+	yes := err == nil && (b[0] == 'y' || b[0] == 'Y')
+	if yes {
+		fmt.Fprintln(c.Stderr, "y")
+	} else {
+		fmt.Fprintln(c.Stderr, "n")
+	}
+	return yes
+}
+
+// correctCommand offers to run c under the builtin or executable name
+// closest to its own, and returns the status and whether it did.
+func (c *Command) correctCommand() (status int, ok bool) {
+	if !c.Shell().correcting() || strings.ContainsRune(c.Name, '/') {
+		return 0, false
+	}
+	candidates := append(Builtins(), findExecutablesHasPrefix("")...)
+	fix, found := closestMatch(c.Name, candidates)
+	if !found || !c.offerCorrection(c.Name, fix) {
+		return 0, false
+	}
+	inner := *c
+	inner.Name = fix
+	if status, ok := inner.runBuiltin(); ok {
+		return status, true
+	}
+	return inner.runExternal(), true
+}
+
+// CorrectDir returns dir with each component that doesn't name a
+// directory replaced by the closest one that does, if the user accepts it.
+func (c *Command) CorrectDir(dir string) (string, bool) {
+	sh := c.Shell()
+	if !sh.correcting() {
+		return "", false
+	}
+	fixed := ""
+	if filepath.IsAbs(dir) {
+		fixed = string(filepath.Separator)
+	}
+	for _, part := range strings.Split(dir, string(filepath.Separator)) {
+		if part == "" {
+			continue
+		}
+		path := filepath.Join(fixed, part)
+		if info, err := os.Stat(sh.Abs(path)); err == nil && info.IsDir() {
+			fixed = path
+			continue
+		}
+		search := sh.Abs(fixed)
+		if fixed == "" {
+			search = sh.dir
+		}
+		entries, err := os.ReadDir(search)
+		if err != nil {
+			return "", false
+		}
+		var names []string
+		for _, entry := range entries {
+			if info, err := os.Stat(filepath.Join(search, entry.Name())); err == nil && info.IsDir() {
+				names = append(names, entry.Name())
+			}
+		}
+		fix, found := closestMatch(part, names)
+		if !found {
+			return "", false
+		}
+		fixed = filepath.Join(fixed, fix)
+	}
+	if fixed == "" || !c.offerCorrection(dir, fixed) {
+		return "", false
+	}
+	return fixed, true
+}
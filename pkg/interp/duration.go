@@ -0,0 +1,153 @@
+package interp
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lastDuration is how long the last command line entered at the prompt
+// took, if it took longer than REPORTTIME, for \c in prompts to show.
+var lastDuration time.Duration
+
+// reportTime returns the REPORTTIME threshold in seconds, and false if it
+// is unset or invalid.
+func reportTime() (time.Duration, bool) {
+	v := GetVar("REPORTTIME")
+	if v == "" {
+		return 0, false
+	}
+	secs, err := strconv.ParseFloat(v, 64)
+	if err != nil || secs < 0 {
+		return 0, false
+	}
+	return time.Duration(secs * float64(time.Second)), true
+}
+
+// TimeLine runs line, then sets CMD_DURATION to its wall time in
+// milliseconds and, if that exceeded REPORTTIME, reports the wall and CPU
+// time it took on standard error and runs the long-command hooks. It stops
+// running line once ctx is done.
+func TimeLine(ctx context.Context, line string) {
+	start := time.Now()
+	user, sys := cpuTimes()
+	RunLineContext(ctx, line)
+	wall := time.Since(start)
+	endUser, endSys := cpuTimes()
+	user, sys = endUser-user, endSys-sys
+	SetVar("CMD_DURATION", strconv.FormatInt(wall.Milliseconds(), 10))
+	lastDuration = 0
+	threshold, ok := reportTime()
+	if !ok || wall <= threshold {
+		return
+	}
+	lastDuration = wall
+	cpu := 0.0
+	if wall > 0 {
+		cpu = 100 * float64(user+sys) / float64(wall)
+	}
+	name := strings.TrimSpace(line)
+	fmt.Fprintf(session.stderr, "%s  %.2fs user %.2fs system %.0f%% cpu %s total\n", name, user.Seconds(), sys.Seconds(), cpu, formatDuration(wall))
+	RunHooks("long-command", name, strconv.Itoa(session.lastStatus))
+}
+
+// cpuTimes returns the user and system CPU time used by the shell and the
+// child processes it has waited for.
+func cpuTimes() (user, sys time.Duration) {
+	user, sys = ShellTimes()
+	childUser, childSys := ChildTimes()
+	return user + childUser, sys + childSys
+}
+
+// defaultTimeFormat is the format of the times time reports with TIMEFORMAT
+// unset.
+const defaultTimeFormat = "\nreal\t%3lR\nuser\t%3lU\nsys\t%3lS"
+
+// reportTimes reports on standard error the time a pipeline run with time
+// took, since start and the CPU times user and sys, in the format of
+// TIMEFORMAT.
+func (sh *Shell) reportTimes(start time.Time, user, sys time.Duration) {
+	wall := time.Since(start)
+	endUser, endSys := cpuTimes()
+	format, ok := sh.LookupVar("TIMEFORMAT")
+	if !ok {
+		format = defaultTimeFormat
+	}
+	if format != "" {
+		fmt.Fprintln(sh.stderr, formatTimes(format, wall, endUser-user, endSys-sys))
+	}
+}
+
+// formatTimes formats the times of a pipeline as TIMEFORMAT does, the way
+// bash does: %R, %U and %S are the real, user and system time in seconds,
+// %P the CPU percentage and %% a %. A digit after the % sets the number
+// of decimals, 3 by default, and an l gives the time in minutes and
+// seconds, like 1m2.500s.
+func formatTimes(format string, wall, user, sys time.Duration) string {
+	var sb strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 == len(format) {
+			sb.WriteByte(format[i])
+			continue
+		}
+		j := i + 1
+		precision := 3
+		if c := format[j]; c >= '0' && c <= '9' {
+			precision = min(int(c-'0'), 3)
+			j++
+		}
+		long := j < len(format) && format[j] == 'l'
+		if long {
+			j++
+		}
+		if j == len(format) {
+			sb.WriteString(format[i:])
+			break
+		}
+		var d time.Duration
+		switch format[j] {
+		case '%':
+			sb.WriteByte('%')
+			i = j
+			continue
+		case 'P':
+			if wall > 0 {
+				fmt.Fprintf(&sb, "%.2f", 100*float64(user+sys)/float64(wall))
+			} else {
+				sb.WriteString("0.00")
+			}
+			i = j
+			continue
+		case 'R':
+			d = wall
+		case 'U':
+			d = user
+		case 'S':
+			d = sys
+		default:
+			sb.WriteString(format[i : j+1])
+			i = j
+			continue
+		}
+		if long {
+			fmt.Fprintf(&sb, "%dm%.*fs", d/time.Minute, precision, (d % time.Minute).Seconds())
+		} else {
+			fmt.Fprintf(&sb, "%.*f", precision, d.Seconds())
+		}
+		i = j
+	}
+	return sb.String()
+}
+
+// formatDuration formats d briefly, like 1.503s, 2m5s or 1h2m.
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%.3fs", d.Seconds())
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%ds", d/time.Minute, d%time.Minute/time.Second)
+	}
+	return fmt.Sprintf("%dh%dm", d/time.Hour, d%time.Hour/time.Minute)
+}
@@ -0,0 +1,51 @@
+package interp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// Error is an error reported by the shell or one of its builtins, with the
+// exit status it causes.
+type Error struct {
+	// Cmd names the command the error is about, or is "" for the shell
+	// itself.
+	Cmd    string
+	Msg    string
+	Status int
+}
+
+func (e *Error) Error() string {
+	if e.Cmd == "" {
+		return "myshell: " + e.Msg
+	}
+	return e.Cmd + ": " + e.Msg
+}
+
+// Report writes err to w, in red with the color-errors option when w is a
+// terminal, and returns the exit status it causes: that of an Error, or 1.
+func (sh *Shell) Report(w io.Writer, err error) int {
+	status := 1
+	var se *Error
+	if errors.As(err, &se) {
+		status = se.Status
+	}
+	msg := err.Error()
+	if f, ok := w.(*os.File); ok && sh.options["color-errors"] && term.IsTerminal(int(f.Fd())) {
+		if color := sh.sgr("red", false); color != "" {
+			msg = color + msg + resetColor
+		}
+	}
+	fmt.Fprintln(w, msg)
+	return status
+}
+
+// Errorf reports an error about c on its standard error and returns
+// status, for builtins to return in turn.
+func (c *Command) Errorf(status int, format string, args ...any) int {
+	return c.Shell().Report(c.Stderr, &Error{Cmd: c.Name, Msg: fmt.Sprintf(format, args...), Status: status})
+}
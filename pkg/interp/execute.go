@@ -0,0 +1,349 @@
+package interp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/lexer"
+	"github.com/codecrafters-io/shell-starter-go/pkg/parser"
+)
+
+// streams are the standard input, output and error a command runs with.
+type streams struct {
+	in       io.Reader
+	out, err io.Writer
+}
+
+// interruptedStatus is the status of commands cut short by the end of
+// their context, that of a command killed by SIGINT.
+const interruptedStatus = 130
+
+// cancelKey is the key of the functions ending the contexts of the lines
+// running, innermost last, in the context of the innermost.
+type cancelKey struct{}
+
+// withCancel returns a context for a line to run in, derived from ctx,
+// that interrupt ends along with those of the lines running it.
+func withCancel(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	outer, _ := ctx.Value(cancelKey{}).([]context.CancelFunc)
+	return context.WithValue(ctx, cancelKey{}, append(slices.Clip(outer), cancel)), cancel
+}
+
+// interrupt ends the context of the line running in ctx and of the lines
+// running it, for a command SIGINT killed: like other shells, myshell takes
+// it that Ctrl+C was meant to stop all of them, not just that command.
+func interrupt(ctx context.Context) {
+	cancels, _ := ctx.Value(cancelKey{}).([]context.CancelFunc)
+	for _, cancel := range cancels {
+		cancel()
+	}
+}
+
+// runList runs the commands of list and returns the status of the last
+// one, leaving $? as it was for a list of none. It stops once ctx is done.
+func (sh *Shell) runList(ctx context.Context, list *parser.List, s streams) int {
+	for _, andOr := range list.Items {
+		if sh.unwinding() {
+			break
+		}
+		if andOr.Background {
+			// Commands in the background run in a subshell, and outlive
+			// the line they are on: the session doesn't wait for them,
+			// and the Run of a Runner returns once they end. Without job
+			// control, they read an empty input rather than compete with
+			// the shell for its own.
+			sub := sh.subshell()
+			bg := streams{strings.NewReader(""), s.out, s.err}
+			sub.job = sh.jobs.start(parser.FormatAndOr(andOr))
+			go func() {
+				sh.jobs.done(sub.job, sub.run(func() int { return sub.runAndOr(sh.ctx, andOr, bg, false) }))
+			}()
+			sh.lastStatus = 0
+			continue
+		}
+		if ctx.Err() != nil {
+			sh.lastStatus = interruptedStatus
+			break
+		}
+		sh.runAndOr(ctx, andOr, s, true)
+	}
+	return sh.lastStatus
+}
+
+// runAndOr runs the pipelines of andOr that the statuses of the ones
+// before call for, and returns the status of the last one run. With
+// setStatus, $? is set to each status in turn.
+func (sh *Shell) runAndOr(ctx context.Context, andOr *parser.AndOr, s streams, setStatus bool) int {
+	status := 0
+	for i, pipeline := range andOr.Pipelines {
+		if sh.unwinding() {
+			break
+		}
+		if i > 0 && (andOr.Ops[i-1] == lexer.AndIf) != (status == 0) {
+			continue
+		}
+		status = sh.runPipeline(ctx, pipeline, s)
+		if setStatus {
+			sh.lastStatus = status
+		}
+	}
+	return status
+}
+
+// runPipeline runs the commands of p at the same time, each reading what
+// the one before writes, and returns the status of the last. Each command
+// of a pipeline of several runs in a subshell of its own.
+func (sh *Shell) runPipeline(ctx context.Context, p *parser.Pipeline, s streams) (status int) {
+	defer func() {
+		if p.Negated {
+			status = boolStatus(status != 0)
+		}
+	}()
+	if p.Timed {
+		user, sys := cpuTimes()
+		defer sh.reportTimes(time.Now(), user, sys)
+	}
+	last := len(p.Commands) - 1
+	if last == 0 {
+		return sh.runCommand(ctx, p.Commands[0], s)
+	}
+	var wg sync.WaitGroup
+	var readers []*os.File
+	in := s.in
+	for _, cmd := range p.Commands[:last] {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return sh.Report(s.err, &Error{Msg: err.Error(), Status: 1})
+		}
+		sub := sh.subshell()
+		wg.Add(1)
+		go func(in io.Reader) {
+			defer wg.Done()
+			sub.run(func() int { return sub.runCommand(ctx, cmd, streams{in, w, s.err}) })
+			w.Close()
+		}(in)
+		in = r
+		readers = append(readers, r)
+	}
+	sub := sh.subshell()
+	status = sub.run(func() int { return sub.runCommand(ctx, p.Commands[last], streams{in, s.out, s.err}) })
+	// Closing the pipes stops the commands still writing to them.
+	for _, r := range readers {
+		r.Close()
+	}
+	wg.Wait()
+	return status
+}
+
+// runCommand runs cmd and returns its status. Loops stop once ctx is done.
+func (sh *Shell) runCommand(ctx context.Context, cmd parser.Command, s streams) int {
+	switch cmd := cmd.(type) {
+	case *parser.SimpleCommand:
+		return sh.runSimpleCommand(ctx, cmd, s)
+	case *parser.If:
+		for clause := cmd; clause != nil; clause = clause.Elif {
+			if sh.runList(ctx, clause.Cond, s) == 0 {
+				return sh.runList(ctx, clause.Then, s)
+			}
+			if clause.Elif == nil && clause.Else != nil {
+				return sh.runList(ctx, clause.Else, s)
+			}
+		}
+		return 0
+	case *parser.For:
+		items := sh.currentScope().args
+		if cmd.In {
+			items = nil
+			for _, item := range cmd.Items {
+				items = append(items, sh.expandFields(item.Text)...)
+			}
+		}
+		sc := sh.currentScope()
+		sc.loops++
+		defer func() { sc.loops-- }()
+		status := 0
+		for _, item := range items {
+			if ctx.Err() != nil {
+				return interruptedStatus
+			}
+			sh.SetVar(cmd.Name, item)
+			status = sh.runList(ctx, cmd.Body, s)
+			if sh.leaveLoop() {
+				break
+			}
+		}
+		return status
+	case *parser.While:
+		sc := sh.currentScope()
+		sc.loops++
+		defer func() { sc.loops-- }()
+		status := 0
+		for {
+			cond := sh.runList(ctx, cmd.Cond, s)
+			if ctx.Err() != nil {
+				return interruptedStatus
+			}
+			if sh.leaveLoop() {
+				return cond
+			}
+			if (cond == 0) == cmd.Until {
+				return status
+			}
+			status = sh.runList(ctx, cmd.Body, s)
+			if sh.leaveLoop() {
+				return status
+			}
+		}
+	case *parser.Group:
+		return sh.runList(ctx, cmd.Body, s)
+	case *parser.FunctionDef:
+		sh.functions[cmd.Name] = cmd
+		return 0
+	}
+	panic(fmt.Sprintf("unknown command %T", cmd))
+}
+
+// runSimpleCommand expands the words of cmd and runs the command they make
+// with its redirections.
+func (sh *Shell) runSimpleCommand(ctx context.Context, cmd *parser.SimpleCommand, s streams) int {
+	c := &Command{Stdin: s.in, Stdout: s.out, Stderr: s.err, sh: sh, ctx: ctx}
+	for _, a := range cmd.Assignments {
+		c.Assignments = append(c.Assignments, strings.Join(sh.Split(a.Text), ""))
+	}
+	// Patterns in [[ ]] need to know which characters were quoted, and a
+	// parameter expanding to nothing there is still an operand.
+	cond := len(cmd.Words) > 0 && cmd.Words[0].Text == "[["
+	var words []string
+	for _, w := range cmd.Words {
+		var fields []string
+		if cond {
+			fields = lexer.Split(w.Text, sh.expandParam, true)
+			if len(fields) == 0 {
+				fields = []string{""}
+			}
+		} else {
+			fields = sh.expandFields(w.Text)
+		}
+		words = append(words, fields...)
+	}
+	if len(words) > 0 {
+		c.Name, c.Args = words[0], words[1:]
+	}
+	files, err := c.redirect(cmd.Redirects)
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	if err != nil {
+		return sh.Report(s.err, &Error{Msg: err.Error(), Status: 1})
+	}
+	start := time.Now()
+	status := c.Run()
+	audit(c, start, status)
+	if c.Name != "" {
+		countCommand(c.Name, time.Since(start))
+	}
+	return status
+}
+
+// expandFields expands the word s of a command into the fields it makes:
+// with the posix option, the values of unquoted parameters are split at the
+// characters in IFS, and otherwise only the word's own blanks split it.
+func (sh *Shell) expandFields(s string) []string {
+	if !sh.options["posix"] {
+		return sh.Split(s)
+	}
+	ifs, ok := sh.LookupVar("IFS")
+	if !ok {
+		ifs = " \t\n"
+	}
+	return lexer.SplitFields(s, sh.expandParam, ifs)
+}
+
+// runFunction runs c as a call to the function f, with its arguments as
+// the positional parameters.
+func (c *Command) runFunction(f *parser.FunctionDef) int {
+	sh := c.Shell()
+	sh.pushScope(c.Name, c.Args)
+	sh.currentScope().function = true
+	defer sh.popScope()
+	status := sh.runCommand(c.Context(), f.Body, streams{c.Stdin, c.Stdout, c.Stderr})
+	sh.returning = false
+	return status
+}
+
+// Break has the n innermost loops of the function or script running left,
+// or all of them if there are fewer, once the command running returns. It
+// fails outside a loop.
+func (sh *Shell) Break(n int) error {
+	loops := sh.currentScope().loops
+	if loops == 0 {
+		return errNoLoop
+	}
+	sh.breaking = min(n, loops)
+	return nil
+}
+
+// Continue has the n-1 innermost loops of the function or script running
+// left, and the next iteration of the one around them started, once the
+// command running returns. It fails outside a loop.
+func (sh *Shell) Continue(n int) error {
+	loops := sh.currentScope().loops
+	if loops == 0 {
+		return errNoLoop
+	}
+	sh.continuing = min(n, loops)
+	return nil
+}
+
+// errNoLoop is the error of break and continue outside a loop.
+var errNoLoop = errors.New("only meaningful in a `for', `while', or `until' loop")
+
+// Return has the function or sourced file running left once the command
+// running returns. It fails outside them.
+func (sh *Shell) Return() error {
+	if sc := sh.currentScope(); !sc.function && !sc.sourced {
+		return errors.New("can only `return' from a function or sourced script")
+	}
+	sh.returning = true
+	return nil
+}
+
+// unwinding reports whether break, continue or return is leaving the
+// commands running, which are then skipped.
+func (sh *Shell) unwinding() bool {
+	return sh.breaking > 0 || sh.continuing > 0 || sh.returning
+}
+
+// leaveLoop is called by a loop once its body or condition ran, and
+// reports whether the loop must stop: break or return leaves it, or
+// continue a loop around it. A continue of the loop itself is done once
+// it is called.
+func (sh *Shell) leaveLoop() bool {
+	switch {
+	case sh.breaking > 0:
+		sh.breaking--
+		return true
+	case sh.continuing > 0:
+		sh.continuing--
+		return sh.continuing > 0
+	}
+	return sh.returning
+}
+
+// boolStatus returns the exit status for a condition: 0 if it holds.
+func boolStatus(ok bool) int {
+	if ok {
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,639 @@
+package interp
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// gitRepo is a git repository read directly from its files, without
+// running git, so that the prompt stays fast.
+type gitRepo struct {
+	// gitDir holds HEAD and the index, and commonDir the objects and
+	// refs, which linked worktrees share with the main one.
+	gitDir, commonDir string
+	worktree          string
+}
+
+// maxAheadBehind limits how many commits are walked on each side when
+// counting how far a branch is ahead of and behind its upstream.
+const maxAheadBehind = 2000
+
+// findGitRepo returns the repository containing dir, if any.
+func findGitRepo(dir string) (*gitRepo, bool) {
+	for {
+		dotGit := filepath.Join(dir, ".git")
+		if info, err := os.Stat(dotGit); err == nil {
+			repo := &gitRepo{gitDir: dotGit, commonDir: dotGit, worktree: dir}
+			if !info.IsDir() {
+				// A linked worktree or submodule has a .git file pointing
+				// at its git directory.
+				data, err := os.ReadFile(dotGit)
+				if err != nil {
+					return nil, false
+				}
+				gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+				if !ok {
+					return nil, false
+				}
+				if !filepath.IsAbs(gitDir) {
+					gitDir = filepath.Join(dir, gitDir)
+				}
+				repo.gitDir, repo.commonDir = gitDir, gitDir
+			}
+			if common, err := os.ReadFile(filepath.Join(repo.gitDir, "commondir")); err == nil {
+				repo.commonDir = strings.TrimSpace(string(common))
+				if !filepath.IsAbs(repo.commonDir) {
+					repo.commonDir = filepath.Join(repo.gitDir, repo.commonDir)
+				}
+			}
+			return repo, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, false
+		}
+		dir = parent
+	}
+}
+
+// head returns the branch checked out, or "" if HEAD is detached, and the
+// commit HEAD points at.
+func (r *gitRepo) head() (branch, hash string) {
+	data, err := os.ReadFile(filepath.Join(r.gitDir, "HEAD"))
+	if err != nil {
+		return "", ""
+	}
+	ref := strings.TrimSpace(string(data))
+	if name, ok := strings.CutPrefix(ref, "ref: "); ok {
+		hash, _ = r.resolveRef(name)
+		return strings.TrimPrefix(name, "refs/heads/"), hash
+	}
+	return "", ref
+}
+
+// resolveRef returns the commit a ref points at, from its loose file or
+// from packed-refs.
+func (r *gitRepo) resolveRef(name string) (string, bool) {
+	for range 10 {
+		data, err := os.ReadFile(filepath.Join(r.commonDir, name))
+		if err != nil {
+			break
+		}
+		ref := strings.TrimSpace(string(data))
+		target, ok := strings.CutPrefix(ref, "ref: ")
+		if !ok {
+			return ref, true
+		}
+		name = target
+	}
+	f, err := os.Open(filepath.Join(r.commonDir, "packed-refs"))
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if hash, ref, ok := strings.Cut(scanner.Text(), " "); ok && ref == name {
+			return hash, true
+		}
+	}
+	return "", false
+}
+
+// upstream returns the remote-tracking ref branch is set to follow in the
+// repository config.
+func (r *gitRepo) upstream(branch string) (string, bool) {
+	f, err := os.Open(filepath.Join(r.commonDir, "config"))
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+	var remote, merge string
+	inSection := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inSection = line == `[branch "`+branch+`"]`
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !inSection || !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "remote":
+			remote = strings.TrimSpace(value)
+		case "merge":
+			merge = strings.TrimSpace(value)
+		}
+	}
+	if remote == "" || merge == "" {
+		return "", false
+	}
+	if remote == "." {
+		return merge, true
+	}
+	return "refs/remotes/" + remote + "/" + strings.TrimPrefix(merge, "refs/heads/"), true
+}
+
+// readObject returns the type and contents of an object, loose or packed.
+func (r *gitRepo) readObject(hash string) (typ string, data []byte, err error) {
+	if len(hash) != 40 {
+		return "", nil, fmt.Errorf("%s: bad object name", hash)
+	}
+	if f, err := os.Open(filepath.Join(r.commonDir, "objects", hash[:2], hash[2:])); err == nil {
+		defer f.Close()
+		zr, err := zlib.NewReader(f)
+		if err != nil {
+			return "", nil, err
+		}
+		raw, err := io.ReadAll(zr)
+		if err != nil {
+			return "", nil, err
+		}
+		header, data, ok := bytes.Cut(raw, []byte{0})
+		if !ok {
+			return "", nil, fmt.Errorf("%s: bad object header", hash)
+		}
+		typ, _, _ = strings.Cut(string(header), " ")
+		return typ, data, nil
+	}
+	sum, err := hex.DecodeString(hash)
+	if err != nil {
+		return "", nil, err
+	}
+	idxFiles, _ := filepath.Glob(filepath.Join(r.commonDir, "objects", "pack", "*.idx"))
+	for _, idx := range idxFiles {
+		if offset, ok := packOffset(idx, sum); ok {
+			return r.readPacked(strings.TrimSuffix(idx, ".idx")+".pack", offset)
+		}
+	}
+	return "", nil, fmt.Errorf("%s: object not found", hash)
+}
+
+// packIndexes caches the pack index files read, which never change once
+// written.
+var packIndexes = map[string][]byte{}
+
+// packOffset looks up the offset of an object in the pack with the version
+// 2 index file idx.
+func packOffset(idx string, sum []byte) (int64, bool) {
+	data, ok := packIndexes[idx]
+	if !ok {
+		var err error
+		if data, err = os.ReadFile(idx); err != nil {
+			return 0, false
+		}
+		packIndexes[idx] = data
+	}
+	if len(data) < 8+256*4 || !bytes.Equal(data[:8], []byte{0xff, 't', 'O', 'c', 0, 0, 0, 2}) {
+		return 0, false
+	}
+	fanout := data[8 : 8+256*4]
+	n := int(binary.BigEndian.Uint32(fanout[255*4:]))
+	lo := 0
+	if sum[0] > 0 {
+		lo = int(binary.BigEndian.Uint32(fanout[(int(sum[0])-1)*4:]))
+	}
+	hi := int(binary.BigEndian.Uint32(fanout[int(sum[0])*4:]))
+	names := 8 + 256*4
+	offsets := names + n*20 + n*4
+	if len(data) < offsets+n*4 {
+		return 0, false
+	}
+	for lo < hi {
+		mid := (lo + hi) / 2
+		switch bytes.Compare(data[names+mid*20:names+mid*20+20], sum) {
+		case -1:
+			lo = mid + 1
+		case 1:
+			hi = mid
+		default:
+			off := binary.BigEndian.Uint32(data[offsets+mid*4:])
+			if off&0x80000000 == 0 {
+				return int64(off), true
+			}
+			large := offsets + n*4 + int(off&0x7fffffff)*8
+			if len(data) < large+8 {
+				return 0, false
+			}
+			return int64(binary.BigEndian.Uint64(data[large:])), true
+		}
+	}
+	return 0, false
+}
+
+var packTypes = map[byte]string{1: "commit", 2: "tree", 3: "blob", 4: "tag"}
+
+// readPacked reads the object at offset in a pack file, applying deltas.
+func (r *gitRepo) readPacked(pack string, offset int64) (typ string, data []byte, err error) {
+	f, err := os.Open(pack)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return "", nil, err
+	}
+	br := bufio.NewReader(f)
+	c, err := br.ReadByte()
+	if err != nil {
+		return "", nil, err
+	}
+	kind := (c >> 4) & 7
+	for c&0x80 != 0 {
+		// The rest of the size varint; the inflated data tells the size.
+		if c, err = br.ReadByte(); err != nil {
+			return "", nil, err
+		}
+	}
+	var baseType string
+	var base []byte
+	switch kind {
+	case 6: // OFS_DELTA
+		c, err := br.ReadByte()
+		if err != nil {
+			return "", nil, err
+		}
+		rel := int64(c & 0x7f)
+		for c&0x80 != 0 {
+			if c, err = br.ReadByte(); err != nil {
+				return "", nil, err
+			}
+			rel = (rel+1)<<7 | int64(c&0x7f)
+		}
+		if baseType, base, err = r.readPacked(pack, offset-rel); err != nil {
+			return "", nil, err
+		}
+	case 7: // REF_DELTA
+		var sum [20]byte
+		if _, err := io.ReadFull(br, sum[:]); err != nil {
+			return "", nil, err
+		}
+		if baseType, base, err = r.readObject(hex.EncodeToString(sum[:])); err != nil {
+			return "", nil, err
+		}
+	}
+	zr, err := zlib.NewReader(br)
+	if err != nil {
+		return "", nil, err
+	}
+	if data, err = io.ReadAll(zr); err != nil {
+		return "", nil, err
+	}
+	if base == nil {
+		return packTypes[kind], data, nil
+	}
+	data, err = applyDelta(base, data)
+	return baseType, data, err
+}
+
+var errBadDelta = errors.New("bad delta")
+
+// applyDelta builds an object from its base and a pack delta of copy and
+// insert instructions.
+func applyDelta(base, delta []byte) ([]byte, error) {
+	varint := func() int {
+		n, shift := 0, 0
+		for len(delta) > 0 {
+			c := delta[0]
+			delta = delta[1:]
+			n |= int(c&0x7f) << shift
+			shift += 7
+			if c&0x80 == 0 {
+				break
+			}
+		}
+		return n
+	}
+	varint() // the base size
+	out := make([]byte, 0, varint())
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+		if op&0x80 == 0 {
+			n := int(op)
+			if n == 0 || n > len(delta) {
+				return nil, errBadDelta
+			}
+			out = append(out, delta[:n]...)
+			delta = delta[n:]
+			continue
+		}
+		var off, size int
+		for i := 0; i < 7; i++ {
+			if op&(1<<i) == 0 {
+				continue
+			}
+			if len(delta) == 0 {
+				return nil, errBadDelta
+			}
+			if i < 4 {
+				off |= int(delta[0]) << (8 * i)
+			} else {
+				size |= int(delta[0]) << (8 * (i - 4))
+			}
+			delta = delta[1:]
+		}
+		if size == 0 {
+			size = 0x10000
+		}
+		if off+size > len(base) {
+			return nil, errBadDelta
+		}
+		out = append(out, base[off:off+size]...)
+	}
+	return out, nil
+}
+
+// commitInfo returns the tree and parents of a commit.
+func (r *gitRepo) commitInfo(hash string) (tree string, parents []string, err error) {
+	typ, data, err := r.readObject(hash)
+	if err != nil {
+		return "", nil, err
+	}
+	if typ != "commit" {
+		return "", nil, fmt.Errorf("%s: not a commit", hash)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			break
+		}
+		if t, ok := strings.CutPrefix(line, "tree "); ok {
+			tree = t
+		} else if p, ok := strings.CutPrefix(line, "parent "); ok {
+			parents = append(parents, p)
+		}
+	}
+	return tree, parents, nil
+}
+
+// gitEntry is a file recorded in a tree or the index.
+type gitEntry struct {
+	hash string
+	mode uint32
+}
+
+// flattenTree adds every file in a tree and its subtrees to files, keyed
+// by path.
+func (r *gitRepo) flattenTree(hash, prefix string, files map[string]gitEntry) error {
+	_, data, err := r.readObject(hash)
+	if err != nil {
+		return err
+	}
+	for len(data) > 0 {
+		header, rest, ok := bytes.Cut(data, []byte{0})
+		if !ok || len(rest) < 20 {
+			return fmt.Errorf("%s: bad tree", hash)
+		}
+		modeStr, name, _ := strings.Cut(string(header), " ")
+		mode, _ := strconv.ParseUint(modeStr, 8, 32)
+		sum := hex.EncodeToString(rest[:20])
+		data = rest[20:]
+		if mode == 040000 {
+			if err := r.flattenTree(sum, prefix+name+"/", files); err != nil {
+				return err
+			}
+			continue
+		}
+		files[prefix+name] = gitEntry{hash: sum, mode: uint32(mode)}
+	}
+	return nil
+}
+
+// indexEntry is a file in the index with the stat data git compares to
+// tell whether the file in the worktree may have changed.
+type indexEntry struct {
+	gitEntry
+	path            string
+	mtimeS, mtimeNS uint32
+	size            uint32
+	stage           int
+}
+
+// readIndex parses the index file, versions 2 to 4.
+func (r *gitRepo) readIndex() ([]indexEntry, error) {
+	data, err := os.ReadFile(filepath.Join(r.gitDir, "index"))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 12 || string(data[:4]) != "DIRC" {
+		return nil, errors.New("bad index")
+	}
+	version := binary.BigEndian.Uint32(data[4:])
+	count := int(binary.BigEndian.Uint32(data[8:]))
+	entries := make([]indexEntry, 0, count)
+	pos, prev := 12, ""
+	for range count {
+		if pos+62 > len(data) {
+			return nil, errors.New("bad index")
+		}
+		e := data[pos:]
+		entry := indexEntry{
+			mtimeS:  binary.BigEndian.Uint32(e[8:]),
+			mtimeNS: binary.BigEndian.Uint32(e[12:]),
+			size:    binary.BigEndian.Uint32(e[36:]),
+		}
+		entry.mode = binary.BigEndian.Uint32(e[24:])
+		entry.hash = hex.EncodeToString(e[40:60])
+		flags := binary.BigEndian.Uint16(e[60:])
+		entry.stage = int(flags>>12) & 3
+		start := pos + 62
+		if flags&0x4000 != 0 && version >= 3 {
+			start += 2
+		}
+		if version == 4 {
+			// The path is prefix-compressed against the previous entry.
+			strip, n := binary.Uvarint(data[start:])
+			if n <= 0 || int(strip) > len(prev) {
+				return nil, errors.New("bad index")
+			}
+			start += n
+			end := bytes.IndexByte(data[start:], 0)
+			if end < 0 {
+				return nil, errors.New("bad index")
+			}
+			entry.path = prev[:len(prev)-int(strip)] + string(data[start:start+end])
+			pos = start + end + 1
+		} else {
+			end := bytes.IndexByte(data[start:], 0)
+			if end < 0 {
+				return nil, errors.New("bad index")
+			}
+			entry.path = string(data[start : start+end])
+			// Entries are padded with NULs to a multiple of eight bytes.
+			pos += (start - pos + end + 8) &^ 7
+		}
+		prev = entry.path
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// modified reports whether the worktree file of entry differs from it.
+// Files whose size and mtime match the index are taken to be unchanged;
+// others are hashed.
+func (r *gitRepo) modified(entry indexEntry) bool {
+	if entry.mode == 0160000 {
+		return false // submodules
+	}
+	path := filepath.Join(r.worktree, filepath.FromSlash(entry.path))
+	info, err := os.Lstat(path)
+	if err != nil {
+		return true
+	}
+	mtime := info.ModTime()
+	if uint32(info.Size()) == entry.size && uint32(mtime.Unix()) == entry.mtimeS && uint32(mtime.Nanosecond()) == entry.mtimeNS {
+		return false
+	}
+	var content []byte
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return true
+		}
+		content = []byte(target)
+	} else if content, err = os.ReadFile(path); err != nil {
+		return true
+	}
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil)) != entry.hash
+}
+
+// status reports whether the index differs from HEAD and whether the
+// worktree differs from the index. Untracked files aren't looked for.
+func (r *gitRepo) status(headHash string) (staged, dirty bool) {
+	entries, err := r.readIndex()
+	if err != nil {
+		return false, false
+	}
+	headFiles := map[string]gitEntry{}
+	if headHash != "" {
+		if tree, _, err := r.commitInfo(headHash); err == nil {
+			r.flattenTree(tree, "", headFiles)
+		}
+	}
+	seen := 0
+	for _, entry := range entries {
+		if entry.stage != 0 {
+			// An unresolved merge conflict.
+			dirty = true
+			continue
+		}
+		if head, ok := headFiles[entry.path]; ok {
+			seen++
+			if head.hash != entry.hash || head.mode != entry.mode {
+				staged = true
+			}
+		} else {
+			staged = true
+		}
+		if !dirty && r.modified(entry) {
+			dirty = true
+		}
+	}
+	if seen != len(headFiles) {
+		staged = true // deletions
+	}
+	return staged, dirty
+}
+
+// ancestors returns up to limit commits reachable from hash, itself
+// included.
+func (r *gitRepo) ancestors(hash string, limit int) map[string]bool {
+	seen := map[string]bool{}
+	queue := []string{hash}
+	for len(queue) > 0 && len(seen) < limit {
+		h := queue[0]
+		queue = queue[1:]
+		if seen[h] {
+			continue
+		}
+		seen[h] = true
+		if _, parents, err := r.commitInfo(h); err == nil {
+			queue = append(queue, parents...)
+		}
+	}
+	return seen
+}
+
+// aheadBehind counts the commits on local that upstream lacks and the
+// other way around.
+func (r *gitRepo) aheadBehind(local, upstream string) (ahead, behind int) {
+	if local == upstream {
+		return 0, 0
+	}
+	l := r.ancestors(local, maxAheadBehind)
+	u := r.ancestors(upstream, maxAheadBehind)
+	for h := range l {
+		if !u[h] {
+			ahead++
+		}
+	}
+	for h := range u {
+		if !l[h] {
+			behind++
+		}
+	}
+	return ahead, behind
+}
+
+// gitPrompt returns the prompt segment for the repository containing dir,
+// or "" outside one: the branch or short commit, how far it is ahead of
+// and behind its upstream, and whether changes are staged or unstaged.
+func gitPrompt(dir string) string {
+	repo, ok := findGitRepo(dir)
+	if !ok {
+		return ""
+	}
+	branch, hash := repo.head()
+	var sb strings.Builder
+	sb.WriteString(glyphBranch.String())
+	switch {
+	case branch != "":
+		sb.WriteString(branch)
+	case len(hash) >= 7:
+		sb.WriteString(hash[:7])
+	}
+	if branch != "" && hash != "" {
+		if ref, ok := repo.upstream(branch); ok {
+			if up, ok := repo.resolveRef(ref); ok {
+				ahead, behind := repo.aheadBehind(hash, up)
+				if ahead > 0 || behind > 0 {
+					sb.WriteByte(' ')
+				}
+				if ahead > 0 {
+					sb.WriteString(glyphAhead.String() + strconv.Itoa(ahead))
+				}
+				if behind > 0 {
+					sb.WriteString(glyphBehind.String() + strconv.Itoa(behind))
+				}
+			}
+		}
+	}
+	staged, dirty := repo.status(hash)
+	if staged || dirty {
+		sb.WriteByte(' ')
+	}
+	if staged {
+		sb.WriteString(glyphStaged.String())
+	}
+	if dirty {
+		sb.WriteString(glyphDirty.String())
+	}
+	return sb.String()
+}
@@ -0,0 +1,55 @@
+package interp
+
+import "strings"
+
+// glyph is a symbol the shell draws in prompts, menus and listings. Each
+// has a Unicode rendering and an ASCII fallback for terminals that can't
+// show it.
+type glyph int
+
+const (
+	glyphBranch glyph = iota
+	glyphAhead
+	glyphBehind
+	glyphStaged
+	glyphDirty
+	glyphPowerline
+	glyphSuccess
+	glyphFailure
+)
+
+// glyphs maps each glyph to its Unicode and ASCII renderings.
+var glyphs = map[glyph]struct{ unicode, ascii string }{
+	glyphBranch:    {"⎇ ", "git:"},
+	glyphAhead:     {"↑", "^"},
+	glyphBehind:    {"↓", "v"},
+	glyphStaged:    {"●", "+"},
+	glyphDirty:     {"✱", "*"},
+	glyphPowerline: {"\ue0b0", ">"},
+	glyphSuccess:   {"✔", "ok"},
+	glyphFailure:   {"✘ ", "!"},
+}
+
+func (g glyph) String() string {
+	if Option("ascii") {
+		return glyphs[g].ascii
+	}
+	return glyphs[g].unicode
+}
+
+// DetectASCII turns on ASCII-only rendering unless the locale uses UTF-8.
+func DetectASCII() {
+	SetOption("ascii", !utf8Locale())
+}
+
+// utf8Locale reports whether the character encoding of the locale, taken
+// from the first of LC_ALL, LC_CTYPE and LANG that is set, is UTF-8.
+func utf8Locale() bool {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if locale := GetVar(name); locale != "" {
+			locale = strings.ToLower(locale)
+			return strings.Contains(locale, "utf-8") || strings.Contains(locale, "utf8")
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package interp
+
+import (
+	"os"
+	"strings"
+)
+
+// HashedCommand is a PATH search result remembered in the hash table.
+type HashedCommand struct {
+	Path string
+	// Hits counts the times the remembered path was used.
+	Hits int
+}
+
+// HashedLookPath returns the path of the executable name runs, consulting
+// the hash table before searching PATH and remembering what it finds.
+// Entries whose file has since disappeared are searched for afresh.
+func (sh *Shell) HashedLookPath(name string) (string, error) {
+	if strings.Contains(name, "/") {
+		return sh.LookPath(name)
+	}
+	if h, ok := sh.hash[name]; ok {
+		if _, err := os.Stat(h.Path); err == nil {
+			h.Hits++
+			return h.Path, nil
+		}
+		delete(sh.hash, name)
+	}
+	path, err := sh.LookPath(name)
+	if err != nil {
+		return "", err
+	}
+	sh.hash[name] = &HashedCommand{Path: path, Hits: 1}
+	return path, nil
+}
+
+// Rehash forgets every cached command lookup and the executables indexed
+// for completion. It runs whenever PATH changes, so commands are looked up
+// in the new PATH.
+func (sh *Shell) Rehash() {
+	clear(sh.hash)
+	clearPathIndex()
+}
+
+// HashedCommands returns a copy of the hash table, keyed by command name.
+func (sh *Shell) HashedCommands() map[string]HashedCommand {
+	table := make(map[string]HashedCommand, len(sh.hash))
+	for name, h := range sh.hash {
+		table[name] = *h
+	}
+	return table
+}
+
+// Hash searches PATH for name and remembers where it was found, without
+// counting a hit.
+func (sh *Shell) Hash(name string) error {
+	path, err := sh.LookPath(name)
+	if err != nil {
+		return err
+	}
+	sh.hash[name] = &HashedCommand{Path: path}
+	return nil
+}
+
+// Unhash forgets where name was found and reports whether it was
+// remembered.
+func (sh *Shell) Unhash(name string) bool {
+	if _, ok := sh.hash[name]; !ok {
+		return false
+	}
+	delete(sh.hash, name)
+	return true
+}
@@ -0,0 +1,164 @@
+package interp
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/lexer"
+	"github.com/codecrafters-io/shell-starter-go/pkg/parser"
+)
+
+// Colors the syntax-highlighting option draws the parts of a line in.
+var (
+	colorCommand  = "green"
+	colorUnknown  = "red"
+	colorQuoted   = "yellow"
+	colorOperator = "cyan"
+	colorVariable = "magenta"
+)
+
+// isOperatorRune reports whether c starts a control or redirection
+// operator, which ends the word before it.
+func isOperatorRune(c rune) bool {
+	return c == '|' || c == '&' || c == ';' || c == '<' || c == '>'
+}
+
+// Highlight returns line with escape sequences coloring command names by
+// whether they resolve, and quoted strings, variables and operators each
+// in their own color. The line is lexed afresh on every call, which is
+// cheap at the length of a command line.
+func Highlight(line []rune) string {
+	var sb strings.Builder
+	paint := func(color string, s string) {
+		if code := sgr(color, false); code != "" && s != "" {
+			sb.WriteString(code + s + resetColor)
+			return
+		}
+		sb.WriteString(s)
+	}
+	commandPos := true
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case unicode.IsSpace(c):
+			sb.WriteRune(c)
+			i++
+		case isOperatorRune(c) || (unicode.IsDigit(c) && i+1 < len(line) && line[i+1] == '>'):
+			j := i + 1
+			for j < len(line) && isOperatorRune(line[j]) {
+				j++
+			}
+			op := string(line[i:j])
+			paint(colorOperator, op)
+			// A redirection is followed by a file name, and anything else
+			// by a command.
+			commandPos = !strings.ContainsAny(op, "<>")
+			i = j
+		default:
+			j := wordEnd(line, i)
+			word := line[i:j]
+			switch {
+			case commandPos && isAssignmentWord(word):
+				highlightWord(&sb, paint, word)
+			case commandPos:
+				name := splitWordsOrRaw(word)
+				if len(Resolve(name, false)) > 0 {
+					paint(colorCommand, string(word))
+				} else {
+					paint(colorUnknown, string(word))
+				}
+				commandPos = name == "command" || name == "in" || name == "retry"
+			default:
+				highlightWord(&sb, paint, word)
+			}
+			i = j
+		}
+	}
+	return sb.String()
+}
+
+// wordEnd returns the index just past the word starting at line[i], which
+// runs to the first unquoted space or operator, or to the end of the line
+// if a quote is left open.
+func wordEnd(line []rune, i int) int {
+	var quote rune
+	for ; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && quote != '\'':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case unicode.IsSpace(c) || isOperatorRune(c):
+			return i
+		}
+	}
+	return len(line)
+}
+
+// isAssignmentWord reports whether word is a NAME=value assignment.
+func isAssignmentWord(word []rune) bool {
+	_, _, ok := parser.ParseAssignment(string(word))
+	return ok
+}
+
+// splitWordsOrRaw returns the text of word with its quotes removed, or
+// word itself if it doesn't split into exactly one word.
+func splitWordsOrRaw(word []rune) string {
+	if words := session.Split(string(word)); len(words) == 1 {
+		return words[0]
+	}
+	return string(word)
+}
+
+// highlightWord writes word with its quoted parts and variables colored.
+func highlightWord(sb *strings.Builder, paint func(color, s string), word []rune) {
+	for i := 0; i < len(word); {
+		c := word[i]
+		switch {
+		case c == '\\':
+			end := min(i+2, len(word))
+			sb.WriteString(string(word[i:end]))
+			i = end
+		case c == '\'' || c == '"':
+			j := i + 1
+			for j < len(word) && word[j] != c {
+				if word[j] == '\\' && c == '"' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(word))
+			paint(colorQuoted, string(word[i:j]))
+			i = j
+		case c == '$':
+			j := i + 1
+			switch {
+			case j < len(word) && word[j] == '{':
+				for j < len(word) && word[j] != '}' {
+					j++
+				}
+				j = min(j+1, len(word))
+			case j < len(word) && strings.ContainsRune("?$#@*0123456789", word[j]):
+				j++
+			default:
+				for j < len(word) && lexer.IsNameChar(word[j]) {
+					j++
+				}
+			}
+			if j == i+1 {
+				sb.WriteRune(c)
+			} else {
+				paint(colorVariable, string(word[i:j]))
+			}
+			i = j
+		default:
+			sb.WriteRune(c)
+			i++
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package interp
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// history holds the command lines entered at the prompt, oldest first,
+// including those loaded from the history file of earlier sessions.
+var history struct {
+	sync.Mutex
+	lines []string
+}
+
+// DataDir returns the directory the shell keeps its persistent state in.
+func (sh *Shell) DataDir() string {
+	if dir := sh.GetVar("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "myshell")
+	}
+	return filepath.Join(sh.GetVar("HOME"), ".local", "share", "myshell")
+}
+
+func (sh *Shell) historyFile() string {
+	if file := sh.GetVar("HISTFILE"); file != "" {
+		return sh.Abs(file)
+	}
+	return filepath.Join(sh.DataDir(), "history")
+}
+
+func LoadHistory() {
+	f, err := os.Open(session.historyFile())
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	history.Lock()
+	defer history.Unlock()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			history.lines = append(history.lines, line)
+		}
+	}
+}
+
+// AddHistory records a line entered at the prompt, skipping blank lines and
+// immediate repeats, and appends it to the history file.
+func AddHistory(line string) {
+	history.Lock()
+	defer history.Unlock()
+	if strings.TrimSpace(line) == "" || (len(history.lines) > 0 && history.lines[len(history.lines)-1] == line) {
+		return
+	}
+	history.lines = append(history.lines, line)
+	file := session.historyFile()
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
+
+// History returns the lines entered at the prompt, oldest first.
+func History() []string {
+	history.Lock()
+	defer history.Unlock()
+	return slices.Clone(history.lines)
+}
+
+// SetHistory replaces the history with lines and rewrites the history file
+// of sh to match.
+func (sh *Shell) SetHistory(lines []string) error {
+	history.Lock()
+	defer history.Unlock()
+	history.lines = lines
+	var sb strings.Builder
+	for _, line := range history.lines {
+		sb.WriteString(line + "\n")
+	}
+	return os.WriteFile(sh.historyFile(), []byte(sb.String()), 0600)
+}
@@ -0,0 +1,75 @@
+package interp
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strconv"
+)
+
+// HookEvents lists the events commands can be hooked to:
+//
+//	chpwd    after every change of working directory
+//	precmd   before each prompt is printed
+//	preexec  after a line is entered, before it runs, with the line as $1
+//	command-not-found
+//	         instead of the error for a command that isn't found, with its
+//	         name and arguments as the positional parameters
+//	job-done before the prompt after a command run in the background
+//	         finished, with its status as $1
+//	long-command
+//	         after a line entered at the prompt ran for longer than
+//	         REPORTTIME, with the line as $1 and its status as $2
+var HookEvents = []string{"chpwd", "precmd", "preexec", "command-not-found", "job-done", "long-command"}
+
+// hookPayload is what a hook plugin is told of the event it runs for.
+type hookPayload struct {
+	Event string   `json:"event"`
+	Args  []string `json:"args"`
+	Dir   string   `json:"cwd"`
+	// Status is $? when the event happened, and PID the shell's process
+	// ID.
+	Status int `json:"status"`
+	PID    int `json:"pid"`
+}
+
+// RunHooks runs the commands hooked to event with args as the positional
+// parameters, then the plugins hooked to it, and returns the status of the
+// last one and whether any ran. They leave $? as it was.
+func (sh *Shell) RunHooks(event string, args ...string) (status int, ok bool) {
+	if len(sh.Hooks[event])+len(sh.HookPlugins[event]) == 0 || sh.runningHooks[event] {
+		return 0, false
+	}
+	sh.runningHooks[event] = true
+	defer delete(sh.runningHooks, event)
+	saved := sh.lastStatus
+	sh.pushScope(event, args)
+	for _, line := range sh.Hooks[event] {
+		status = sh.RunLine(line)
+	}
+	sh.popScope()
+	sh.lastStatus = saved
+	for _, plugin := range sh.HookPlugins[event] {
+		status = sh.runHookPlugin(plugin, event, args, saved)
+	}
+	return status, true
+}
+
+// runHookPlugin runs the executable plugin for event, found in PATH as
+// commands are and subject to the same policy, and returns its status.
+func (sh *Shell) runHookPlugin(plugin, event string, args []string, status int) int {
+	payload, _ := json.Marshal(hookPayload{Event: event, Args: append([]string{}, args...), Dir: sh.dir, Status: status, PID: os.Getpid()})
+	c := &Command{Name: plugin, Args: args, Stdin: bytes.NewReader(payload), Stdout: sh.stdout, Stderr: sh.stderr, sh: sh}
+	sh.WithVars([]string{"MYSHELL_HOOK_EVENT=" + event}, func() {
+		status = c.runExternal()
+	})
+	return status
+}
+
+// RunJobHooks runs the job-done hooks for each command run in the
+// background that finished since it last ran.
+func (sh *Shell) RunJobHooks() {
+	for _, status := range sh.jobs.finished() {
+		sh.RunHooks("job-done", strconv.Itoa(status))
+	}
+}
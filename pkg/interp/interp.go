@@ -0,0 +1,317 @@
+// Package interp runs the commands of the shell and holds its state: its
+// variables and options, the working directory, the scripts being run, the
+// history, and what it knows to complete words and draw prompts with.
+package interp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/lexer"
+	"github.com/codecrafters-io/shell-starter-go/pkg/parser"
+)
+
+// Command is a command being run, as builtins are given it.
+type Command struct {
+	Name string
+	Args []string
+	// Assignments holds the NAME=value assignments preceding the command.
+	Assignments []string
+	Stdin       io.Reader
+	Stdout      io.Writer
+	Stderr      io.Writer
+
+	// sh is the shell the command runs in, or nil for the session's, and
+	// ctx the context it runs in, or nil for that of the shell.
+	sh  *Shell
+	ctx context.Context
+}
+
+// Shell returns the shell c runs in, whose state it reads and changes.
+func (c *Command) Shell() *Shell {
+	if c.sh == nil {
+		return session
+	}
+	return c.sh
+}
+
+// WithContext returns a copy of c that runs in ctx.
+func (c *Command) WithContext(ctx context.Context) *Command {
+	c2 := *c
+	c2.ctx = ctx
+	return &c2
+}
+
+// Context returns the context c runs in, which is done when c should stop:
+// builtins that run for a while or wait give up then, and executables are
+// killed.
+func (c *Command) Context() context.Context {
+	if c.ctx == nil {
+		return c.Shell().ctx
+	}
+	return c.ctx
+}
+
+// Builtin is a command built into the shell.
+type Builtin struct {
+	Name string
+	// Run runs the builtin and returns its exit status. Files its output
+	// is redirected to are closed once it returns.
+	Run func(c *Command) int
+	// Flags lists the flags it accepts, for completion.
+	Flags []Flag
+}
+
+// Flag is a flag a builtin accepts, with a short description of what it
+// does.
+type Flag struct {
+	Name, Description string
+}
+
+// builtins holds the builtins registered, in the order they were.
+var builtins []*Builtin
+
+// Register adds b to the builtins, in place of any of the same name.
+func Register(b *Builtin) {
+	for i, old := range builtins {
+		if old.Name == b.Name {
+			builtins[i] = b
+			return
+		}
+	}
+	builtins = append(builtins, b)
+}
+
+// LookupBuiltin returns the builtin named name.
+func LookupBuiltin(name string) (*Builtin, bool) {
+	for _, b := range builtins {
+		if b.Name == name {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+// Builtins returns the names of the builtins, in the order they were
+// registered.
+func Builtins() []string {
+	names := make([]string, len(builtins))
+	for i, b := range builtins {
+		names[i] = b.Name
+	}
+	return names
+}
+
+// LastStatus returns the exit status of the last command run.
+func (sh *Shell) LastStatus() int {
+	return sh.lastStatus
+}
+
+// Split splits s into words the way a command line is, removing quotes and
+// expanding parameters.
+func (sh *Shell) Split(s string) []string {
+	return lexer.Split(s, sh.expandParam, false)
+}
+
+// RunLine parses and runs a line of input in the shell's context and
+// returns its exit status.
+func (sh *Shell) RunLine(line string) int {
+	return sh.RunLineContext(sh.ctx, line)
+}
+
+// RunLineContext is like RunLine, but stops running the line once ctx is
+// done, killing the commands it started and setting $? to 130. A command
+// killed by SIGINT stops it too.
+func (sh *Shell) RunLineContext(ctx context.Context, line string) int {
+	list, ok := sh.parseLine(line)
+	if !ok {
+		return sh.lastStatus
+	}
+	ctx, cancel := withCancel(ctx)
+	defer cancel()
+	sh.runList(ctx, list, streams{sh.stdin, sh.stdout, sh.stderr})
+	if ctx.Err() != nil {
+		sh.lastStatus = interruptedStatus
+	}
+	return sh.lastStatus
+}
+
+// parseLine parses line, reporting a syntax error in it and setting $? for
+// it, and returns the commands it runs and whether it could.
+func (sh *Shell) parseLine(line string) (*parser.List, bool) {
+	list, err := parser.Parse(line)
+	if err != nil {
+		sh.lastStatus = sh.Report(sh.stderr, sh.syntaxError(err.(*parser.SyntaxError)))
+		return nil, false
+	}
+	return list, true
+}
+
+// syntaxError returns the error to report for err, with the line it is on:
+// of the file being sourced or script being run, if there is one, or of the
+// command itself.
+func (sh *Shell) syntaxError(err *parser.SyntaxError) *Error {
+	sc := sh.currentScope()
+	e := &Error{Msg: err.Msg, Status: 2}
+	if err.Pos.Line > 0 {
+		e.Msg = fmt.Sprintf("line %d: %s", sc.line+err.Pos.Line, err.Msg)
+	}
+	if sc.sourced {
+		e.Cmd = sc.name
+	}
+	return e
+}
+
+// redirect opens the files of redirects and points c's input and output at
+// them, returning the files opened for the caller to close.
+func (c *Command) redirect(redirects []*parser.Redirect) (files []*os.File, err error) {
+	for _, r := range redirects {
+		file := c.Shell().Split(r.File.Text)
+		if len(file) != 1 {
+			return files, fmt.Errorf("%s: ambiguous redirect", r.File.Text)
+		}
+		flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		switch {
+		case r.Fd < 0 || r.Fd > 2:
+			return files, fmt.Errorf("%d: bad file descriptor", r.Fd)
+		case r.Fd == 0:
+			flag = os.O_RDONLY
+		case r.Append:
+			flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		}
+		f, err := os.OpenFile(c.Shell().Abs(file[0]), flag, 0644)
+		if err != nil {
+			return files, err
+		}
+		files = append(files, f)
+		switch r.Fd {
+		case 0:
+			c.Stdin = f
+		case 1:
+			c.Stdout = f
+		case 2:
+			c.Stderr = f
+		}
+	}
+	return files, nil
+}
+
+// Run runs c with its variable assignments in effect, as the function,
+// builtin or PATH executable it names, and returns its exit status.
+// Assignments without a command set shell variables instead.
+func (c *Command) Run() (status int) {
+	sh := c.Shell()
+	if c.Name == "" {
+		for _, a := range c.Assignments {
+			name, value, _ := parser.ParseAssignment(a)
+			sh.SetVar(name, value)
+		}
+		return 0
+	}
+	sh.WithVars(c.Assignments, func() {
+		if f, ok := sh.lookupFunction(c.Name); ok {
+			status = c.runFunction(f)
+			return
+		}
+		status = c.Exec()
+	})
+	return status
+}
+
+// Exec runs c as the builtin or PATH executable it names, leaving out its
+// variable assignments, and returns its exit status.
+func (c *Command) Exec() int {
+	if status, ok := c.runBuiltin(); ok {
+		return status
+	}
+	return c.runExternal()
+}
+
+// runBuiltin runs c if it names a builtin and returns its exit status and
+// whether it did.
+func (c *Command) runBuiltin() (status int, ok bool) {
+	b, ok := LookupBuiltin(c.Name)
+	if !ok {
+		return 0, false
+	}
+	if !c.allowed("") {
+		return c.refuse(), true
+	}
+	return b.Run(c), true
+}
+
+// runExternal runs c as an executable found in PATH and returns its exit
+// status.
+func (c *Command) runExternal() int {
+	sh := c.Shell()
+	path, err := sh.HashedLookPath(c.Name)
+	if err != nil {
+		if status, ok := sh.RunHooks("command-not-found", append([]string{c.Name}, c.Args...)...); ok {
+			return status
+		}
+		if status, ok := c.correctCommand(); ok {
+			return status
+		}
+		return c.Errorf(127, "command not found")
+	}
+	if !c.allowed(path) {
+		return c.refuse()
+	}
+	command := exec.CommandContext(c.Context(), path, c.Args...)
+	command.Args[0] = c.Name
+	command.Dir = sh.dir
+	sb := c.sandboxFor(path)
+	if sb != nil {
+		if err := sandboxCommand(command, sb); err != nil {
+			return c.Errorf(126, "%v", err)
+		}
+	}
+	command.Env = sh.Environ()
+	command.Stdin = c.Stdin
+	command.Stdout = c.Stdout
+	command.Stderr = c.Stderr
+	// The executables a job starts are recorded in it, with their
+	// priority, for jobs to list and nice to renice.
+	var priority Priority
+	if sb != nil {
+		priority = sb.priority
+	}
+	if err = command.Start(); err == nil {
+		sh.jobs.started(sh.job, command.Process.Pid, priority)
+		err = command.Wait()
+		sh.jobs.exited(sh.job, command.Process.Pid)
+	}
+	addChildTimes(command.ProcessState)
+	if err != nil {
+		var execErr *exec.ExitError
+		if errors.As(err, &execErr) {
+			if ws, ok := execErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() && ws.Signal() == syscall.SIGINT {
+				interrupt(c.Context())
+			}
+			// The limits are those of the policy's sandbox as well as
+			// those of the context.
+			if sb != nil && exceededCPU(execErr.ProcessState, sb.limits) {
+				return c.Errorf(exitStatus(execErr.ProcessState), "CPU time limit of %ds exceeded", cpuSeconds(sb.limits.CPU))
+			}
+			return exitStatus(execErr.ProcessState)
+		}
+		// The file was found but couldn't be run, e.g. for lack of
+		// permission.
+		return c.Errorf(126, "%v", errors.Unwrap(err))
+	}
+	return 0
+}
+
+// exitStatus returns the exit status of a finished process, or 128 plus
+// the signal number if a signal killed it.
+func exitStatus(state *os.ProcessState) int {
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return 128 + int(ws.Signal())
+	}
+	return state.ExitCode()
+}
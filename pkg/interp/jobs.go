@@ -0,0 +1,253 @@
+package interp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+// jobs is the job table: the commands run in the background, in the order
+// they started, and the statuses of those that finished since RunJobHooks
+// last ran. They finish on goroutines of their own, where hooks can't run.
+type jobs struct {
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	table    []*job
+	statuses []int
+}
+
+// job is a command run in the background. Its id and command are set when
+// it starts; the mu of its table guards the rest.
+type job struct {
+	id      int
+	command string
+	// priority is that of the executables it runs, as the last of them
+	// started with, and adjust what nice added to it since it started,
+	// which those starting after get too.
+	priority, adjust Priority
+	// pids holds the process IDs of the executables running.
+	pids   map[int]bool
+	done   chan struct{}
+	status int
+}
+
+// Job describes a job of the job table, which %ID names.
+type Job struct {
+	ID int
+	// Command is the command it runs, as Format writes it.
+	Command string
+	// Done is set once it finished, with the status it finished with.
+	Done   bool
+	Status int
+	// Priority is that of the executables it runs, and PIDs the process
+	// IDs of those running.
+	Priority Priority
+	PIDs     []int
+}
+
+// start adds a job running command to the table, numbered with the lowest
+// number no other job has, and returns it.
+func (j *jobs) start(command string) *job {
+	j.wg.Add(1)
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	id := 1
+	for slices.ContainsFunc(j.table, func(jb *job) bool { return jb.id == id }) {
+		id++
+	}
+	jb := &job{id: id, command: command, pids: map[int]bool{}, done: make(chan struct{})}
+	j.table = append(j.table, jb)
+	return jb
+}
+
+// done records that jb finished with status. It stays in the table until
+// its status is reported or waited for.
+func (j *jobs) done(jb *job, status int) {
+	defer j.wg.Done()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	jb.status = status
+	close(jb.done)
+	j.statuses = append(j.statuses, status)
+}
+
+// count returns the number of jobs running.
+func (j *jobs) count() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	n := 0
+	for _, jb := range j.table {
+		if !jb.finished() {
+			n++
+		}
+	}
+	return n
+}
+
+// finished returns the statuses of the jobs that finished since it was
+// last called, forgetting them.
+func (j *jobs) finished() []int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.table = slices.DeleteFunc(j.table, (*job).finished)
+	statuses := j.statuses
+	j.statuses = nil
+	return statuses
+}
+
+// finished reports whether jb finished.
+func (jb *job) finished() bool {
+	select {
+	case <-jb.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// started records that jb, if not nil, started the executable with process
+// ID pid, at priority p.
+func (j *jobs) started(jb *job, pid int, p Priority) {
+	if jb == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	jb.pids[pid] = true
+	jb.priority = p
+}
+
+// exited records that the executable with process ID pid that jb, if not
+// nil, started has exited.
+func (j *jobs) exited(jb *job, pid int) {
+	if jb == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	delete(jb.pids, pid)
+}
+
+// adjustment returns what nice added to the priority of jb, if not nil,
+// since it started.
+func (j *jobs) adjustment(jb *job) Priority {
+	if jb == nil {
+		return Priority{}
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return jb.adjust
+}
+
+// lookup returns the job spec names: %n for job n, and %%, %+ or % for the
+// job started last.
+func (j *jobs) lookup(spec string) (*job, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	switch spec {
+	case "%", "%%", "%+":
+		if len(j.table) > 0 {
+			return j.table[len(j.table)-1], nil
+		}
+	default:
+		if len(spec) > 1 && spec[0] == '%' {
+			if id, err := strconv.Atoi(spec[1:]); err == nil {
+				if i := slices.IndexFunc(j.table, func(jb *job) bool { return jb.id == id }); i >= 0 {
+					return j.table[i], nil
+				}
+			}
+		}
+	}
+	return nil, fmt.Errorf("%s: no such job", spec)
+}
+
+// forget removes jb from the table.
+func (j *jobs) forget(jb *job) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.table = slices.DeleteFunc(j.table, func(other *job) bool { return other == jb })
+}
+
+// Jobs returns the jobs of the job table of sh, by number. Those done are
+// forgotten once returned, as reported.
+func (sh *Shell) Jobs() []Job {
+	j := sh.jobs
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var list []Job
+	for _, jb := range j.table {
+		job := Job{ID: jb.id, Command: jb.command, Priority: jb.priority}
+		if job.Done = jb.finished(); job.Done {
+			job.Status = jb.status
+		}
+		for pid := range jb.pids {
+			job.PIDs = append(job.PIDs, pid)
+		}
+		slices.Sort(job.PIDs)
+		list = append(list, job)
+	}
+	j.table = slices.DeleteFunc(j.table, (*job).finished)
+	slices.SortFunc(list, func(a, b Job) int { return a.ID - b.ID })
+	return list
+}
+
+// WaitJob waits for the job spec names to finish, or for ctx to be done,
+// and returns the status it finished with, or that of an interrupted
+// command. The job is forgotten once it finished. It fails if spec names
+// no job.
+func (sh *Shell) WaitJob(ctx context.Context, spec string) (int, error) {
+	jb, err := sh.jobs.lookup(spec)
+	if err != nil {
+		return 127, err
+	}
+	select {
+	case <-jb.done:
+	case <-ctx.Done():
+		return interruptedStatus, nil
+	}
+	sh.jobs.forget(jb)
+	return jb.status, nil
+}
+
+// WaitJobs waits for every job of the job table to finish, or for ctx to
+// be done, and returns 0, or the status of an interrupted command.
+func (sh *Shell) WaitJobs(ctx context.Context) int {
+	sh.jobs.mu.Lock()
+	table := slices.Clone(sh.jobs.table)
+	sh.jobs.mu.Unlock()
+	for _, jb := range table {
+		select {
+		case <-jb.done:
+		case <-ctx.Done():
+			return interruptedStatus
+		}
+		sh.jobs.forget(jb)
+	}
+	return 0
+}
+
+// ReniceJob adds the niceness of p to that of the executables the job spec
+// names runs, and gives them its I/O class, if any, as it does those the
+// job starts after.
+func (sh *Shell) ReniceJob(spec string, p Priority) error {
+	jb, err := sh.jobs.lookup(spec)
+	if err != nil {
+		return err
+	}
+	j := sh.jobs
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for pid := range jb.pids {
+		// An executable that has just exited is no longer there to renice.
+		if err := setPriority(pid, p); err != nil && !errors.Is(err, syscall.ESRCH) {
+			return fmt.Errorf("%s: %v", spec, err)
+		}
+	}
+	jb.adjust = jb.adjust.add(p)
+	jb.priority = jb.priority.add(p)
+	return nil
+}
@@ -0,0 +1,7 @@
+package interp
+
+import "syscall"
+
+// rlimitMemory is the resource limit Limits.Memory sets, the data segment
+// for want of a limit on the whole address space.
+const rlimitMemory = syscall.RLIMIT_DATA
@@ -0,0 +1,8 @@
+//go:build unix && !openbsd
+
+package interp
+
+import "syscall"
+
+// rlimitMemory is the resource limit Limits.Memory sets.
+const rlimitMemory = syscall.RLIMIT_AS
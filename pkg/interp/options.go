@@ -0,0 +1,77 @@
+package interp
+
+import "slices"
+
+// OptionInfo describes a shell option: its name, the letter that turns it
+// on and off as a flag, if any, and what it does.
+type OptionInfo struct {
+	Name        string
+	Letter      rune
+	Description string
+}
+
+// options is the registry of the shell options, sorted by name.
+var options = []OptionInfo{
+	{Name: "accessible", Description: "plain output screen readers can follow, without bells or redrawing"},
+	{Name: "ascii", Description: "ASCII glyphs in prompts instead of Unicode ones"},
+	{Name: "audit", Description: "log each command run as a line of JSON to AUDITLOG"},
+	{Name: "autopair-brackets", Description: "insert the closing bracket when typing an opening one"},
+	{Name: "autopair-quotes", Description: "insert the closing quote when typing an opening one"},
+	{Name: "autosuggestions", Description: "suggest the rest of the line from the history"},
+	{Name: "bash-completion", Description: "complete with the bash-completion package's completions"},
+	{Name: "color-errors", Description: "show errors in red on a terminal"},
+	{Name: "completion-fuzzy", Description: "complete to candidates containing the typed characters in order"},
+	{Name: "completion-ignore-case", Description: "complete ignoring case"},
+	{Name: "correct", Description: "offer corrections for misspelled commands"},
+	{Name: "correct-auto", Description: "correct misspelled commands without asking"},
+	{Name: "noexec", Letter: 'n', Description: "check scripts for syntax errors without running their commands"},
+	{Name: "posix", Description: "behave more like a POSIX sh, for scripts written for one"},
+	{Name: "syntax-highlighting", Description: "color the command line as it is typed"},
+	{Name: "verbose", Letter: 'v', Description: "echo each line of input to standard error as it is read"},
+}
+
+// Options returns the shell options, sorted by name.
+func Options() []OptionInfo {
+	return slices.Clone(options)
+}
+
+// ShortOptions maps the letters of the options set and the shell itself
+// take as single-letter flags, like -n, to their names.
+var ShortOptions = func() map[rune]string {
+	m := map[rune]string{}
+	for _, o := range options {
+		if o.Letter != 0 {
+			m[o.Letter] = o.Name
+		}
+	}
+	return m
+}()
+
+// FlagOptions holds the options given as flags to the shell, like -n, on
+// or off as they were given, which the config file doesn't override.
+var FlagOptions = map[string]bool{}
+
+// Option reports whether the shell option name is on. The options are
+// toggled with `set -o name` and `set +o name`, and every option is off
+// unless enabled.
+func (sh *Shell) Option(name string) bool {
+	return sh.options[name]
+}
+
+// SetOption turns the shell option name on or off, and reports whether
+// there is such an option.
+func (sh *Shell) SetOption(name string, on bool) bool {
+	if _, ok := sh.options[name]; !ok {
+		return false
+	}
+	sh.options[name] = on
+	return true
+}
+
+// DetectAccessibility turns on accessible mode when the ACCESSIBLE
+// environment variable asks for it.
+func (sh *Shell) DetectAccessibility() {
+	if v := sh.GetVar("ACCESSIBLE"); v != "" && v != "0" {
+		sh.options["accessible"] = true
+	}
+}
@@ -0,0 +1,79 @@
+package interp
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// indexedDir holds the executables found in a PATH directory when it was
+// last modified at modTime.
+type indexedDir struct {
+	modTime time.Time
+	names   []string
+}
+
+// pathIndex caches the executables in each PATH directory, by its absolute
+// path, so completing command names doesn't read every directory on each
+// Tab. A directory is read again once its modification time changes, that
+// is when files are added to or removed from it; rehash empties the index
+// for the rest, such as a file made executable.
+var pathIndex = struct {
+	sync.Mutex
+	dirs map[string]*indexedDir
+}{dirs: map[string]*indexedDir{}}
+
+// IndexPath fills the index for the directories of path in the background,
+// so that the first Tab is as quick as the rest.
+func IndexPath(path string) {
+	go func() {
+		for _, dir := range filepath.SplitList(path) {
+			pathExecutables(dir)
+		}
+	}()
+}
+
+// pathExecutables returns the names of the executables in the PATH
+// directory dir, from the index if it is up to date.
+func pathExecutables(dir string) []string {
+	if dir == "" {
+		dir = "."
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return nil
+	}
+	pathIndex.Lock()
+	defer pathIndex.Unlock()
+	if d, ok := pathIndex.dirs[abs]; ok && d.modTime.Equal(info.ModTime()) {
+		return d.names
+	}
+	d := &indexedDir{modTime: info.ModTime()}
+	// Only the entries of dir itself are commands, not those of the
+	// directories in it, and they are executables by the rule the commands
+	// are run by.
+	entries, _ := os.ReadDir(abs)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if _, err := exec.LookPath(abs + string(filepath.Separator) + entry.Name()); err == nil {
+			d.names = append(d.names, entry.Name())
+		}
+	}
+	pathIndex.dirs[abs] = d
+	return d.names
+}
+
+// clearPathIndex forgets the executables indexed.
+func clearPathIndex() {
+	pathIndex.Lock()
+	clear(pathIndex.dirs)
+	pathIndex.Unlock()
+}
@@ -0,0 +1,224 @@
+package interp
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/lexer"
+)
+
+// PolicyFile is where administrators put the policy of which commands may
+// run, unless the shell is given another with --policy.
+const PolicyFile = "/etc/myshell/policy"
+
+// policyRule allows or denies the commands it matches, or with a sandbox
+// has them run in it: those whose name matches the first pattern and whose
+// arguments match the rest, each the one in its place. Further arguments
+// don't matter.
+type policyRule struct {
+	allow    bool
+	sandbox  *sandbox
+	patterns []string
+}
+
+// policy holds the rules loaded from the policy file, first match first.
+var policy []policyRule
+
+// LoadPolicy reads the policy of which commands may run from file. Each
+// line of it is a rule, allow or deny followed by glob patterns, quoted
+// the way words of a command are:
+//
+//	deny rm -rf
+//	allow git
+//	allow /usr/bin/*
+//
+// The first rule matching a command decides whether it may run. A pattern
+// for the name with a slash in it matches the path of the executable the
+// name resolves to, cleaned or with its symlinks resolved, and a * in it
+// doesn't match a slash. One without matches the name without its
+// directory, as typed and of that executable: any of them for deny rules,
+// and all of them for allow rules. Commands no rule matches may run, unless the
+// policy has allow rules, which makes it a list of the only commands
+// allowed besides cd, exit and the other builtins in coreBuiltins: only
+// deny rules keep those from running.
+//
+// On Linux, sandbox rules have the executables they match run in a
+// sandbox, the first rule's, with the restrictions before the patterns:
+// nonet for no network, ro for a read-only filesystem, write=dir for a
+// read-only filesystem but for dir, contain for namespaces of its own, and
+// cpu=seconds, mem=size and files=count for resource limits, separated by
+// commas, with \, for a comma in a directory:
+//
+//	sandbox nonet,write=/tmp,cpu=600 make
+//	sandbox 'write=/srv/a\,b' make
+func LoadPolicy(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var rules []policyRule
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words := lexer.Split(line, nil, true)
+		switch {
+		case len(words) >= 3 && words[0] == "sandbox":
+			// The restrictions are taken without the backslashes that
+			// keep quoted characters of patterns from being wildcards.
+			sb, err := parseSandbox(splitRestrictions(lexer.Split(line, nil, false)[1]))
+			if err != nil {
+				return fmt.Errorf("%s: line %d: %v", file, n, err)
+			}
+			rules = append(rules, policyRule{sandbox: sb, patterns: words[2:]})
+		case len(words) >= 2 && (words[0] == "allow" || words[0] == "deny"):
+			rules = append(rules, policyRule{allow: words[0] == "allow", patterns: words[1:]})
+		default:
+			return fmt.Errorf("%s: line %d: expected allow, deny or sandbox and a command", file, n)
+		}
+		if pattern := rules[len(rules)-1].patterns[0]; strings.Contains(pattern, "/") {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("%s: line %d: %s: invalid pattern", file, n, pattern)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	policy = rules
+	return nil
+}
+
+// splitRestrictions splits the restrictions of a sandbox rule at the
+// commas between them, taking \, for a comma in one.
+func splitRestrictions(s string) []string {
+	var rs []string
+	var r strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == ',':
+			r.WriteByte(',')
+			i++
+		case s[i] == ',':
+			rs = append(rs, r.String())
+			r.Reset()
+		default:
+			r.WriteByte(s[i])
+		}
+	}
+	return append(rs, r.String())
+}
+
+// coreBuiltins are the builtins the allow rules of a policy don't keep
+// from running, without which the user couldn't so much as leave the
+// shell.
+var coreBuiltins = []string{"break", "cd", "continue", "dirs", "echo", "exit", "help", "local", "popd", "pushd", "pwd", "return", "type"}
+
+// policyPaths returns the paths a pattern for the executable at path, or a
+// builtin if path is "", is matched against: the path made absolute
+// against dir and cleaned, unless it has .. in it, which a symlink before it can take
+// anywhere, and the path with every symlink in it resolved. Each names the
+// file that runs.
+func policyPaths(dir, path string) []string {
+	if path == "" {
+		return nil
+	}
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") && !filepath.IsAbs(path) {
+		path = filepath.ToSlash(dir) + "/" + path
+	}
+	var paths []string
+	if !slices.Contains(strings.Split(path, "/"), "..") {
+		paths = append(paths, filepath.ToSlash(filepath.Clean(path)))
+	}
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		if real = filepath.ToSlash(real); !slices.Contains(paths, real) {
+			paths = append(paths, real)
+		}
+	}
+	return paths
+}
+
+// matches reports whether r matches running args, the name and arguments
+// of a command, as the executable policyPaths gives paths for.
+//
+// A pattern for the name without a slash is matched against the last
+// component of the name as typed and of each path, since /bin/rm, ./rm and
+// a link named ls to rm all run rm. Deny and sandbox rules match if any of
+// them matches, and allow rules only if all of them do, so that neither a
+// path nor another name in PATH gets a command past the policy.
+func (r policyRule) matches(args []string, paths []string) bool {
+	if len(args) < len(r.patterns) {
+		return false
+	}
+	if strings.Contains(r.patterns[0], "/") {
+		if !slices.ContainsFunc(paths, func(p string) bool {
+			ok, _ := path.Match(r.patterns[0], p)
+			return ok
+		}) {
+			return false
+		}
+	} else {
+		names := []string{path.Base(filepath.ToSlash(args[0]))}
+		for _, p := range paths {
+			names = append(names, path.Base(p))
+		}
+		matched := slices.ContainsFunc(names, func(name string) bool {
+			return lexer.MatchPattern(r.patterns[0], name)
+		})
+		if r.allow {
+			matched = !slices.ContainsFunc(names, func(name string) bool {
+				return !lexer.MatchPattern(r.patterns[0], name)
+			})
+		}
+		if !matched {
+			return false
+		}
+	}
+	for i, pattern := range r.patterns[1:] {
+		if !lexer.MatchPattern(pattern, args[i+1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// allowed reports whether the policy lets c run as the executable at path,
+// or as a builtin if path is "".
+func (c *Command) allowed(path string) bool {
+	args := append([]string{c.Name}, c.Args...)
+	paths := policyPaths(c.Shell().dir, path)
+	hasAllow := false
+	for _, r := range policy {
+		if r.sandbox != nil {
+			continue
+		}
+		if r.matches(args, paths) {
+			return r.allow
+		}
+		hasAllow = hasAllow || r.allow
+	}
+	return !hasAllow || (path == "" && slices.Contains(coreBuiltins, c.Name))
+}
+
+// refuse reports that the policy doesn't let c run and logs it where the
+// audit option logs commands, whether the option is on or not.
+func (c *Command) refuse() int {
+	writeAudit(c.Shell(), auditRecord{
+		Time:   time.Now(),
+		Dir:    c.Shell().dir,
+		Args:   append([]string{c.Name}, c.Args...),
+		Status: 126,
+		Denied: true,
+	})
+	return c.Errorf(126, "denied by policy")
+}
@@ -0,0 +1,190 @@
+package interp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// loadTestPolicy loads a policy file of lines for the rest of the test.
+func loadTestPolicy(t *testing.T, lines ...string) {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "policy")
+	if err := os.WriteFile(file, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadPolicy(file); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { policy = nil })
+}
+
+// TestPolicyPrecedence checks that the first rule matching a command
+// decides whether it may run, and that allow rules deny what they don't
+// match but the core builtins.
+func TestPolicyPrecedence(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy []string
+		args   []string
+		// path is that of the executable run, or "" for a builtin.
+		path string
+		want bool
+	}{
+		{"no rules", nil, []string{"rm", "-rf", "/"}, "/bin/rm", true},
+		{"deny first", []string{"deny rm -rf", "allow rm"}, []string{"rm", "-rf", "x"}, "/bin/rm", false},
+		{"allow first", []string{"allow rm", "deny rm -rf"}, []string{"rm", "-rf", "x"}, "/bin/rm", true},
+		{"arguments not matching", []string{"deny rm -rf"}, []string{"rm", "x"}, "/bin/rm", true},
+		{"too few arguments", []string{"deny rm -rf"}, []string{"rm"}, "/bin/rm", true},
+		{"arguments after the patterns", []string{"deny git push"}, []string{"git", "push", "-f"}, "/bin/git", false},
+		{"allow list", []string{"allow git"}, []string{"ls"}, "/bin/ls", false},
+		{"allow list match", []string{"allow git"}, []string{"git", "status"}, "/bin/git", true},
+		{"allow list builtin", []string{"allow git"}, []string{"source", "x"}, "", false},
+		{"allow list core builtin", []string{"allow git"}, []string{"exit", "1"}, "", true},
+		{"denied core builtin", []string{"deny cd", "allow git"}, []string{"cd", "/"}, "", false},
+		{"denied by a pattern", []string{"deny *", "allow git"}, []string{"exit"}, "", false},
+		{"sandbox rules don't allow", []string{"sandbox nonet curl", "allow git"}, []string{"curl"}, "/bin/curl", false},
+		{"name pattern", []string{"allow g*"}, []string{"grep"}, "/bin/grep", true},
+		{"argument pattern", []string{"deny rm -*r*"}, []string{"rm", "-fr", "x"}, "/bin/rm", false},
+		{"quoted pattern", []string{"deny 'rm*'"}, []string{"rmdir"}, "/bin/rmdir", true},
+		{"path pattern", []string{"allow /usr/bin/*"}, []string{"ls"}, "/usr/bin/ls", true},
+		{"path pattern for a builtin", []string{"allow /usr/bin/*"}, []string{"source"}, "", false},
+		{"path pattern across a slash", []string{"allow /usr/*"}, []string{"ls"}, "/usr/bin/ls", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loadTestPolicy(t, tt.policy...)
+			c := &Command{Name: tt.args[0], Args: tt.args[1:]}
+			if got := c.allowed(tt.path); got != tt.want {
+				t.Errorf("%q as %q under %q: allowed = %v, want %v", tt.args, tt.path, tt.policy, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPolicyPaths checks that path patterns match the file an executable
+// is, however its path gets there: neither .. nor symlinks get a file
+// outside the directories allowed past the policy.
+func TestPolicyPaths(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range []string{"bin", "tmp"} {
+		if err := os.Mkdir(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range []string{"bin/tool", "tmp/evil"} {
+		if err := os.WriteFile(filepath.Join(dir, f), nil, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("../tmp/evil", filepath.Join(dir, "bin", "link")); err != nil {
+		t.Skipf("no symlinks: %v", err)
+	}
+	if err := os.Symlink("../tmp", filepath.Join(dir, "bin", "sub")); err != nil {
+		t.Fatal(err)
+	}
+	allow := []string{"allow " + dir + "/bin/*"}
+	deny := []string{"deny " + dir + "/tmp/*"}
+	tests := []struct {
+		name   string
+		policy []string
+		path   string
+		want   bool
+	}{
+		{"allowed", allow, "/bin/tool", true},
+		{"doubled slash", allow, "/bin//tool", true},
+		{"dot", allow, "/bin/./tool", true},
+		{"traversal", allow, "/bin/../tmp/evil", false},
+		{"traversal back", allow, "/tmp/../bin/tool", true},
+		{"symlinked directory", allow, "/bin/sub/evil", false},
+		{"traversal through a symlink", allow, "/bin/sub/../bin/tool", true},
+		{"symlink in an allowed directory", allow, "/bin/link", true},
+		{"denied", deny, "/tmp/evil", false},
+		{"denied by traversal", deny, "/bin/../tmp/evil", false},
+		{"denied through a symlink", deny, "/bin/link", false},
+		{"denied through a symlinked directory", deny, "/bin/sub/evil", false},
+		{"not denied", deny, "/bin/tool", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loadTestPolicy(t, tt.policy...)
+			c := &Command{Name: filepath.Base(tt.path)}
+			if got := c.allowed(dir + tt.path); got != tt.want {
+				t.Errorf("%s under %q: allowed = %v, want %v", tt.path, tt.policy, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPolicyNames checks that a pattern for the name matches the command
+// however it is named: by a path, or by a name in PATH that is another
+// executable.
+func TestPolicyNames(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "rm"), nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("../rm", filepath.Join(dir, "bin", "ls")); err != nil {
+		t.Skipf("no symlinks: %v", err)
+	}
+	tests := []struct {
+		name   string
+		policy []string
+		// typed is the name of the command as typed, and path the path it
+		// resolves to, if relative, in the directory of the test.
+		typed, path string
+		want        bool
+	}{
+		{"denied by /bin/rm", []string{"deny rm"}, "/bin/rm", "/bin/rm", false},
+		{"denied by a path", []string{"deny rm"}, dir + "/rm", "rm", false},
+		{"denied by a relative path", []string{"deny rm"}, "./rm", "rm", false},
+		{"denied by another name in PATH", []string{"deny rm"}, "ls", "bin/ls", false},
+		{"not denied", []string{"deny rm"}, "ls", "ls", true},
+		{"allowed by a path", []string{"allow rm"}, "./rm", "rm", true},
+		{"allowed name of another executable", []string{"allow ls"}, "ls", "bin/ls", false},
+		{"allowed name", []string{"allow ls"}, "ls", "ls", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loadTestPolicy(t, tt.policy...)
+			path := tt.path
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			c := &Command{Name: tt.typed}
+			if got := c.allowed(path); got != tt.want {
+				t.Errorf("%s as %s under %q: allowed = %v, want %v", tt.typed, tt.path, tt.policy, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPolicySandbox checks that the restrictions of sandbox rules are
+// split at commas but for escaped ones, and make it whole through the
+// arguments of --sandbox-exec.
+func TestPolicySandbox(t *testing.T) {
+	loadTestPolicy(t, `sandbox 'nonet,write=/srv/a\,b,cpu=5' make`)
+	sb := policy[0].sandbox
+	if want := []string{"/srv/a,b"}; !slices.Equal(sb.writable, want) {
+		t.Errorf("writable = %q, want %q", sb.writable, want)
+	}
+	parsed, err := parseSandbox(sb.restrictions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, sb) {
+		t.Errorf("parsed %q back as %+v, want %+v", sb.restrictions(), parsed, sb)
+	}
+}
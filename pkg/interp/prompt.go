@@ -0,0 +1,120 @@
+package interp
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Prompt returns the prompt template in the variable name, or
+// fallback if it is unset, with its escapes expanded. A theme in use draws
+// PS1 and RPROMPT instead.
+func Prompt(name, fallback string) string {
+	if name == "PS1" || name == "RPROMPT" {
+		if prompt, ok := themedPrompt(name == "RPROMPT"); ok {
+			return prompt
+		}
+	}
+	tmpl, ok := LookupVar(name)
+	if !ok {
+		tmpl = fallback
+	}
+	return expandPrompt(tmpl)
+}
+
+// expandPrompt expands the backslash escapes in a prompt template:
+//
+//	\w  the working directory, with ~ for HOME
+//	\W  the last element of the working directory
+//	\u  the user name
+//	\h  the host name up to the first dot
+//	\g  the git branch and status of the working directory, if in a
+//	    repository
+//	\$  # for root, $ for anyone else
+//	\c  how long the last command took, if longer than REPORTTIME
+//	\?  the exit status of the last command
+//	\x  a green mark if the last command succeeded, or a red one and
+//	    its exit status if it failed
+//	\t  the time as HH:MM:SS
+//	\A  the time as HH:MM
+//	\n  a newline
+//	\e  an escape character, to start a terminal control sequence
+//	\\  a backslash
+func expandPrompt(tmpl string) string {
+	var sb strings.Builder
+	for i := 0; i < len(tmpl); i++ {
+		if tmpl[i] != '\\' || i+1 == len(tmpl) {
+			sb.WriteByte(tmpl[i])
+			continue
+		}
+		i++
+		switch tmpl[i] {
+		case 'w':
+			sb.WriteString(session.TildePath(WorkingDir()))
+		case 'W':
+			wd := WorkingDir()
+			if tilde := session.TildePath(wd); tilde == "~" {
+				sb.WriteString(tilde)
+			} else {
+				sb.WriteString(filepath.Base(wd))
+			}
+		case 'g':
+			sb.WriteString(gitPrompt(WorkingDir()))
+		case 'u':
+			if u, err := user.Current(); err == nil {
+				sb.WriteString(u.Username)
+			}
+		case 'h':
+			host, _ := os.Hostname()
+			host, _, _ = strings.Cut(host, ".")
+			sb.WriteString(host)
+		case '$':
+			if os.Geteuid() == 0 {
+				sb.WriteByte('#')
+			} else {
+				sb.WriteByte('$')
+			}
+		case 'c':
+			if lastDuration > 0 {
+				sb.WriteString(formatDuration(lastDuration))
+			}
+		case '?':
+			sb.WriteString(strconv.Itoa(session.lastStatus))
+		case 'x':
+			if session.lastStatus == 0 {
+				sb.WriteString(sgr("green", false) + glyphSuccess.String() + resetColor)
+			} else {
+				sb.WriteString(sgr("red", false) + glyphFailure.String() + strconv.Itoa(session.lastStatus) + resetColor)
+			}
+		case 't':
+			sb.WriteString(time.Now().Format("15:04:05"))
+		case 'A':
+			sb.WriteString(time.Now().Format("15:04"))
+		case 'n':
+			sb.WriteString("\r\n")
+		case 'e':
+			sb.WriteByte('\x1b')
+		default:
+			sb.WriteByte(tmpl[i])
+		}
+	}
+	return sb.String()
+}
+
+// TildePath abbreviates HOME at the start of path to ~.
+func (sh *Shell) TildePath(path string) string {
+	home := sh.GetVar("HOME")
+	if home == "" || home == "/" {
+		return path
+	}
+	if path == home {
+		return "~"
+	}
+	if rest, ok := strings.CutPrefix(path, home+string(filepath.Separator)); ok {
+		return "~" + string(filepath.Separator) + rest
+	}
+	return path
+}
@@ -0,0 +1,77 @@
+package interp
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/terminal"
+)
+
+// sessionRecording is a recording of the session to file.
+type sessionRecording struct {
+	file *os.File
+	// terminal, out and err are the terminal, standard output and
+	// standard error of the shell before the recording started.
+	terminal terminal.Terminal
+	out, err io.Writer
+}
+
+// StartRecording starts recording the session on the terminal to file, or
+// by default to a file named after the time in the recordings directory of
+// the shell's data directory, and returns the file. Commands run while it
+// records write to the terminal through a pipe, for their output to be
+// recorded too; as they don't write to a terminal, those that check for
+// one, like ls for colors and full-screen programs, behave as they would
+// with their output redirected.
+func (sh *Shell) StartRecording(file string) (string, error) {
+	if sh.recording != nil {
+		return "", errors.New("already recording to " + sh.recording.file.Name())
+	}
+	if sh.terminal == nil || !sh.terminal.IsTerminal() {
+		return "", errors.New("no terminal to record")
+	}
+	if file == "" {
+		dir := filepath.Join(sh.DataDir(), "recordings")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", err
+		}
+		file = filepath.Join(dir, time.Now().Format("2006-01-02T15-04-05")+".cast")
+	}
+	f, err := os.OpenFile(sh.Abs(file), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", err
+	}
+	r, err := terminal.NewRecorder(sh.terminal, f)
+	if err != nil {
+		f.Close()
+		return "", err
+	}
+	sh.recording = &sessionRecording{f, sh.terminal, sh.stdout, sh.stderr}
+	sh.terminal, sh.stdout, sh.stderr = r, r.Tee(sh.stdout), r.Tee(sh.stderr)
+	return file, nil
+}
+
+// StopRecording stops the session recording and returns the file it was
+// made to.
+func (sh *Shell) StopRecording() (string, error) {
+	if sh.recording == nil {
+		return "", errors.New("not recording")
+	}
+	sh.terminal, sh.stdout, sh.stderr = sh.recording.terminal, sh.recording.out, sh.recording.err
+	file := sh.recording.file.Name()
+	err := sh.recording.file.Close()
+	sh.recording = nil
+	return file, err
+}
+
+// RecordingFile returns the file the session is being recorded to, or ""
+// if it isn't.
+func (sh *Shell) RecordingFile() string {
+	if sh.recording == nil {
+		return ""
+	}
+	return sh.recording.file.Name()
+}
@@ -0,0 +1,130 @@
+package interp
+
+import (
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/parser"
+)
+
+// Kind classifies what a command name resolves to.
+type Kind string
+
+const (
+	KindFunction Kind = "function"
+	KindBuiltin  Kind = "builtin"
+	KindFile     Kind = "file"
+)
+
+// Resolution is one way a command name can be resolved.
+type Resolution struct {
+	Kind Kind
+	// Path is the executable's path for KindFile.
+	Path string
+}
+
+// Resolve returns the ways name resolves, in the order the shell
+// tries them when running it. Unless all is set, it stops at the first.
+func (sh *Shell) Resolve(name string, all bool) (res []Resolution) {
+	if _, ok := sh.lookupFunction(name); ok {
+		res = append(res, Resolution{Kind: KindFunction})
+		if !all {
+			return
+		}
+	}
+	if _, ok := LookupBuiltin(name); ok {
+		res = append(res, Resolution{Kind: KindBuiltin})
+		if !all {
+			return
+		}
+	}
+	paths := sh.lookPathAll(name)
+	if !all && len(paths) > 0 {
+		paths = paths[:1]
+	}
+	for _, path := range paths {
+		res = append(res, Resolution{Kind: KindFile, Path: path})
+	}
+	return
+}
+
+// specialBuiltins are the builtins POSIX has found before functions, which
+// the posix option does.
+var specialBuiltins = []string{".", ":", "break", "continue", "eval", "exec", "exit", "export", "readonly", "return", "set", "shift", "times", "trap", "unset"}
+
+// lookupFunction returns the function name runs, if it runs one.
+func (sh *Shell) lookupFunction(name string) (*parser.FunctionDef, bool) {
+	if sh.options["posix"] && slices.Contains(specialBuiltins, name) {
+		if _, ok := LookupBuiltin(name); ok {
+			return nil, false
+		}
+	}
+	f, ok := sh.functions[name]
+	return f, ok
+}
+
+// Functions returns the functions defined, sorted by name.
+func (sh *Shell) Functions() []*parser.FunctionDef {
+	fs := make([]*parser.FunctionDef, 0, len(sh.functions))
+	for _, f := range sh.functions {
+		fs = append(fs, f)
+	}
+	slices.SortFunc(fs, func(a, b *parser.FunctionDef) int { return strings.Compare(a.Name, b.Name) })
+	return fs
+}
+
+// LookPath returns the path of the executable name runs.
+func (sh *Shell) LookPath(name string) (string, error) {
+	if paths := sh.lookPathAll(name); len(paths) > 0 {
+		return paths[0], nil
+	}
+	return "", exec.ErrNotFound
+}
+
+// lookPathAll returns every executable name may refer to: name itself if
+// it contains a slash, otherwise each match in the PATH directories in
+// order. Relative paths are taken from the working directory of sh, and
+// stay relative.
+func (sh *Shell) lookPathAll(name string) (paths []string) {
+	if name == "" {
+		return
+	}
+	if strings.Contains(name, "/") {
+		if path, err := sh.lookPath(name); err == nil {
+			paths = append(paths, path)
+		}
+		return
+	}
+	for _, dir := range filepath.SplitList(sh.GetVar("PATH")) {
+		if dir == "" {
+			dir = "."
+		}
+		// Joining by hand keeps "./name" from being cleaned into a bare
+		// name, which LookPath would search PATH for again.
+		path, err := sh.lookPath(dir + string(filepath.Separator) + name)
+		if err != nil {
+			continue
+		}
+		if !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+	return
+}
+
+// lookPath is exec.LookPath for a path containing a slash, relative to the
+// working directory of sh if it isn't absolute.
+func (sh *Shell) lookPath(path string) (string, error) {
+	if filepath.IsAbs(path) {
+		return exec.LookPath(path)
+	}
+	abs := sh.Abs(path)
+	found, err := exec.LookPath(abs)
+	if err != nil {
+		return "", err
+	}
+	// On Windows, what was found may have an extension added.
+	return path + strings.TrimPrefix(found, abs), nil
+}
@@ -0,0 +1,158 @@
+package interp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Runner runs shell source for a Go program embedding the shell. Each
+// Runner has a Shell of its own, whose variables, functions, options,
+// hooks, working directory, positional parameters and exit status it keeps
+// from one Run to the next; only the builtins are shared with every other
+// Runner and the shell itself. The working directory is the Runner's own,
+// which the commands it runs start in, not that of the process, so that
+// Runners can run at the same time.
+//
+// A program running commands with limits, at another priority, contained
+// or under a policy with sandbox rules must call MaybeSandboxExec first
+// thing in main; without it, those commands fail to start.
+type Runner struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	env    []string
+	dir    string
+
+	// mu serializes the Runs of the Runner, which share its Shell.
+	mu sync.Mutex
+	sh *Shell
+}
+
+// RunnerOption configures a Runner made by New.
+type RunnerOption func(r *Runner)
+
+// StdIO sets the standard input, output and error of the commands a Runner
+// runs. A nil in is an empty input, and a nil out or err discards what is
+// written to it. Without StdIO, a Runner uses those of the process.
+func StdIO(in io.Reader, out, err io.Writer) RunnerOption {
+	return func(r *Runner) {
+		if in == nil {
+			in = strings.NewReader("")
+		}
+		if out == nil {
+			out = io.Discard
+		}
+		if err == nil {
+			err = io.Discard
+		}
+		r.stdin, r.stdout, r.stderr = in, out, err
+	}
+}
+
+// Env sets the environment a Runner starts with, as NAME=value pairs which
+// become exported variables. Without Env, it is that of the process.
+func Env(env []string) RunnerOption {
+	return func(r *Runner) {
+		r.env = env
+	}
+}
+
+// Dir sets the working directory a Runner starts in. Without Dir, it is
+// that of the process.
+func Dir(dir string) RunnerOption {
+	return func(r *Runner) {
+		r.dir = dir
+	}
+}
+
+// New returns a Runner configured by opts. It fails if the working
+// directory it would start in isn't a directory.
+func New(opts ...RunnerOption) (*Runner, error) {
+	r := &Runner{
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+		env:    os.Environ(),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.dir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		r.dir = wd
+	}
+	dir, err := filepath.Abs(r.dir)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(dir); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, &os.PathError{Op: "chdir", Path: dir, Err: errors.New("not a directory")}
+	}
+	r.dir = dir
+	// The commands of a pipeline and those in the background write at the
+	// same time, which a file takes but an io.Writer in general doesn't.
+	var mu sync.Mutex
+	r.stdout = lockWriter(&mu, r.stdout)
+	r.stderr = lockWriter(&mu, r.stderr)
+	r.sh = newShell(dir)
+	r.sh.stdin, r.sh.stdout, r.sh.stderr = r.stdin, r.stdout, r.stderr
+	// There is no one at a terminal to ask questions of.
+	r.sh.terminal = nil
+	r.sh.exit = func(code int) { panic(exitCode(code)) }
+	loadEnv(r.sh.variables, r.env)
+	r.sh.variables["PWD"] = &Variable{Value: dir, Exported: true}
+	return r, nil
+}
+
+// lockedWriter is an io.Writer writing to w under mu.
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return lw.w.Write(p)
+}
+
+// lockWriter returns w, or if it isn't a file, a writer writing to it
+// under mu.
+func lockWriter(mu *sync.Mutex, w io.Writer) io.Writer {
+	if _, ok := w.(*os.File); ok {
+		return w
+	}
+	return &lockedWriter{mu: mu, w: w}
+}
+
+// Run runs src, which may span several lines, and returns the exit status
+// of the last command it ran, or that given to exit. It returns an error if
+// ctx is done before src has run. The commands it starts are killed when
+// ctx is done, and it returns once those it ran in the background have
+// finished too, after running their job-done hooks. Runs of the same
+// Runner wait for each other.
+func (r *Runner) Run(ctx context.Context, src string) (status int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sh.ctx = ctx
+	status = r.sh.run(func() int {
+		err = r.sh.RunScript(ctx, strings.NewReader(src))
+		return r.sh.lastStatus
+	})
+	r.sh.jobs.wg.Wait()
+	r.sh.run(func() int {
+		r.sh.RunJobHooks()
+		return status
+	})
+	return status, err
+}
@@ -0,0 +1,100 @@
+package interp
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRunnerDir checks that a Runner keeps a working directory of its own,
+// which its commands and redirections go by, without changing that of the
+// process.
+func TestRunnerDir(t *testing.T) {
+	if _, err := exec.LookPath("ls"); err != nil {
+		t.Skip("no ls")
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	r, dir := newTestRunner(t, &out)
+	if _, err := r.Run(context.Background(), "cd sub\necho x > made\nls\necho $PWD"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "made\n" + filepath.Join(dir, "sub") + "\n"; out.String() != want {
+		t.Errorf("printed %q, want %q", out.String(), want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sub", "made")); err != nil {
+		t.Errorf("redirection: %v", err)
+	}
+	if got, _ := os.Getwd(); got != wd {
+		t.Errorf("working directory of the process changed from %s to %s", wd, got)
+	}
+}
+
+// TestRunnersAtOnce checks that Runners run at the same time each in their
+// own working directory, with their own variables.
+func TestRunnersAtOnce(t *testing.T) {
+	if _, err := exec.LookPath("ls"); err != nil {
+		t.Skip("no ls")
+	}
+	var wg sync.WaitGroup
+	for i := range 4 {
+		var out bytes.Buffer
+		r, dir := newTestRunner(t, &out)
+		name := string(rune('a' + i))
+		if err := os.WriteFile(filepath.Join(dir, "sub", name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			src := "x=" + name + "\nfor i in 1 2 3 4 5; do\ncd sub\nls\ncd ..\ndone\necho $x"
+			if _, err := r.Run(context.Background(), src); err != nil {
+				t.Error(err)
+				return
+			}
+			want := ""
+			for range 5 {
+				want += name + "\n"
+			}
+			want += name + "\n"
+			if out.String() != want {
+				t.Errorf("Runner %s printed %q, want %q", name, out.String(), want)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// TestRunnerJobs checks that Run returns once the commands it ran in the
+// background have finished, or been killed with the end of its context.
+func TestRunnerJobs(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("no sleep")
+	}
+	var out bytes.Buffer
+	r, _ := newTestRunner(t, &out)
+	if _, err := r.Run(context.Background(), "sleep 0.1 && echo late &\necho now"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "now\nlate\n"; out.String() != want {
+		t.Errorf("printed %q, want %q", out.String(), want)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := r.Run(ctx, "sleep 10 &"); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("Run returned after %v, with its context done after 100ms", d)
+	}
+}
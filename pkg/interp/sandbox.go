@@ -0,0 +1,332 @@
+package interp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits are resource limits on the executables a command runs. Zero is
+// no limit.
+type Limits struct {
+	// CPU is the CPU time each may use, in whole seconds.
+	CPU time.Duration
+	// Memory is the bytes of address space each may use.
+	Memory uint64
+	// Files is the number of files each may have open.
+	Files uint64
+}
+
+// tighten returns the tighter of each of the limits of l and m.
+func (l Limits) tighten(m Limits) Limits {
+	tighter := func(a, b uint64) uint64 {
+		if a == 0 || (b != 0 && b < a) {
+			return b
+		}
+		return a
+	}
+	return Limits{
+		CPU:    time.Duration(tighter(uint64(l.CPU), uint64(m.CPU))),
+		Memory: tighter(l.Memory, m.Memory),
+		Files:  tighter(l.Files, m.Files),
+	}
+}
+
+// limitsKey is the key of the Limits in the context of a command run with
+// them.
+type limitsKey struct{}
+
+// WithLimits returns a context derived from ctx that has the executables
+// commands run in it start with limits, on top of those of ctx.
+func WithLimits(ctx context.Context, limits Limits) context.Context {
+	outer, _ := ctx.Value(limitsKey{}).(Limits)
+	return context.WithValue(ctx, limitsKey{}, outer.tighten(limits))
+}
+
+// IOClass is an I/O scheduling class, numbered as ionice(1) numbers them.
+type IOClass int
+
+const (
+	IOClassNone IOClass = iota
+	IOClassRealtime
+	IOClassBestEffort
+	IOClassIdle
+)
+
+// ioClassNames are the names of the I/O scheduling classes.
+var ioClassNames = map[IOClass]string{
+	IOClassRealtime:   "realtime",
+	IOClassBestEffort: "best-effort",
+	IOClassIdle:       "idle",
+}
+
+// Priority is the scheduling priority of the executables a command runs.
+type Priority struct {
+	// Nice is added to the niceness they would have.
+	Nice int
+	// IOClass is their I/O scheduling class, left as it is if none, and
+	// IOLevel their priority within the real-time and best-effort classes,
+	// from 0, the highest, to 7.
+	IOClass IOClass
+	IOLevel int
+}
+
+// add returns p with q on top: the niceness of both added up, and the I/O
+// class of q if it has one, that of p otherwise.
+func (p Priority) add(q Priority) Priority {
+	p.Nice += q.Nice
+	if q.IOClass != IOClassNone {
+		p.IOClass, p.IOLevel = q.IOClass, q.IOLevel
+	}
+	return p
+}
+
+// String returns p as nice=n and io=class[:level], with io=none for no I/O
+// class.
+func (p Priority) String() string {
+	io := "none"
+	if p.IOClass != IOClassNone {
+		io = p.ioString()
+	}
+	return fmt.Sprintf("nice=%d io=%s", p.Nice, io)
+}
+
+// ioString returns the I/O class and level of p the way ParseIOPriority
+// reads them.
+func (p Priority) ioString() string {
+	if p.IOClass == IOClassRealtime || p.IOClass == IOClassBestEffort {
+		return fmt.Sprintf("%s:%d", ioClassNames[p.IOClass], p.IOLevel)
+	}
+	return ioClassNames[p.IOClass]
+}
+
+// ParseIOPriority parses an I/O scheduling class, realtime, best-effort or
+// idle, followed for the first two by an optional colon and level, 4 if
+// left out.
+func ParseIOPriority(s string) (class IOClass, level int, err error) {
+	name, lvl, hasLevel := strings.Cut(s, ":")
+	for c, n := range ioClassNames {
+		if n == name {
+			class = c
+		}
+	}
+	level = 4
+	if hasLevel {
+		level, err = strconv.Atoi(lvl)
+	}
+	if class == IOClassNone || err != nil || level < 0 || level > 7 || (hasLevel && class == IOClassIdle) {
+		return IOClassNone, 0, fmt.Errorf("%s: invalid I/O priority", s)
+	}
+	return class, level, nil
+}
+
+// priorityKey is the key of the Priority in the context of a command run
+// with one.
+type priorityKey struct{}
+
+// WithPriority returns a context derived from ctx that has the executables
+// commands run in it start with p: with its niceness added to that of ctx,
+// and its I/O class, if any, instead of that of ctx.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	outer, _ := ctx.Value(priorityKey{}).(Priority)
+	return context.WithValue(ctx, priorityKey{}, outer.add(p))
+}
+
+// ParseSize parses a number of bytes, with an optional K, M, G or T suffix
+// for kibibytes and the larger units.
+func ParseSize(s string) (uint64, error) {
+	digits, shift := s, 0
+	if n := len(s); n > 0 {
+		if i := strings.IndexByte("KMGT", s[n-1]&^0x20); i >= 0 {
+			digits, shift = s[:n-1], 10*(i+1)
+		}
+	}
+	n, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil || n > ^uint64(0)>>shift {
+		return 0, fmt.Errorf("%s: invalid size", s)
+	}
+	return n << shift, nil
+}
+
+// containKey is the key of the context of a command run contained.
+type containKey struct{}
+
+// Contain returns a context derived from ctx that has the executables
+// commands run in it run contained: in namespaces of their own, with no
+// network, no other processes to see and a read-only root filesystem.
+func Contain(ctx context.Context) context.Context {
+	return context.WithValue(ctx, containKey{}, true)
+}
+
+// errNoSandbox is the error for running a command in a sandbox where
+// there is nothing to make it with: restrictions other than limits need
+// Landlock, seccomp and namespaces, and limits a Unix system.
+var errNoSandbox = errors.New("sandboxes aren't supported on this system")
+
+// sandbox is what a command run in a sandbox is kept from doing, by the
+// policy or the limits it runs with.
+type sandbox struct {
+	// noNetwork keeps it from opening sockets other than Unix and netlink
+	// ones.
+	noNetwork bool
+	// readOnly keeps it from changing files anywhere but beneath the
+	// directories in writable.
+	readOnly bool
+	writable []string
+	// contained runs it in new user, mount, PID and network namespaces,
+	// with a read-only root filesystem and a tmpfs on /tmp.
+	contained bool
+	limits    Limits
+	priority  Priority
+}
+
+// parseSandbox parses the restrictions of a sandbox: nonet for no
+// network, ro for a read-only filesystem, write=dir for a read-only
+// filesystem but for dir, contain for namespaces of its own, cpu=seconds,
+// mem=size and files=count for resource limits, and nice=n and
+// io=class[:level] for its priority.
+func parseSandbox(restrictions []string) (*sandbox, error) {
+	sb := &sandbox{}
+	for _, r := range restrictions {
+		key, value, _ := strings.Cut(r, "=")
+		var err error
+		switch {
+		case r == "nonet":
+			sb.noNetwork = true
+		case r == "ro":
+			sb.readOnly = true
+		case r == "contain":
+			sb.contained = true
+		case key == "write" && value != "":
+			sb.readOnly = true
+			sb.writable = append(sb.writable, value)
+		case key == "cpu":
+			var secs uint64
+			secs, err = strconv.ParseUint(value, 10, 32)
+			sb.limits.CPU = time.Duration(secs) * time.Second
+		case key == "mem":
+			sb.limits.Memory, err = ParseSize(value)
+		case key == "files":
+			sb.limits.Files, err = strconv.ParseUint(value, 10, 32)
+		case key == "nice":
+			sb.priority.Nice, err = strconv.Atoi(value)
+		case key == "io":
+			sb.priority.IOClass, sb.priority.IOLevel, err = ParseIOPriority(value)
+		default:
+			return nil, fmt.Errorf("%s: unknown sandbox restriction", r)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid value", r)
+		}
+	}
+	return sb, nil
+}
+
+// restrictions returns the restrictions of sb the way parseSandbox reads
+// them.
+func (sb *sandbox) restrictions() []string {
+	var rs []string
+	if sb.noNetwork {
+		rs = append(rs, "nonet")
+	}
+	if sb.readOnly && len(sb.writable) == 0 {
+		rs = append(rs, "ro")
+	}
+	for _, dir := range sb.writable {
+		rs = append(rs, "write="+dir)
+	}
+	if sb.contained {
+		rs = append(rs, "contain")
+	}
+	if sb.limits.CPU > 0 {
+		rs = append(rs, fmt.Sprintf("cpu=%d", cpuSeconds(sb.limits.CPU)))
+	}
+	if sb.limits.Memory > 0 {
+		rs = append(rs, fmt.Sprintf("mem=%d", sb.limits.Memory))
+	}
+	if sb.limits.Files > 0 {
+		rs = append(rs, fmt.Sprintf("files=%d", sb.limits.Files))
+	}
+	if sb.priority.Nice != 0 {
+		rs = append(rs, fmt.Sprintf("nice=%d", sb.priority.Nice))
+	}
+	if sb.priority.IOClass != IOClassNone {
+		rs = append(rs, "io="+sb.priority.ioString())
+	}
+	return rs
+}
+
+// cpuSeconds rounds a CPU time limit up to whole seconds, the unit it is
+// set in.
+func cpuSeconds(d time.Duration) uint64 {
+	return uint64((d + time.Second - 1) / time.Second)
+}
+
+// sandboxFor returns the sandbox c runs in as the executable at path: the
+// first the policy has it run in, with the limits and priority of its
+// context, what nice added to that of its job, and contained if it is, or
+// nil if it runs in none.
+func (c *Command) sandboxFor(path string) *sandbox {
+	sb := &sandbox{}
+	args, paths := append([]string{c.Name}, c.Args...), policyPaths(c.Shell().dir, path)
+	for _, r := range policy {
+		if r.sandbox != nil && r.matches(args, paths) {
+			*sb = *r.sandbox
+			break
+		}
+	}
+	if limits, ok := c.Context().Value(limitsKey{}).(Limits); ok {
+		sb.limits = sb.limits.tighten(limits)
+	}
+	if p, ok := c.Context().Value(priorityKey{}).(Priority); ok {
+		sb.priority = sb.priority.add(p)
+	}
+	sb.priority = sb.priority.add(c.Shell().jobs.adjustment(c.Shell().job))
+	if c.Context().Value(containKey{}) != nil {
+		sb.contained = true
+	}
+	if len(sb.restrictions()) == 0 {
+		return nil
+	}
+	return sb
+}
+
+// sandboxExecReady is set by MaybeSandboxExec, which the program must
+// call for the process it starts to set up a sandbox to do so.
+var sandboxExecReady bool
+
+// errNoSandboxExec is the error for running a command in a sandbox from a
+// program that doesn't call MaybeSandboxExec.
+var errNoSandboxExec = errors.New("sandboxes need the program to call interp.MaybeSandboxExec first in main")
+
+// MaybeSandboxExec runs a command in a sandbox if the process was started
+// to, with the arguments --sandbox-exec, the restrictions each on its own,
+// --, the path of the executable, and the command's name and arguments: it
+// replaces the process, or exits with status 126 if it can't. Otherwise it
+// returns.
+//
+// The executables a command runs in a sandbox, with limits, at another
+// priority or contained are started by running the program itself that
+// way, so it must call MaybeSandboxExec before anything else in main.
+func MaybeSandboxExec() {
+	sandboxExecReady = true
+	if len(os.Args) < 2 || os.Args[1] != "--sandbox-exec" {
+		return
+	}
+	args := os.Args[2:]
+	err := fmt.Errorf("sandbox: expected restrictions, --, a path and a command")
+	// No restriction is --, not even write=-- for a directory named so.
+	if i := slices.Index(args, "--"); i >= 0 && len(args) >= i+3 {
+		var sb *sandbox
+		if sb, err = parseSandbox(args[:i]); err == nil {
+			err = sb.exec(args[i+1], args[i+2:])
+		}
+	}
+	Report(os.Stderr, &Error{Msg: err.Error(), Status: 126})
+	os.Exit(126)
+}
@@ -0,0 +1,46 @@
+//go:build unix && !linux
+
+package interp
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// sysProcAttr returns no attributes, there being no namespaces to start
+// the process in.
+func (sb *sandbox) sysProcAttr() *syscall.SysProcAttr {
+	return nil
+}
+
+// setPriority adds the niceness of p to that of the process pid, or this
+// one if it is 0, and fails for an I/O scheduling class, which there is no
+// setting.
+func setPriority(pid int, p Priority) error {
+	if p.IOClass != IOClassNone {
+		return errors.New("I/O priorities aren't supported on this system")
+	}
+	if p.Nice == 0 {
+		return nil
+	}
+	nice, err := unix.Getpriority(unix.PRIO_PROCESS, pid)
+	if err != nil {
+		return err
+	}
+	if err := unix.Setpriority(unix.PRIO_PROCESS, pid, nice+p.Nice); err != nil {
+		return fmt.Errorf("setting niceness: %w", err)
+	}
+	return nil
+}
+
+// restrict fails for a sandbox with restrictions other than limits, which
+// need Landlock, seccomp and namespaces.
+func (sb *sandbox) restrict() error {
+	if sb.readOnly || sb.noNetwork || sb.contained {
+		return errNoSandbox
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package interp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// sysProcAttr returns the attributes of the process setting up sb, which
+// for a contained command start it in new namespaces as the same user.
+func (sb *sandbox) sysProcAttr() *syscall.SysProcAttr {
+	if !sb.contained {
+		return nil
+	}
+	uid, gid := os.Getuid(), os.Getgid()
+	return &syscall.SysProcAttr{
+		Cloneflags:  syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWPID | syscall.CLONE_NEWNET,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: uid, HostID: uid, Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: gid, HostID: gid, Size: 1}},
+	}
+}
+
+// setPriority adds the niceness of p to that of the process pid, or the
+// calling thread if it is 0, and sets its I/O scheduling class.
+func setPriority(pid int, p Priority) error {
+	if p.Nice != 0 {
+		// The system call gives 20 minus the niceness, to be positive.
+		prio, err := unix.Getpriority(unix.PRIO_PROCESS, pid)
+		if err != nil {
+			return err
+		}
+		if err := unix.Setpriority(unix.PRIO_PROCESS, pid, 20-prio+p.Nice); err != nil {
+			return fmt.Errorf("setting niceness: %w", err)
+		}
+	}
+	if p.IOClass != IOClassNone {
+		// The class is in the top bits of an I/O priority, above the level.
+		const ioprioWhoProcess, ioprioClassShift = 1, 13
+		prio := uintptr(p.IOClass)<<ioprioClassShift | uintptr(p.IOLevel)
+		if _, _, errno := unix.Syscall(unix.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(pid), prio); errno != 0 {
+			return fmt.Errorf("setting I/O priority: %w", errno)
+		}
+	}
+	return nil
+}
+
+// restrict puts the restrictions of sb other than its limits on the
+// calling thread.
+func (sb *sandbox) restrict() error {
+	if !sb.readOnly && !sb.noNetwork && !sb.contained {
+		return nil
+	}
+	if sb.contained {
+		if err := contain(); err != nil {
+			return err
+		}
+	}
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return err
+	}
+	if sb.readOnly {
+		if err := restrictWrites(sb.writable); err != nil {
+			return err
+		}
+	}
+	if sb.noNetwork {
+		return denyNetwork()
+	}
+	return nil
+}
+
+// contain sets up the namespaces a contained command starts in: it makes
+// every filesystem read-only, mounts a tmpfs on /tmp and a /proc showing
+// only the processes of the new PID namespace, and drops the capabilities
+// the process has in its namespaces, so that the command can't undo any of
+// it. The network namespace has nothing but a loopback interface that is
+// down.
+func contain() error {
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("making mounts private: %v", err)
+	}
+	if err := unix.MountSetattr(-1, "/", unix.AT_RECURSIVE, &unix.MountAttr{Attr_set: unix.MOUNT_ATTR_RDONLY}); err != nil {
+		return fmt.Errorf("making / read-only: %v", err)
+	}
+	if err := unix.Mount("proc", "/proc", "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, ""); err != nil {
+		return fmt.Errorf("mounting /proc: %v", err)
+	}
+	if err := unix.Mount("tmpfs", "/tmp", "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=1777"); err != nil {
+		return fmt.Errorf("mounting /tmp: %v", err)
+	}
+	return dropCapabilities()
+}
+
+// dropCapabilities empties the bounding, ambient and inheritable sets of
+// capabilities, so that the executable the process is replaced with has
+// none, even run as root.
+func dropCapabilities() error {
+	for c := 0; c <= unix.CAP_LAST_CAP; c++ {
+		if err := unix.Prctl(unix.PR_CAPBSET_DROP, uintptr(c), 0, 0, 0); err != nil && err != unix.EINVAL {
+			return fmt.Errorf("dropping capabilities: %v", err)
+		}
+	}
+	if err := unix.Prctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL, 0, 0, 0); err != nil {
+		return fmt.Errorf("dropping capabilities: %v", err)
+	}
+	hdr := unix.CapUserHeader{Version: unix.LINUX_CAPABILITY_VERSION_3}
+	var data [2]unix.CapUserData
+	if err := unix.Capget(&hdr, &data[0]); err != nil {
+		return fmt.Errorf("dropping capabilities: %v", err)
+	}
+	data[0].Inheritable, data[1].Inheritable = 0, 0
+	if err := unix.Capset(&hdr, &data[0]); err != nil {
+		return fmt.Errorf("dropping capabilities: %v", err)
+	}
+	return nil
+}
+
+// restrictWrites uses Landlock to keep the process from changing files
+// anywhere but beneath the directories in writable. Writing to devices,
+// such as /dev/null and the terminal, stays allowed.
+func restrictWrites(writable []string) error {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		return fmt.Errorf("Landlock isn't available: %v", errno)
+	}
+	// The rights to change files, as many as the kernel's version of
+	// Landlock knows of.
+	var access uint64 = unix.LANDLOCK_ACCESS_FS_WRITE_FILE | unix.LANDLOCK_ACCESS_FS_REMOVE_DIR |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_FILE | unix.LANDLOCK_ACCESS_FS_MAKE_CHAR | unix.LANDLOCK_ACCESS_FS_MAKE_DIR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_REG | unix.LANDLOCK_ACCESS_FS_MAKE_SOCK | unix.LANDLOCK_ACCESS_FS_MAKE_FIFO |
+		unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK | unix.LANDLOCK_ACCESS_FS_MAKE_SYM
+	fileAccess := uint64(unix.LANDLOCK_ACCESS_FS_WRITE_FILE)
+	if abi >= 2 {
+		access |= unix.LANDLOCK_ACCESS_FS_REFER
+	}
+	if abi >= 3 {
+		access |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+		fileAccess |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	}
+	attr := unix.LandlockRulesetAttr{Access_fs: access}
+	fd, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return errno
+	}
+	defer unix.Close(int(fd))
+	allow := func(dir string, access uint64) error {
+		dirFd, err := unix.Open(dir, unix.O_PATH|unix.O_CLOEXEC, 0)
+		if err != nil {
+			return fmt.Errorf("%s: %v", dir, err)
+		}
+		defer unix.Close(dirFd)
+		rule := unix.LandlockPathBeneathAttr{Allowed_access: access, Parent_fd: int32(dirFd)}
+		if _, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, fd, unix.LANDLOCK_RULE_PATH_BENEATH, uintptr(unsafe.Pointer(&rule)), 0, 0, 0); errno != 0 {
+			return fmt.Errorf("%s: %v", dir, errno)
+		}
+		return nil
+	}
+	if err := allow("/dev", fileAccess); err != nil {
+		return err
+	}
+	for _, dir := range writable {
+		if err := allow(dir, access); err != nil {
+			return err
+		}
+	}
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, fd, 0, 0); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// auditArch is the seccomp architecture of the system calls of the
+// executables the shell runs, for the architectures denyNetwork supports.
+var auditArch = map[string]uint32{
+	"amd64": unix.AUDIT_ARCH_X86_64,
+	"arm64": unix.AUDIT_ARCH_AARCH64,
+}
+
+// denyNetwork installs a seccomp filter making socket(2) fail with EACCES
+// for every family but Unix sockets and netlink, which only reach the
+// local kernel, for the likes of getaddrinfo(3) and ip(8). IPv4, IPv6 and
+// packet sockets are all denied, raw access to network devices included.
+// io_uring, which can open sockets without socket(2), fails with ENOSYS,
+// as if the kernel didn't have it, for programs to fall back to system
+// calls. System calls of another architecture than the shell's kill the
+// process, since they would get past the filter, as do those of the x32
+// ABI, which share the x86-64 audit architecture but are numbered from
+// x32SyscallBit.
+func denyNetwork() error {
+	arch, ok := auditArch[runtime.GOARCH]
+	if !ok {
+		return errors.New("no network filter for " + runtime.GOARCH)
+	}
+	const (
+		load = unix.BPF_LD | unix.BPF_W | unix.BPF_ABS
+		jeq  = unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K
+		jge  = unix.BPF_JMP | unix.BPF_JGE | unix.BPF_K
+		ret  = unix.BPF_RET | unix.BPF_K
+		// Offsets of the fields of struct seccomp_data.
+		nrOffset   = 0
+		archOffset = 4
+		arg0Offset = 16
+		// x32SyscallBit is set in the numbers of x32 system calls.
+		x32SyscallBit = 0x40000000
+	)
+	filter := []unix.SockFilter{
+		{Code: load, K: archOffset},
+		{Code: jeq, Jt: 1, K: arch},
+		{Code: ret, K: unix.SECCOMP_RET_KILL_PROCESS},
+		{Code: load, K: nrOffset},
+		{Code: jge, Jf: 1, K: x32SyscallBit},
+		{Code: ret, K: unix.SECCOMP_RET_KILL_PROCESS},
+		{Code: jeq, Jt: 7, K: unix.SYS_IO_URING_SETUP},
+		{Code: jeq, Jt: 6, K: unix.SYS_IO_URING_ENTER},
+		{Code: jeq, Jt: 5, K: unix.SYS_IO_URING_REGISTER},
+		{Code: jeq, Jf: 5, K: unix.SYS_SOCKET},
+		{Code: load, K: arg0Offset},
+		{Code: jeq, Jt: 3, K: unix.AF_UNIX},
+		{Code: jeq, Jt: 2, K: unix.AF_NETLINK},
+		{Code: ret, K: unix.SECCOMP_RET_ERRNO | uint32(unix.EACCES)},
+		{Code: ret, K: unix.SECCOMP_RET_ERRNO | uint32(unix.ENOSYS)},
+		{Code: ret, K: unix.SECCOMP_RET_ALLOW},
+	}
+	prog := unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+	if _, _, errno := unix.Syscall(unix.SYS_SECCOMP, unix.SECCOMP_SET_MODE_FILTER, 0, uintptr(unsafe.Pointer(&prog))); errno != 0 {
+		return errno
+	}
+	return nil
+}
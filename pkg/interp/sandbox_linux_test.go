@@ -0,0 +1,203 @@
+package interp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/lexer"
+	"golang.org/x/sys/unix"
+)
+
+// TestMain has the test binary set up the sandboxes the tests run it in,
+// as the shell does, and then run as the helper the tests ask for.
+func TestMain(m *testing.M) {
+	MaybeSandboxExec()
+	if helper := os.Getenv("MYSHELL_TEST_HELPER"); helper != "" {
+		sandboxHelper(helper, os.Args[1:])
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// sandboxHelper does what a test runs the test binary in a sandbox for:
+// with dial, connects to the TCP address in args; with write, writes each
+// file in args; and with spin, uses up CPU time until it is killed. It
+// prints the outcome of each, ok or the error number's message.
+func sandboxHelper(helper string, args []string) {
+	outcome := func(err error) string {
+		var errno unix.Errno
+		if errors.As(err, &errno) {
+			return errno.Error()
+		}
+		if err != nil {
+			return err.Error()
+		}
+		return "ok"
+	}
+	switch helper {
+	case "dial":
+		conn, err := net.Dial("tcp", args[0])
+		if err == nil {
+			conn.Close()
+		}
+		fmt.Println(outcome(err))
+	case "write":
+		for _, file := range args {
+			fmt.Println(outcome(os.WriteFile(file, nil, 0644)))
+		}
+	case "spin":
+		for {
+		}
+	}
+}
+
+// runSandboxed runs the test binary as helper with args in a Runner, with
+// the sandbox rule of the policy for it having restrictions, and in ctx.
+// It returns what the helper printed, and the error output of the Runner.
+func runSandboxed(t *testing.T, ctx context.Context, restrictions, helper string, args ...string) (out, errOut string) {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restrictions != "" {
+		loadTestPolicy(t, "sandbox "+restrictions+" "+lexer.Quote(exe))
+	}
+	var stdout, stderr bytes.Buffer
+	r, err := New(StdIO(nil, &stdout, &stderr), Dir(t.TempDir()), Env([]string{"MYSHELL_TEST_HELPER=" + helper}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	words := []string{lexer.Quote(exe)}
+	for _, arg := range args {
+		words = append(words, lexer.Quote(arg))
+	}
+	if _, err := r.Run(ctx, strings.Join(words, " ")); err != nil {
+		t.Fatal(err)
+	}
+	return stdout.String(), stderr.String()
+}
+
+// needSeccomp skips the test unless the network filter can be installed.
+func needSeccomp(t *testing.T) {
+	t.Helper()
+	if _, ok := auditArch[runtime.GOARCH]; !ok {
+		t.Skip("no network filter for " + runtime.GOARCH)
+	}
+	if _, err := unix.PrctlRetInt(unix.PR_GET_SECCOMP, 0, 0, 0, 0); err != nil {
+		t.Skipf("no seccomp: %v", err)
+	}
+}
+
+// needLandlock skips the test unless Landlock is available.
+func needLandlock(t *testing.T) {
+	t.Helper()
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION); errno != 0 {
+		t.Skipf("no Landlock: %v", errno)
+	}
+}
+
+// needNamespaces skips the test unless a process can start in the
+// namespaces of a contained command.
+func needNamespaces(t *testing.T) {
+	t.Helper()
+	cmd := exec.Command("/bin/true")
+	cmd.SysProcAttr = (&sandbox{contained: true}).sysProcAttr()
+	if err := cmd.Run(); err != nil {
+		t.Skipf("no user namespaces: %v", err)
+	}
+}
+
+// TestSandboxNoNetwork checks that nonet keeps a command from connecting
+// over TCP, even to a port that is listening.
+func TestSandboxNoNetwork(t *testing.T) {
+	needSeccomp(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no TCP: %v", err)
+	}
+	defer ln.Close()
+	if out, errOut := runSandboxed(t, context.Background(), "", "dial", ln.Addr().String()); out != "ok\n" {
+		t.Fatalf("without a sandbox, dial printed %q, %q", out, errOut)
+	}
+	out, errOut := runSandboxed(t, context.Background(), "nonet", "dial", ln.Addr().String())
+	if want := unix.EACCES.Error() + "\n"; out != want {
+		t.Errorf("dial printed %q, %q, want %q", out, errOut, want)
+	}
+}
+
+// TestSandboxWrites checks that ro keeps a command from writing files,
+// and write=dir from writing them anywhere but beneath dir.
+func TestSandboxWrites(t *testing.T) {
+	needLandlock(t)
+	dir := t.TempDir()
+	writable := filepath.Join(dir, "writable")
+	if err := os.Mkdir(writable, 0755); err != nil {
+		t.Fatal(err)
+	}
+	inside, outside := filepath.Join(writable, "f"), filepath.Join(dir, "f")
+	denied := unix.EACCES.Error()
+	tests := []struct {
+		name         string
+		restrictions string
+		want         string
+	}{
+		{"ro", "ro", denied + "\n" + denied + "\n"},
+		{"write=dir", "write=" + writable, "ok\n" + denied + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, errOut := runSandboxed(t, context.Background(), lexer.Quote(tt.restrictions), "write", inside, outside)
+			if out != tt.want {
+				t.Errorf("writing %s and %s printed %q, %q, want %q", inside, outside, out, errOut, tt.want)
+			}
+		})
+	}
+}
+
+// TestSandboxContain checks that a contained command finds the root
+// filesystem read-only, its working directory included, but for a /tmp of
+// its own. It runs sh, the test binary being hidden beneath that /tmp if
+// it was built in the usual place.
+func TestSandboxContain(t *testing.T) {
+	needNamespaces(t)
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	var stdout, stderr bytes.Buffer
+	r, err := New(StdIO(nil, &stdout, &stderr), Dir(t.TempDir()), Env([]string{"PATH=" + os.Getenv("PATH")}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := `sh -c 'true >f && echo wrote f; true >/tmp/f && echo wrote /tmp/f'`
+	if _, err := r.Run(Contain(context.Background()), src); err != nil {
+		t.Fatal(err)
+	}
+	if want := "wrote /tmp/f\n"; stdout.String() != want {
+		t.Errorf("printed %q, %q, want %q", stdout.String(), stderr.String(), want)
+	}
+	if !strings.Contains(strings.ToLower(stderr.String()), unix.EROFS.Error()) {
+		t.Errorf("reported %q, want it to say %q", stderr.String(), unix.EROFS.Error())
+	}
+}
+
+// TestSandboxCPULimit checks that a command killed for going over the CPU
+// time limit of its sandbox is reported as such.
+func TestSandboxCPULimit(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	_, errOut := runSandboxed(t, ctx, "cpu=1", "spin")
+	if want := "CPU time limit of 1s exceeded"; !strings.Contains(errOut, want) {
+		t.Errorf("reported %q, want it to contain %q", errOut, want)
+	}
+}
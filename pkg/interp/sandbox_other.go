@@ -0,0 +1,25 @@
+//go:build !unix
+
+package interp
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+func sandboxCommand(cmd *exec.Cmd, sb *sandbox) error {
+	return errNoSandbox
+}
+
+func (sb *sandbox) exec(path string, args []string) error {
+	return errNoSandbox
+}
+
+func exceededCPU(state *os.ProcessState, limits Limits) bool {
+	return false
+}
+
+func setPriority(pid int, p Priority) error {
+	return errors.New("priorities aren't supported on this system")
+}
@@ -0,0 +1,91 @@
+//go:build unix
+
+package interp
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+)
+
+// sandboxCommand has cmd run the shell to set up sb and then run the
+// executable in it, since the restrictions can only be put on a process
+// from inside it. Namespaces are the exception, made as it starts.
+func sandboxCommand(cmd *exec.Cmd, sb *sandbox) error {
+	if !sandboxExecReady {
+		return errNoSandboxExec
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	// The restrictions are arguments of their own, for a directory with a
+	// comma in it to stay whole.
+	args := append([]string{"myshell", "--sandbox-exec"}, sb.restrictions()...)
+	cmd.Args = append(append(args, "--", cmd.Path), cmd.Args...)
+	cmd.Path = self
+	cmd.SysProcAttr = sb.sysProcAttr()
+	return nil
+}
+
+// exec restricts the process to sb and replaces it with the executable at
+// path run with args. The restrictions are on the calling thread, which
+// the executable then runs on.
+func (sb *sandbox) exec(path string, args []string) error {
+	runtime.LockOSThread()
+	if err := setLimits(sb.limits); err != nil {
+		return fmt.Errorf("sandbox: %v", err)
+	}
+	if err := setPriority(0, sb.priority); err != nil {
+		return fmt.Errorf("sandbox: %v", err)
+	}
+	if err := sb.restrict(); err != nil {
+		return fmt.Errorf("sandbox: %v", err)
+	}
+	return syscall.Exec(path, args, os.Environ())
+}
+
+// setLimits sets the resource limits of the process to limits. The hard
+// limit on CPU time is a second past the soft one, so that running out
+// sends SIGXCPU, and SIGKILL only to a process that ignores it.
+func setLimits(limits Limits) error {
+	set := func(resource int, soft, hard uint64) error {
+		var r syscall.Rlimit
+		setRlim(&r.Cur, soft)
+		setRlim(&r.Max, hard)
+		return syscall.Setrlimit(resource, &r)
+	}
+	if limits.CPU > 0 {
+		secs := cpuSeconds(limits.CPU)
+		if err := set(syscall.RLIMIT_CPU, secs, secs+1); err != nil {
+			return err
+		}
+	}
+	if limits.Memory > 0 {
+		if err := set(rlimitMemory, limits.Memory, limits.Memory); err != nil {
+			return err
+		}
+	}
+	if limits.Files > 0 {
+		return set(syscall.RLIMIT_NOFILE, limits.Files, limits.Files)
+	}
+	return nil
+}
+
+// setRlim sets a field of a syscall.Rlimit to n, whichever integer type
+// it has on the system.
+func setRlim[T ~int64 | ~uint64](field *T, n uint64) {
+	*field = T(n)
+}
+
+// exceededCPU reports whether a process was killed for running out of the
+// CPU time limits gave it.
+func exceededCPU(state *os.ProcessState, limits Limits) bool {
+	ws, ok := state.Sys().(syscall.WaitStatus)
+	if limits.CPU == 0 || !ok || !ws.Signaled() {
+		return false
+	}
+	return ws.Signal() == syscall.SIGXCPU || (ws.Signal() == syscall.SIGKILL && state.UserTime()+state.SystemTime() >= limits.CPU)
+}
@@ -0,0 +1,210 @@
+package interp
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/parser"
+)
+
+// scope is a script or sourced file being run, or the session itself at
+// the bottom of the stack.
+type scope struct {
+	// name and args are $0 and the positional parameters.
+	name string
+	args []string
+	// deferred holds the commands registered with defer, run last first
+	// when the scope exits.
+	deferred []string
+	// function is set for the scope of a function call, and sourced for
+	// that of a sourced file, which return leaves. line is the number of
+	// lines of the script being run before the command running.
+	function bool
+	sourced  bool
+	line     int
+	// loops counts the loops running in the scope, which break and
+	// continue leave.
+	loops int
+	// locals holds the variables local made local to the function, as
+	// they were before, or nil for those that were unset, to be restored
+	// when it returns.
+	locals map[string]*Variable
+}
+
+func (sh *Shell) currentScope() *scope {
+	return sh.scopes[len(sh.scopes)-1]
+}
+
+func (sh *Shell) pushScope(name string, args []string) {
+	sh.scopes = append(sh.scopes, &scope{name: name, args: args})
+}
+
+// popScope leaves the innermost scope, running its deferred commands and
+// restoring the variables made local to it.
+func (sh *Shell) popScope() {
+	sc := sh.currentScope()
+	sh.runDeferred(sc)
+	for name, v := range sc.locals {
+		if v == nil {
+			delete(sh.variables, name)
+		} else {
+			sh.variables[name] = v
+		}
+		if name == "PATH" {
+			sh.Rehash()
+		}
+	}
+	sh.scopes = sh.scopes[:len(sh.scopes)-1]
+}
+
+func (sh *Shell) runDeferred(s *scope) {
+	for len(s.deferred) > 0 {
+		line := s.deferred[len(s.deferred)-1]
+		s.deferred = s.deferred[:len(s.deferred)-1]
+		sh.RunLine(line)
+	}
+}
+
+// Exit exits the shell with code once the deferred commands of every
+// scope have run, innermost scope first.
+func (sh *Shell) Exit(code int) {
+	for i := len(sh.scopes) - 1; i >= 0; i-- {
+		sh.runDeferred(sh.scopes[i])
+	}
+	sh.exit(code)
+}
+
+// ConfirmExit reports whether the shell run by c may exit. Commands it
+// runs in the background end with it, so while any run, the user typing
+// commands at it is asked first.
+func (c *Command) ConfirmExit() bool {
+	sh := c.Shell()
+	n := sh.jobs.count()
+	if !sh.interactive || n == 0 || sh.terminal == nil || !sh.terminal.IsTerminal() {
+		return true
+	}
+	question := "there is 1 command running in the background; exit anyway"
+	if n > 1 {
+		question = fmt.Sprintf("there are %d commands running in the background; exit anyway", n)
+	}
+	return c.ask(question)
+}
+
+// DeferCommand registers line to run when the innermost scope exits, before
+// the commands registered earlier.
+func (sh *Shell) DeferCommand(line string) {
+	sc := sh.currentScope()
+	sc.deferred = append(sc.deferred, line)
+}
+
+// Deferred returns the commands registered to run when the innermost scope
+// exits, in the order they were registered.
+func (sh *Shell) Deferred() []string {
+	return slices.Clone(sh.currentScope().deferred)
+}
+
+// positionalParam expands the positional and special parameters $0-$9, $#,
+// $@ and $* at the start of s, and returns the value and the number of
+// bytes of s it spans, or 0 if s doesn't start with one of them.
+func (sh *Shell) positionalParam(s string) (value string, n int) {
+	if s == "" {
+		return "", 0
+	}
+	sc := sh.currentScope()
+	switch c := s[0]; {
+	case c == '0':
+		return sc.name, 1
+	case c >= '1' && c <= '9':
+		if i := int(c - '1'); i < len(sc.args) {
+			return sc.args[i], 1
+		}
+		return "", 1
+	case c == '#':
+		return strconv.Itoa(len(sc.args)), 1
+	case c == '@' || c == '*':
+		return strings.Join(sc.args, " "), 1
+	}
+	return "", 0
+}
+
+// RunScript runs each line read from r, joining the lines of commands that
+// continue onto the next line. With the noexec option it only checks them
+// for syntax errors. It stops early, returning ctx's error, if ctx is
+// done.
+func (sh *Shell) RunScript(ctx context.Context, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	pending := ""
+	// n counts the lines read, and start those before pending.
+	n, start := 0, 0
+	for scanner.Scan() {
+		n++
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		sh.EchoInput(scanner.Text())
+		if pending != "" {
+			pending = parser.JoinLines(pending, scanner.Text())
+		} else {
+			pending = strings.TrimSpace(scanner.Text())
+			start = n - 1
+			if pending == "" || strings.HasPrefix(pending, "#") {
+				pending = ""
+				continue
+			}
+		}
+		if parser.Incomplete(pending) {
+			continue
+		}
+		sh.currentScope().line = start
+		sh.runScriptLine(ctx, pending)
+		pending = ""
+		if sh.returning {
+			return nil
+		}
+	}
+	if pending != "" {
+		sh.currentScope().line = start
+		sh.runScriptLine(ctx, pending)
+	}
+	return scanner.Err()
+}
+
+// EchoInput writes line to standard error as it is read, before anything in
+// it is expanded, with the verbose option.
+func (sh *Shell) EchoInput(line string) {
+	if sh.options["verbose"] {
+		fmt.Fprintln(sh.stderr, line)
+	}
+}
+
+// runScriptLine runs a command read from a script, or only parses it with
+// the noexec option.
+func (sh *Shell) runScriptLine(ctx context.Context, line string) {
+	if sh.options["noexec"] {
+		sh.parseLine(line)
+		return
+	}
+	sh.RunLineContext(ctx, line)
+}
+
+// SourceFile runs file in a scope of its own with args as its positional
+// parameters, stopping early if ctx is done.
+func (sh *Shell) SourceFile(ctx context.Context, file string, args []string) error {
+	f, err := os.Open(sh.Abs(file))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sh.pushScope(file, args)
+	sh.currentScope().sourced = true
+	defer sh.popScope()
+	err = sh.RunScript(ctx, f)
+	sh.returning = false
+	return err
+}
@@ -0,0 +1,169 @@
+package interp
+
+import (
+	"context"
+	"io"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/terminal"
+)
+
+// The functions below act on the shell of the session, for the code
+// driving it: the prompt, the line editor and the startup of the shell.
+// Builtins act on the shell they run in instead, Command.Shell.
+
+// LastStatus returns the exit status of the last command run.
+func LastStatus() int {
+	return session.LastStatus()
+}
+
+// RunLine parses and runs a line of input and returns its exit status.
+func RunLine(line string) int {
+	return session.RunLine(line)
+}
+
+// RunLineContext is like RunLine, but stops running the line once ctx is
+// done.
+func RunLineContext(ctx context.Context, line string) int {
+	return session.RunLineContext(ctx, line)
+}
+
+// RunScript runs each line read from r, stopping early if ctx is done.
+func RunScript(ctx context.Context, r io.Reader) error {
+	return session.RunScript(ctx, r)
+}
+
+// SourceFile runs file in a scope of its own with args as its positional
+// parameters.
+func SourceFile(ctx context.Context, file string, args []string) error {
+	return session.SourceFile(ctx, file, args)
+}
+
+// EchoInput writes line to standard error as it is read, with the verbose
+// option.
+func EchoInput(line string) {
+	session.EchoInput(line)
+}
+
+// Exit exits with code once the deferred commands of every scope have run.
+func Exit(code int) {
+	session.Exit(code)
+}
+
+// LoadEnvironment seeds the variables with the environment the shell was
+// started with.
+func LoadEnvironment() {
+	session.LoadEnvironment()
+}
+
+// GetVar returns the value of the variable name, or "" if it is unset.
+func GetVar(name string) string {
+	return session.GetVar(name)
+}
+
+// LookupVar returns the value of the variable name and whether it is set.
+func LookupVar(name string) (string, bool) {
+	return session.LookupVar(name)
+}
+
+// SetVar sets the variable name to value.
+func SetVar(name, value string) {
+	session.SetVar(name, value)
+}
+
+// ExportVar exports the variable name.
+func ExportVar(name string) {
+	session.ExportVar(name)
+}
+
+// UnsetVar unsets the variable name.
+func UnsetVar(name string) {
+	session.UnsetVar(name)
+}
+
+// Environ returns the exported variables as NAME=value pairs.
+func Environ() []string {
+	return session.Environ()
+}
+
+// VarNames returns the names of the variables matching pattern, sorted.
+func VarNames(pattern string) []string {
+	return session.VarNames(pattern)
+}
+
+// InitWorkingDir sets PWD to the logical working directory.
+func InitWorkingDir() {
+	session.InitWorkingDir()
+}
+
+// WorkingDir returns the logical working directory.
+func WorkingDir() string {
+	return session.WorkingDir()
+}
+
+// HashedLookPath returns the path of the executable name runs, consulting
+// the hash table before searching PATH.
+func HashedLookPath(name string) (string, error) {
+	return session.HashedLookPath(name)
+}
+
+// Resolve returns the ways name resolves, in the order the shell tries
+// them.
+func Resolve(name string, all bool) []Resolution {
+	return session.Resolve(name, all)
+}
+
+// Option reports whether the shell option name is on.
+func Option(name string) bool {
+	return session.Option(name)
+}
+
+// SetOption turns the shell option name on or off, and reports whether
+// there is such an option.
+func SetOption(name string, on bool) bool {
+	return session.SetOption(name, on)
+}
+
+// DetectAccessibility turns on accessible mode when the ACCESSIBLE
+// environment variable asks for it.
+func DetectAccessibility() {
+	session.DetectAccessibility()
+}
+
+// Report writes err to w and returns the exit status it causes.
+func Report(w io.Writer, err error) int {
+	return session.Report(w, err)
+}
+
+// RunHooks runs the commands and plugins hooked to event, and returns the
+// status of the last one and whether any ran.
+func RunHooks(event string, args ...string) (int, bool) {
+	return session.RunHooks(event, args...)
+}
+
+// RunJobHooks runs the job-done hooks for each command run in the
+// background that finished since it last ran.
+func RunJobHooks() {
+	session.RunJobHooks()
+}
+
+// DataDir returns the directory the shell keeps its persistent state in.
+func DataDir() string {
+	return session.DataDir()
+}
+
+// Terminal returns the terminal the shell asks questions on, or nil if
+// there is none.
+func Terminal() terminal.Terminal {
+	return session.terminal
+}
+
+// SetInteractive marks the shell as reading commands typed at the
+// terminal, for exit to ask before leaving commands running.
+func SetInteractive() {
+	session.interactive = true
+}
+
+// SetTerminal sets the terminal the shell asks questions on.
+func SetTerminal(t terminal.Terminal) {
+	session.terminal = t
+}
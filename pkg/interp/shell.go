@@ -0,0 +1,219 @@
+package interp
+
+import (
+	"context"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/parser"
+	"github.com/codecrafters-io/shell-starter-go/pkg/terminal"
+)
+
+// Shell is the state of a shell: its variables, functions, options, hooks
+// and working directory, the scripts it is running, and where its commands
+// read and write. The session the user types commands to has one, as does
+// each Runner. The commands of a pipeline and those run in the background
+// run in a subshell, a copy of the state of the shell starting them, so
+// that what they change doesn't change it.
+//
+// A Shell is used by one goroutine at a time.
+type Shell struct {
+	variables map[string]*Variable
+	functions map[string]*parser.FunctionDef
+	options   map[string]bool
+	// scopes is the stack of scopes being run, innermost last.
+	scopes []*scope
+	// lastStatus is the exit status of the last command run, $?.
+	lastStatus int
+	// breaking and continuing count the loops break and continue are
+	// leaving, and returning is set while return leaves a function or a
+	// sourced file. The commands they leave are skipped.
+	breaking, continuing int
+	returning            bool
+
+	// dir is the logical working directory, which commands start in and
+	// relative paths are taken from. Only the session's follows the
+	// process's working directory; see ChangeDir.
+	dir string
+	// DirStack holds the directories pushd saved, most recent first.
+	DirStack []string
+
+	// stdin, stdout and stderr are the standard input, output and error
+	// of the shell, which commands use unless redirected.
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	// terminal is the terminal the shell asks questions on, such as
+	// whether to correct a command name, or nil if there is none.
+	terminal terminal.Terminal
+	// ctx is the context of the shell, whose end kills the commands it
+	// starts and those it runs in the background.
+	ctx context.Context
+	// exit ends the shell with an exit status: the process for the
+	// session, and the Run or subshell in progress for the others.
+	exit func(code int)
+
+	// hash remembers where commands were found in PATH, so that running
+	// them again doesn't search PATH again.
+	hash map[string]*HashedCommand
+	// Hooks maps each event to the command lines run when it happens, in
+	// the order they were added.
+	Hooks map[string][]string
+	// HookPlugins maps each event to the executables run when it happens,
+	// after the command lines hooked to it, as the config file declares
+	// them. Each is run with the event's arguments, MYSHELL_HOOK_EVENT set
+	// to the event, and a hookPayload in JSON on its standard input.
+	HookPlugins map[string][]string
+	// runningHooks holds the events whose hooks are running, so that a
+	// hook can't trigger its own event again.
+	runningHooks map[string]bool
+	// CompletionSpecs maps each command registered with complete to how
+	// its arguments are completed.
+	CompletionSpecs map[string]*CompletionSpec
+	// Abbreviations maps each abbreviation defined with abbr to the text
+	// it expands to in the line editor.
+	Abbreviations map[string]string
+	// Themes holds the bundled themes and those defined with theme new,
+	// and CurrentTheme names the one the prompt is drawn with, or is "" to
+	// use PS1 and RPROMPT.
+	Themes       map[string]*Theme
+	CurrentTheme string
+	// recording is the session recording in progress, or nil.
+	recording *sessionRecording
+
+	// jobs is the job table of the commands run in the background, by
+	// the shell and its subshells alike, and job the job the shell runs,
+	// or nil if it runs in the foreground.
+	jobs *jobs
+	job  *job
+	// session is set for the shell of the session, which owns the
+	// working directory of the process, and interactive once it reads
+	// commands typed at the terminal.
+	session     bool
+	interactive bool
+}
+
+// newShell returns a shell with no variables, running in dir and using the
+// standard input, output and error of the process.
+func newShell(dir string) *Shell {
+	opts := map[string]bool{}
+	for _, o := range options {
+		opts[o.Name] = false
+	}
+	return &Shell{
+		variables:       map[string]*Variable{},
+		functions:       map[string]*parser.FunctionDef{},
+		options:         opts,
+		scopes:          []*scope{{name: "myshell"}},
+		dir:             dir,
+		stdin:           os.Stdin,
+		stdout:          os.Stdout,
+		stderr:          os.Stderr,
+		terminal:        terminal.Std(),
+		ctx:             context.Background(),
+		exit:            os.Exit,
+		hash:            map[string]*HashedCommand{},
+		Hooks:           map[string][]string{},
+		HookPlugins:     map[string][]string{},
+		runningHooks:    map[string]bool{},
+		CompletionSpecs: map[string]*CompletionSpec{},
+		Abbreviations:   map[string]string{},
+		Themes:          bundledThemes(),
+		jobs:            &jobs{},
+	}
+}
+
+// session is the shell of the session the user types commands to.
+var session = func() *Shell {
+	wd, _ := os.Getwd()
+	sh := newShell(wd)
+	sh.session = true
+	return sh
+}()
+
+// Session returns the shell of the session the user types commands to,
+// which the package-level functions act on.
+func Session() *Shell {
+	return session
+}
+
+// exitCode is what the exit of a shell other than the session's panics
+// with, to end the Run or subshell in progress.
+type exitCode int
+
+// subshell returns a copy of sh for a stage of a pipeline or a command run
+// in the background to run in. It starts with sh's variables, functions,
+// options, working directory and the rest, which it changes only for
+// itself, and exiting it ends only the commands run in it.
+func (sh *Shell) subshell() *Shell {
+	sub := *sh
+	sub.variables = make(map[string]*Variable, len(sh.variables))
+	for name, v := range sh.variables {
+		v := *v
+		v.Array = slices.Clone(v.Array)
+		sub.variables[name] = &v
+	}
+	sub.functions = maps.Clone(sh.functions)
+	sub.options = maps.Clone(sh.options)
+	sub.scopes = make([]*scope, len(sh.scopes))
+	for i, sc := range sh.scopes {
+		// The deferred commands are those of the scopes of sh, which run
+		// when sh leaves them.
+		sub.scopes[i] = &scope{name: sc.name, args: slices.Clone(sc.args), function: sc.function, sourced: sc.sourced, line: sc.line, loops: sc.loops}
+	}
+	sub.DirStack = slices.Clone(sh.DirStack)
+	sub.exit = func(code int) { panic(exitCode(code)) }
+	sub.hash = make(map[string]*HashedCommand, len(sh.hash))
+	for name, h := range sh.hash {
+		h := *h
+		sub.hash[name] = &h
+	}
+	sub.Hooks = cloneLists(sh.Hooks)
+	sub.HookPlugins = cloneLists(sh.HookPlugins)
+	sub.runningHooks = maps.Clone(sh.runningHooks)
+	sub.CompletionSpecs = maps.Clone(sh.CompletionSpecs)
+	sub.Abbreviations = maps.Clone(sh.Abbreviations)
+	sub.Themes = maps.Clone(sh.Themes)
+	sub.recording = nil
+	sub.session, sub.interactive = false, false
+	return &sub
+}
+
+// cloneLists returns a copy of m whose lists can be changed without
+// changing those of m.
+func cloneLists(m map[string][]string) map[string][]string {
+	m2 := make(map[string][]string, len(m))
+	for k, v := range m {
+		m2[k] = slices.Clone(v)
+	}
+	return m2
+}
+
+// run runs f in sh and returns its status, or the one given to exit if
+// that ends sh first.
+func (sh *Shell) run(f func() int) (status int) {
+	defer func() {
+		if v := recover(); v != nil {
+			code, ok := v.(exitCode)
+			if !ok {
+				panic(v)
+			}
+			status = int(code)
+			sh.lastStatus = status
+		}
+	}()
+	return f()
+}
+
+// Abs returns path as the commands of sh take it: relative to its working
+// directory, unless it is absolute already. The result isn't cleaned, for
+// .. in it to be resolved as the system does, after any symlink before it.
+func (sh *Shell) Abs(path string) string {
+	if path == "" || filepath.IsAbs(path) || sh.dir == "" {
+		return path
+	}
+	return sh.dir + string(filepath.Separator) + path
+}
@@ -0,0 +1,236 @@
+package interp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// The builtins the tests run, stand-ins for those of package builtins,
+// which imports this one.
+func init() {
+	Register(&Builtin{Name: "echo", Run: func(c *Command) int {
+		c.Stdout.Write([]byte(strings.Join(c.Args, " ") + "\n"))
+		return 0
+	}})
+	Register(&Builtin{Name: "cd", Run: func(c *Command) int {
+		if err := c.Shell().ChangeDir(c.Args[0], false); err != nil {
+			return c.Errorf(1, "%v", err)
+		}
+		return 0
+	}})
+	Register(&Builtin{Name: "exit", Run: func(c *Command) int {
+		code, _ := strconv.Atoi(c.Args[0])
+		c.Shell().Exit(code)
+		return code
+	}})
+	Register(&Builtin{Name: "return", Run: func(c *Command) int {
+		status := c.Shell().LastStatus()
+		if len(c.Args) > 0 {
+			status, _ = strconv.Atoi(c.Args[0])
+		}
+		if err := c.Shell().Return(); err != nil {
+			return c.Errorf(1, "%v", err)
+		}
+		return status
+	}})
+	for name, f := range map[string]func(sh *Shell, n int) error{"break": (*Shell).Break, "continue": (*Shell).Continue} {
+		Register(&Builtin{Name: name, Run: func(c *Command) int {
+			n := 1
+			if len(c.Args) > 0 {
+				n, _ = strconv.Atoi(c.Args[0])
+			}
+			if err := f(c.Shell(), n); err != nil {
+				return c.Errorf(1, "%v", err)
+			}
+			return 0
+		}})
+	}
+	Register(&Builtin{Name: "source", Run: func(c *Command) int {
+		if err := c.Shell().SourceFile(c.Context(), c.Args[0], c.Args[1:]); err != nil {
+			return c.Errorf(1, "%v", err)
+		}
+		return c.Shell().LastStatus()
+	}})
+	Register(&Builtin{Name: "wait", Run: func(c *Command) int {
+		if len(c.Args) == 0 {
+			return c.Shell().WaitJobs(c.Context())
+		}
+		status, err := c.Shell().WaitJob(c.Context(), c.Args[0])
+		if err != nil {
+			return c.Errorf(status, "%v", err)
+		}
+		return status
+	}})
+	Register(&Builtin{Name: "jobs", Run: func(c *Command) int {
+		for _, j := range c.Shell().Jobs() {
+			fmt.Fprintf(c.Stdout, "%d %s\n", j.ID, j.Command)
+		}
+		return 0
+	}})
+	Register(&Builtin{Name: "local", Run: func(c *Command) int {
+		for _, arg := range c.Args {
+			name, value, ok := strings.Cut(arg, "=")
+			if err := c.Shell().Local(name); err != nil {
+				return c.Errorf(1, "%v", err)
+			}
+			if ok {
+				c.Shell().SetVar(name, value)
+			}
+		}
+		return 0
+	}})
+}
+
+// newTestRunner returns a Runner writing its output to out, started in a
+// directory of its own, and the path of that directory. The directory has
+// a subdirectory sub, and a script return.sh returning 6 after its first
+// line.
+func newTestRunner(t *testing.T, out *bytes.Buffer) (*Runner, string) {
+	t.Helper()
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "return.sh"), []byte("echo a\nreturn 6\necho no\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(StdIO(nil, out, nil), Dir(dir), Env([]string{"PATH=" + os.Getenv("PATH")}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, dir
+}
+
+// TestSubshells checks that the stages of a pipeline and the commands run
+// in the background change only their own state, and that exiting ends
+// only them.
+func TestSubshells(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		// want is the output expected, with $DIR for the directory the
+		// shell starts in.
+		want string
+	}{
+		{"cd in a pipeline", "cd sub | echo a\necho $PWD", "a\n$DIR\n"},
+		{"assignment in a pipeline", "x=1 | echo a\necho [$x]", "a\n[]\n"},
+		{"exit in a pipeline", "exit 3 | echo a\necho $?", "a\n0\n"},
+		{"exit ending a pipeline", "echo a | exit 3\necho $?", "3\n"},
+		{"cd in the background", "cd sub &\necho $PWD", "$DIR\n"},
+		{"exit in the background", "exit 3 &\necho a", "a\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			r, dir := newTestRunner(t, &out)
+			if _, err := r.Run(context.Background(), tt.src); err != nil {
+				t.Fatal(err)
+			}
+			if want := strings.ReplaceAll(tt.want, "$DIR", dir); out.String() != want {
+				t.Errorf("%q printed %q, want %q", tt.src, out.String(), want)
+			}
+		})
+	}
+}
+
+// TestControlFlow checks that return, break and continue skip the rest of
+// what they leave, and that local variables are restored on return.
+func TestControlFlow(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"return", "f() { return 3; echo no; }\nf\necho $?", "3\n"},
+		{"return with the last status", "f() { g; return; echo no; }\ng() { return 4; }\nf\necho $?", "4\n"},
+		{"return from a loop", "f() { for i in 1 2 3; do echo $i; return 5; done; echo no; }\nf\necho $?", "1\n5\n"},
+		{"return from an and-or list", "f() { return 0 && echo no; echo no; }\nf\necho $?", "0\n"},
+		{"return outside a function", "return 2\necho $?", "1\n"},
+		{"return from a sourced file", "source return.sh\necho $?", "a\n6\n"},
+		{"break", "for i in 1 2 3; do echo $i; break; echo no; done\necho $?", "1\n0\n"},
+		{"break from a while loop", "while x=1; do echo a; break; done", "a\n"},
+		{"break from an if", "for i in 1 2 3; do echo $i; if x=1; then break; fi; echo no; done", "1\n"},
+		{"break 2", "for i in 1 2; do for j in a b; do echo $i$j; break 2; done; echo no; done", "1a\n"},
+		{"break past every loop", "for i in 1 2; do for j in a b; do echo $i$j; break 5; done; done\necho done", "1a\ndone\n"},
+		{"break outside a loop", "break\necho $?", "1\n"},
+		{"break in a function outside its loop", "f() { break; }\nfor i in 1 2; do f; echo $i; done", "1\n2\n"},
+		{"continue", "for i in 1 2 3; do continue; echo no; done\necho $i", "3\n"},
+		{"continue 2", "for i in 1 2; do for j in a b; do echo $i$j; continue 2; echo no; done; echo no; done", "1a\n2a\n"},
+		{"local", "x=1\nf() { local x=2 y; echo $x [$y]; x=3; }\nf\necho $x", "2 []\n1\n"},
+		{"local of an unset variable", "f() { local x=2; }\nf\necho [$x]", "[]\n"},
+		{"local seen by calls", "g() { echo $x; }\nf() { local x=2; g; }\nx=1\nf", "2\n"},
+		{"local outside a function", "local x=1\necho $? [$x]", "1 []\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			r, _ := newTestRunner(t, &out)
+			if _, err := r.Run(context.Background(), tt.src); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("%q printed %q, want %q", tt.src, out.String(), tt.want)
+			}
+		})
+	}
+}
+
+// TestBackgroundJobs checks that commands run in the background, which
+// look up and hash the executables they run, don't race with the shell
+// running more commands. Go test -race reports it if they do.
+func TestBackgroundJobs(t *testing.T) {
+	if _, err := exec.LookPath("ls"); err != nil {
+		t.Skip("no ls")
+	}
+	var out bytes.Buffer
+	r, dir := newTestRunner(t, &out)
+	src := "for i in 1 2 3 4 5 6 7 8; do\nls >/dev/null &\nx=$i\ncd sub\ncd ..\nls >/dev/null\ndone"
+	if _, err := r.Run(context.Background(), src); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.sh.GetVar("x"); got != "8" {
+		t.Errorf("x = %q, want 8", got)
+	}
+	if got := r.sh.GetVar("PWD"); got != dir {
+		t.Errorf("PWD = %q, want %q", got, dir)
+	}
+}
+
+// TestJobs checks that commands run in the background are numbered in the
+// job table, the lowest number free first, and can be waited for by
+// number, which forgets them.
+func TestJobs(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"wait", "exit 3 &\nwait %1\necho $?", "3\n"},
+		{"wait for the last job", "exit 4 &\nexit 5 &\nwait %%\necho $?", "5\n"},
+		{"wait for no job", "wait %1\necho $?", "127\n"},
+		{"wait for every job", "exit 1 &\nexit 2 &\nwait\necho $?\njobs", "0\n"},
+		{"numbers", "exit 1 &\nexit 2 &\nwait %1\nexit 3 &\njobs", "1 exit 3 &\n2 exit 2 &\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			r, _ := newTestRunner(t, &out)
+			if _, err := r.Run(context.Background(), tt.src); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("%q printed %q, want %q", tt.src, out.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package interp
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CommandStats are how many times a command was run and how long it took
+// in all.
+type CommandStats struct {
+	Name  string
+	Count int
+	Total time.Duration
+}
+
+// Average returns how long the command took on average.
+func (s CommandStats) Average() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Count)
+}
+
+// unsavedStats holds the stats of the commands run since they were last
+// added to the stats file, by the shell and its subshells alike.
+var unsavedStats = struct {
+	sync.Mutex
+	m map[string]*CommandStats
+}{m: map[string]*CommandStats{}}
+
+func (sh *Shell) statsFile() string {
+	return filepath.Join(sh.DataDir(), "stats")
+}
+
+// countCommand adds a run of the command name that took d to its stats.
+func countCommand(name string, d time.Duration) {
+	unsavedStats.Lock()
+	defer unsavedStats.Unlock()
+	s, ok := unsavedStats.m[name]
+	if !ok {
+		s = &CommandStats{Name: name}
+		unsavedStats.m[name] = s
+	}
+	s.Count++
+	s.Total += d
+}
+
+// loadStats reads the stats file, which has a command name, a count and a
+// total in nanoseconds separated by tabs on each line.
+func (sh *Shell) loadStats() map[string]*CommandStats {
+	stats := map[string]*CommandStats{}
+	f, err := os.Open(sh.statsFile())
+	if err != nil {
+		return stats
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != 3 {
+			continue
+		}
+		count, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		total, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		stats[fields[0]] = &CommandStats{Name: fields[0], Count: count, Total: time.Duration(total)}
+	}
+	return stats
+}
+
+// mergeStats returns the stats in the file with the unsaved ones added. The
+// caller holds the lock of unsavedStats.
+func (sh *Shell) mergeStats() map[string]*CommandStats {
+	stats := sh.loadStats()
+	for name, s := range unsavedStats.m {
+		if saved, ok := stats[name]; ok {
+			saved.Count += s.Count
+			saved.Total += s.Total
+		} else {
+			stats[name] = &CommandStats{Name: name, Count: s.Count, Total: s.Total}
+		}
+	}
+	return stats
+}
+
+// SaveStats adds the stats of the commands run since the last save to the
+// stats file, kept with the history in the shell's data directory. Adding
+// rather than overwriting keeps the counts of other shells running at the
+// same time.
+func SaveStats() error {
+	unsavedStats.Lock()
+	defer unsavedStats.Unlock()
+	if len(unsavedStats.m) == 0 {
+		return nil
+	}
+	stats := session.mergeStats()
+	var sb strings.Builder
+	for _, s := range stats {
+		fmt.Fprintf(&sb, "%s\t%d\t%d\n", s.Name, s.Count, int64(s.Total))
+	}
+	file := session.statsFile()
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, []byte(sb.String()), 0600); err != nil {
+		return err
+	}
+	clear(unsavedStats.m)
+	return nil
+}
+
+// Stats returns the stats of every command run, saved or not, sorted by
+// name.
+func (sh *Shell) Stats() []CommandStats {
+	unsavedStats.Lock()
+	defer unsavedStats.Unlock()
+	var list []CommandStats
+	for _, s := range sh.mergeStats() {
+		list = append(list, *s)
+	}
+	slices.SortFunc(list, func(a, b CommandStats) int { return strings.Compare(a.Name, b.Name) })
+	return list
+}
+
+// ClearStats forgets the stats of every command run.
+func (sh *Shell) ClearStats() error {
+	unsavedStats.Lock()
+	defer unsavedStats.Unlock()
+	clear(unsavedStats.m)
+	if err := os.Remove(sh.statsFile()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
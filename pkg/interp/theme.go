@@ -0,0 +1,151 @@
+package interp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PromptSegment is a part of a themed prompt: a prompt template drawn in a
+// foreground and background color, either of which may be "".
+type PromptSegment struct {
+	Template string
+	FG, BG   string
+}
+
+// Theme describes a prompt as segments joined by a separator and followed
+// by end, with optional segments for the right prompt. Segments that
+// expand to nothing, like \g outside a repository, are left out.
+type Theme struct {
+	Left, Right []PromptSegment
+	Separator   string
+	// Powerline themes join segments with the powerline arrow drawn in the
+	// colors of the segments on either side.
+	Powerline bool
+	End       string
+}
+
+// bundledThemes returns the themes that come with the shell, by name.
+func bundledThemes() map[string]*Theme {
+	return map[string]*Theme{
+		"classic": {
+			Left:      []PromptSegment{{`\u@\h`, "green", ""}, {`\w`, "blue", ""}},
+			Separator: ":",
+			End:       `\$ `,
+		},
+		"minimal": {
+			Left:      []PromptSegment{{`\W`, "cyan", ""}, {`\g`, "magenta", ""}},
+			Separator: " ",
+			End:       ` \$ `,
+		},
+		"powerline": {
+			Left:      []PromptSegment{{` \u `, "black", "blue"}, {` \w `, "black", "cyan"}, {` \g `, "black", "yellow"}},
+			Right:     []PromptSegment{{`\x`, "", ""}, {`\A`, "bright-black", ""}},
+			Powerline: true,
+			End:       " ",
+		},
+	}
+}
+
+// colorCodes maps color names to their ANSI numbers.
+var colorCodes = map[string]int{
+	"black": 0, "red": 1, "green": 2, "yellow": 3,
+	"blue": 4, "magenta": 5, "cyan": 6, "white": 7,
+}
+
+// sgr returns the escape sequence setting the foreground or background
+// color named color: one of colorCodes, bright- and one of them, or a
+// number from the 256-color palette.
+func sgr(color string, background bool) string {
+	return session.sgr(color, background)
+}
+
+// sgr is sgr for the NO_COLOR of sh.
+func (sh *Shell) sgr(color string, background bool) string {
+	if color == "" || sh.GetVar("NO_COLOR") != "" {
+		return ""
+	}
+	base := 30
+	if background {
+		base = 40
+	}
+	if n, err := strconv.Atoi(color); err == nil && n >= 0 && n < 256 {
+		return fmt.Sprintf("\x1b[%d;5;%dm", base+8, n)
+	}
+	if name, ok := strings.CutPrefix(color, "bright-"); ok {
+		if n, ok := colorCodes[name]; ok {
+			return fmt.Sprintf("\x1b[%dm", base+60+n)
+		}
+	}
+	if n, ok := colorCodes[color]; ok {
+		return fmt.Sprintf("\x1b[%dm", base+n)
+	}
+	return ""
+}
+
+// Color returns the escape sequence setting the foreground color named
+// color, or "" if it sets none, as when NO_COLOR is set.
+func Color(color string) string {
+	return sgr(color, false)
+}
+
+// ValidColor reports whether sgr understands color.
+func ValidColor(color string) bool {
+	if n, err := strconv.Atoi(color); err == nil {
+		return n >= 0 && n < 256
+	}
+	_, ok := colorCodes[strings.TrimPrefix(color, "bright-")]
+	return ok
+}
+
+const resetColor = "\x1b[0m"
+
+// render draws segs in their colors, expanding their templates.
+func (t *Theme) render(segs []PromptSegment) string {
+	var sb strings.Builder
+	colored := GetVar("NO_COLOR") == ""
+	prevBG := ""
+	first := true
+	for _, seg := range segs {
+		text := expandPrompt(seg.Template)
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		switch {
+		case first:
+		case t.Powerline && colored:
+			sb.WriteString(sgr(prevBG, false) + sgr(seg.BG, true) + glyphPowerline.String() + resetColor)
+		case t.Powerline:
+			sb.WriteString(glyphPowerline.String())
+		default:
+			sb.WriteString(t.Separator)
+		}
+		first = false
+		if colored && (seg.FG != "" || seg.BG != "") {
+			text = sgr(seg.FG, false) + sgr(seg.BG, true) + text + resetColor
+		}
+		sb.WriteString(text)
+		prevBG = seg.BG
+	}
+	if t.Powerline && !first && prevBG != "" {
+		if colored {
+			sb.WriteString(sgr(prevBG, false) + glyphPowerline.String() + resetColor)
+		} else {
+			sb.WriteString(glyphPowerline.String())
+		}
+	}
+	return sb.String()
+}
+
+// themedPrompt returns the left or right prompt drawn by the current
+// theme, and whether a theme is in use.
+func themedPrompt(right bool) (string, bool) {
+	t, ok := session.Themes[session.CurrentTheme]
+	if !ok {
+		return "", false
+	}
+	if right {
+		return t.render(t.Right), true
+	}
+	return t.render(t.Left) + expandPrompt(t.End), true
+}
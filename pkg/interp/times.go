@@ -0,0 +1,34 @@
+package interp
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// childTimes accumulates the user and system CPU time of every child
+// process the shell has waited for.
+var childTimes struct {
+	sync.Mutex
+	user, sys time.Duration
+}
+
+// addChildTimes adds the CPU time of a finished child process to the
+// totals ChildTimes reports.
+func addChildTimes(state *os.ProcessState) {
+	if state == nil {
+		return
+	}
+	childTimes.Lock()
+	defer childTimes.Unlock()
+	childTimes.user += state.UserTime()
+	childTimes.sys += state.SystemTime()
+}
+
+// ChildTimes returns the user and system CPU time used by the child
+// processes the shell has waited for.
+func ChildTimes() (user, sys time.Duration) {
+	childTimes.Lock()
+	defer childTimes.Unlock()
+	return childTimes.user, childTimes.sys
+}
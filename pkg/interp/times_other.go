@@ -0,0 +1,11 @@
+//go:build !unix
+
+package interp
+
+import "time"
+
+// ShellTimes reports no CPU time for the shell where rusage isn't
+// available.
+func ShellTimes() (user, sys time.Duration) {
+	return 0, 0
+}
@@ -0,0 +1,241 @@
+package interp
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/lexer"
+	"github.com/codecrafters-io/shell-starter-go/pkg/parser"
+)
+
+// Variable is a shell variable. Exported variables make up the environment
+// of the commands the shell runs.
+type Variable struct {
+	Value string
+	// Array holds the elements of an indexed array, whose first element
+	// is also its value.
+	Array    []string
+	Exported bool
+}
+
+// LoadEnvironment seeds the variables with the environment the shell was
+// started with, exported.
+func (sh *Shell) LoadEnvironment() {
+	loadEnv(sh.variables, os.Environ())
+}
+
+// loadEnv adds the NAME=value pairs of env to vars, exported.
+func loadEnv(vars map[string]*Variable, env []string) {
+	for _, kv := range env {
+		if name, value, ok := strings.Cut(kv, "="); ok && lexer.ValidName(name) {
+			vars[name] = &Variable{Value: value, Exported: true}
+		}
+	}
+}
+
+// GetVar returns the value of the variable name, or "" if it is unset.
+func (sh *Shell) GetVar(name string) string {
+	value, _ := sh.LookupVar(name)
+	return value
+}
+
+// LookupVar returns the value of the variable name and whether it is set.
+func (sh *Shell) LookupVar(name string) (string, bool) {
+	if v, ok := sh.variables[name]; ok {
+		return v.Value, true
+	}
+	return "", false
+}
+
+// Var returns a copy of the variable name and whether it is set.
+func (sh *Shell) Var(name string) (Variable, bool) {
+	if v, ok := sh.variables[name]; ok {
+		return *v, true
+	}
+	return Variable{}, false
+}
+
+// Local makes the variable name local to the function running: it is
+// unset until assigned, and restored once the function returns. It fails
+// outside a function.
+func (sh *Shell) Local(name string) error {
+	sc := sh.currentScope()
+	if !sc.function {
+		return errors.New("can only be used in a function")
+	}
+	if _, ok := sc.locals[name]; ok {
+		return nil
+	}
+	if sc.locals == nil {
+		sc.locals = map[string]*Variable{}
+	}
+	sc.locals[name] = sh.variables[name]
+	delete(sh.variables, name)
+	if name == "PATH" {
+		sh.Rehash()
+	}
+	return nil
+}
+
+// SetVar sets the variable name to value.
+func (sh *Shell) SetVar(name, value string) {
+	v, ok := sh.variables[name]
+	if !ok {
+		v = &Variable{}
+		sh.variables[name] = v
+	}
+	v.Value = value
+	if name == "PATH" {
+		sh.Rehash()
+	}
+}
+
+// SetArray sets name to an indexed array of values.
+func (sh *Shell) SetArray(name string, values []string) {
+	sh.SetVar(name, "")
+	if len(values) > 0 {
+		sh.SetVar(name, values[0])
+	}
+	sh.variables[name].Array = values
+}
+
+// arrayElement returns element sub of the variable name: an index, or @ or
+// * for every element. A scalar is an array of its one value.
+func (sh *Shell) arrayElement(name, sub string) string {
+	v, ok := sh.variables[name]
+	if !ok {
+		return ""
+	}
+	elems := v.Array
+	if elems == nil {
+		elems = []string{v.Value}
+	}
+	if sub == "@" || sub == "*" {
+		return strings.Join(elems, " ")
+	}
+	i, err := strconv.Atoi(sub)
+	if err != nil || i < 0 || i >= len(elems) {
+		return ""
+	}
+	return elems[i]
+}
+
+// FormatVar returns the value of the variable name quoted for an
+// assignment, in parentheses for arrays.
+func (sh *Shell) FormatVar(name string) string {
+	v := sh.variables[name]
+	if v.Array == nil {
+		return lexer.Quote(v.Value)
+	}
+	elems := make([]string, len(v.Array))
+	for i, e := range v.Array {
+		elems[i] = lexer.Quote(e)
+	}
+	return "(" + strings.Join(elems, " ") + ")"
+}
+
+// ExportVar exports the variable name, setting it to "" if it is unset.
+func (sh *Shell) ExportVar(name string) {
+	v, ok := sh.variables[name]
+	if !ok {
+		v = &Variable{}
+		sh.variables[name] = v
+	}
+	v.Exported = true
+}
+
+// Environ returns the exported variables as NAME=value pairs, sorted by
+// name, for the environment of the commands the shell runs.
+func (sh *Shell) Environ() []string {
+	var env []string
+	for _, name := range sh.VarNames("") {
+		if v := sh.variables[name]; v.Exported {
+			env = append(env, name+"="+v.Value)
+		}
+	}
+	return env
+}
+
+// UnsetVar unsets the variable name.
+func (sh *Shell) UnsetVar(name string) {
+	delete(sh.variables, name)
+	if name == "PATH" {
+		sh.Rehash()
+	}
+}
+
+// expandParam expands the parameter reference at the start of s, which
+// follows a '$', and returns its value and the number of bytes of s it
+// spans. It returns 0 if s doesn't start with a parameter reference.
+func (sh *Shell) expandParam(s string) (value string, n int) {
+	name, n := lexer.ParamRef(s)
+	if n == 0 {
+		return "", 0
+	}
+	return sh.paramValue(name), n
+}
+
+// paramValue returns the value of the parameter name, named the way
+// lexer.ParamRef names it.
+func (sh *Shell) paramValue(name string) string {
+	if value, n := sh.positionalParam(name); n > 0 {
+		return value
+	}
+	switch name {
+	case "$":
+		return strconv.Itoa(os.Getpid())
+	case "?":
+		return strconv.Itoa(sh.lastStatus)
+	}
+	if i := strings.IndexByte(name, '['); i > 0 {
+		return sh.arrayElement(name[:i], name[i+1:len(name)-1])
+	}
+	return sh.GetVar(name)
+}
+
+// WithVars runs fn with the given NAME=value assignments in effect and
+// exported, restoring the variables they replace afterwards.
+func (sh *Shell) WithVars(assignments []string, fn func()) {
+	saved := map[string]*Variable{}
+	for _, a := range assignments {
+		name, value, _ := parser.ParseAssignment(a)
+		if _, ok := saved[name]; !ok {
+			if v, ok := sh.variables[name]; ok {
+				saved[name] = &Variable{Value: v.Value, Exported: v.Exported}
+			} else {
+				saved[name] = nil
+			}
+		}
+		sh.SetVar(name, value)
+		sh.ExportVar(name)
+	}
+	fn()
+	for name, v := range saved {
+		sh.UnsetVar(name)
+		if v != nil {
+			sh.SetVar(name, v.Value)
+			if v.Exported {
+				sh.ExportVar(name)
+			}
+		}
+	}
+}
+
+// VarNames returns the names of the variables matching pattern,
+// sorted. An empty pattern matches every variable.
+func (sh *Shell) VarNames(pattern string) (names []string) {
+	for name := range sh.variables {
+		if pattern != "" {
+			if ok, _ := filepath.Match(pattern, name); !ok {
+				continue
+			}
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return
+}
@@ -0,0 +1,132 @@
+package interp
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// InitWorkingDir sets PWD to the logical working directory. An inherited
+// PWD is kept if it still names the working directory, so a path reached
+// through a symlink survives into the shell.
+func (sh *Shell) InitWorkingDir() {
+	wd, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	if pwd := sh.GetVar("PWD"); filepath.IsAbs(pwd) && sameDir(pwd, wd) {
+		wd = pwd
+	}
+	sh.dir = wd
+	sh.SetVar("PWD", wd)
+	sh.ExportVar("PWD")
+}
+
+// sameDir reports whether a and b name the same directory.
+func sameDir(a, b string) bool {
+	ai, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bi, err := os.Stat(b)
+	return err == nil && os.SameFile(ai, bi)
+}
+
+// WorkingDir returns the logical working directory, which keeps the
+// symlinks it was reached through, unlike os.Getwd.
+func (sh *Shell) WorkingDir() string {
+	return sh.dir
+}
+
+// PhysicalDir returns the working directory with every symlink resolved.
+func (sh *Shell) PhysicalDir() (string, error) {
+	return filepath.EvalSymlinks(sh.dir)
+}
+
+// ChdirFuncs are called with a shell and its new working directory
+// whenever it changes, before the chpwd hooks run.
+var ChdirFuncs []func(sh *Shell, dir string)
+
+// ChangeDir changes the working directory to dir and updates PWD and
+// OLDPWD. Logically, .. in dir removes the previous path component, and PWD
+// keeps the symlinks dir was reached through. Physically, symlinks are
+// resolved first and PWD is the real path.
+//
+// The session's shell changes the working directory of the process too,
+// which the completion and prompts go by; other shells only keep theirs
+// for the commands they run.
+func (sh *Shell) ChangeDir(dir string, physical bool) error {
+	old := sh.dir
+	target := dir
+	if !physical {
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(old, target)
+		}
+		target = filepath.Clean(target)
+		if sh.enter(target) != nil {
+			// The logical path may not exist, as when .. leaves a symlink
+			// to a directory with no parent of the same name.
+			physical = true
+		}
+	}
+	if physical {
+		if err := sh.enter(sh.Abs(dir)); err != nil {
+			return err
+		}
+		resolved, err := filepath.EvalSymlinks(sh.Abs(dir))
+		if err != nil {
+			return err
+		}
+		target = resolved
+	}
+	sh.dir = target
+	sh.SetVar("OLDPWD", old)
+	sh.ExportVar("OLDPWD")
+	sh.SetVar("PWD", target)
+	sh.ExportVar("PWD")
+	for _, f := range ChdirFuncs {
+		f(sh, target)
+	}
+	sh.RunHooks("chpwd")
+	return nil
+}
+
+// enter checks that dir is a directory that can be changed to, changing
+// the process's working directory to it for the session.
+func (sh *Shell) enter(dir string) error {
+	if sh.session {
+		return os.Chdir(dir)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		var pe *os.PathError
+		if errors.As(err, &pe) {
+			err = pe.Err
+		}
+		return &os.PathError{Op: "chdir", Path: dir, Err: err}
+	}
+	if !info.IsDir() {
+		return &os.PathError{Op: "chdir", Path: dir, Err: syscall.ENOTDIR}
+	}
+	return nil
+}
+
+// WithDir runs fn with dir as the working directory, then changes back,
+// without changing PWD or running the chpwd hooks.
+func (sh *Shell) WithDir(dir string, fn func()) error {
+	dir = sh.Abs(dir)
+	if err := sh.enter(dir); err != nil {
+		return err
+	}
+	old := sh.dir
+	sh.dir = dir
+	defer func() {
+		sh.dir = old
+		if sh.session {
+			os.Chdir(old)
+		}
+	}()
+	fn()
+	return nil
+}
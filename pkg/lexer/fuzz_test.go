@@ -0,0 +1,136 @@
+package lexer
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+var seeds = []string{
+	"",
+	"echo hello world",
+	`echo 'a b' "c $HOME d" e\ f`,
+	"ls /nonexistent 2>/dev/null >> out.txt < in.txt",
+	"a && b || c; d & e | f",
+	"if true; then echo yes; fi",
+	"for x in a b; do\n\techo $x\ndone",
+	"[[ $a < $b && ( -f x ) ]]; echo ${BASH_REMATCH[1]}",
+	"echo $(echo \"a)\" ) ${x} $? $1 $#",
+	"echo a # comment\necho b",
+	"echo 'unterminated",
+	`echo "a\"b`,
+	"echo $(",
+	"f() { echo hi; }",
+	"x=1 y='2 3' cmd",
+	"echo \\\n continued",
+}
+
+// FuzzLex checks that the tokens of any text are the pieces of it they say
+// they are, in order, ending in EOF.
+func FuzzLex(f *testing.F) {
+	for _, s := range seeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		tokens, err := Lex(src)
+		if err != nil {
+			if _, ok := err.(*Error); !ok {
+				t.Fatalf("Lex(%q) error %T, want *Error", src, err)
+			}
+			return
+		}
+		if len(tokens) == 0 || tokens[len(tokens)-1].Kind != EOF {
+			t.Fatalf("Lex(%q) = %v, want a list ending in EOF", src, tokens)
+		}
+		end := 0
+		for _, tok := range tokens {
+			off := tok.Pos.Offset
+			if off < end || off+len(tok.Text) > len(src) || src[off:off+len(tok.Text)] != tok.Text {
+				t.Fatalf("Lex(%q): token %v %q at %d doesn't match the text after %d", src, tok.Kind, tok.Text, off, end)
+			}
+			if tok.Kind != EOF && tok.Text == "" {
+				t.Fatalf("Lex(%q): empty %v token at %d", src, tok.Kind, off)
+			}
+			line := strings.Count(src[:off], "\n") + 1
+			col := utf8.RuneCountInString(src[strings.LastIndexByte(src[:off], '\n')+1:off]) + 1
+			if tok.Pos.Line != line || tok.Pos.Col != col {
+				t.Fatalf("Lex(%q): token at %d is at %v, want %d:%d", src, off, tok.Pos, line, col)
+			}
+			end = off + len(tok.Text)
+		}
+	})
+}
+
+// FuzzQuote checks that a quoted string lexes as a single word that splits
+// back into the string.
+func FuzzQuote(f *testing.F) {
+	for _, s := range append(seeds, "#", "it's", "a\nb", "{", "\x00") {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		if !utf8.ValidString(s) {
+			return
+		}
+		q := Quote(s)
+		tokens, err := Lex(q)
+		if err != nil || len(tokens) != 2 || tokens[0].Kind != Word || tokens[0].Text != q {
+			t.Fatalf("Lex(Quote(%q)) = %v, %v, want the one word %q", s, tokens, err, q)
+		}
+		if words := Split(q, nil, false); !slices.Equal(words, []string{s}) {
+			t.Fatalf("Split(Quote(%q)) = %q", s, words)
+		}
+	})
+}
+
+// FuzzSplit checks that splitting doesn't panic, that splitting fields
+// with an empty IFS splits the same, and that text without quotes, escapes
+// or parameters splits the way strings.Fields does.
+func FuzzSplit(f *testing.F) {
+	for _, s := range seeds {
+		f.Add(s, false)
+		f.Add(s, true)
+	}
+	vars := map[string]string{"HOME": "/home/me", "1": "first", "?": "0", "x": "a b"}
+	expand := func(s string) (string, int) {
+		name, n := ParamRef(s)
+		return vars[name], n
+	}
+	f.Fuzz(func(t *testing.T, s string, escapeQuoted bool) {
+		words := Split(s, expand, false)
+		if fields := SplitFields(s, expand, ""); !slices.Equal(fields, words) {
+			t.Fatalf("SplitFields(%q) with no IFS = %q, but Split gives %q", s, fields, words)
+		}
+		SplitFields(s, expand, " :")
+		Split(s, expand, escapeQuoted)
+		if !utf8.ValidString(s) || strings.ContainsAny(s, `'"\$`) {
+			return
+		}
+		if got, want := Split(s, expand, escapeQuoted), strings.Fields(s); !slices.Equal(got, want) {
+			t.Fatalf("Split(%q) = %q, want %q", s, got, want)
+		}
+	})
+}
+
+// FuzzParamRef checks that a parameter reference spans part of the text it
+// is read from, and reads the same from just that part.
+func FuzzParamRef(f *testing.F) {
+	for _, s := range []string{"", "HOME/x", "{HOME}x", "{a[1]}", "?", "12", "{1}", "{", "-", "_a9 b"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		name, n := ParamRef(s)
+		if n == 0 {
+			if name != "" {
+				t.Fatalf("ParamRef(%q) = %q, 0", s, name)
+			}
+			return
+		}
+		if n > len(s) || name == "" {
+			t.Fatalf("ParamRef(%q) = %q, %d", s, name, n)
+		}
+		if name2, n2 := ParamRef(s[:n]); name2 != name || n2 != n {
+			t.Fatalf("ParamRef(%q) = %q, %d, but ParamRef(%q) = %q, %d", s, name, n, s[:n], name2, n2)
+		}
+	})
+}
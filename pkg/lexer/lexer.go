@@ -0,0 +1,156 @@
+// Package lexer reads the text of a command line into the words and
+// operators it is made of, splits words the way the shell expands them, and
+// quotes words so that they read back the same.
+package lexer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Expander expands the parameter reference at the start of s, which
+// follows a '$', and returns its value and the number of bytes of s it
+// spans, or 0 if s doesn't start with a parameter reference.
+type Expander func(s string) (value string, n int)
+
+// Split splits s into words, removing quotes and expanding parameters with
+// expand, if it is set. With escapeQuoted set, every quoted character other
+// than a letter or digit keeps a backslash in front of it, so that pattern
+// matching can tell it apart from an unquoted wildcard.
+func Split(s string, expand Expander, escapeQuoted bool) []string {
+	return split(s, expand, escapeQuoted, "")
+}
+
+// SplitFields is like Split, but also splits the values of parameters
+// outside quotes into fields at the characters in ifs, the way POSIX shells
+// do: runs of whitespace in ifs separate fields, and each other character
+// ends one, even if empty.
+func SplitFields(s string, expand Expander, ifs string) []string {
+	return split(s, expand, false, ifs)
+}
+
+func split(s string, expand Expander, escapeQuoted bool, ifs string) (args []string) {
+	var sb strings.Builder
+	inSingleQuotes := false
+	inDoubleQuotes := false
+	escaped := false
+	// hasQuotes keeps a word made only of quotes, like "", as an empty word.
+	hasQuotes := false
+	skipTo := 0
+	writeQuoted := func(c rune) {
+		if escapeQuoted && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(c)
+	}
+	for i, c := range s {
+		if i < skipTo {
+			continue
+		}
+		switch {
+		case escaped:
+			writeQuoted(c)
+			escaped = false
+		case c == '\'':
+			if inDoubleQuotes {
+				writeQuoted(c)
+				continue
+			}
+			inSingleQuotes = !inSingleQuotes
+			hasQuotes = true
+		case c == '"':
+			if inSingleQuotes {
+				writeQuoted(c)
+				continue
+			}
+			inDoubleQuotes = !inDoubleQuotes
+			hasQuotes = true
+		case c == '$' && !inSingleQuotes:
+			value, n := "", 0
+			if expand != nil {
+				value, n = expand(s[i+1:])
+			}
+			if n == 0 {
+				value = "$"
+			}
+			for _, v := range value {
+				switch {
+				case inDoubleQuotes:
+					writeQuoted(v)
+				case !strings.ContainsRune(ifs, v):
+					sb.WriteRune(v)
+				case sb.Len() > 0 || hasQuotes || !unicode.IsSpace(v):
+					args = append(args, sb.String())
+					sb.Reset()
+					hasQuotes = false
+				}
+			}
+			skipTo = i + 1 + n
+		case c == '\\':
+			switch {
+			case inSingleQuotes:
+				writeQuoted(c)
+			case inDoubleQuotes:
+				if i+1 >= len(s) {
+					writeQuoted(c)
+					continue
+				}
+				nextC := s[i+1]
+				if nextC == '\\' || nextC == '$' || nextC == '"' {
+					escaped = true
+					continue
+				}
+				writeQuoted(c)
+			default:
+				escaped = true
+			}
+		case unicode.IsSpace(c):
+			if inSingleQuotes || inDoubleQuotes {
+				writeQuoted(c)
+				continue
+			}
+			if sb.Len() > 0 || hasQuotes {
+				args = append(args, sb.String())
+				sb.Reset()
+				hasQuotes = false
+			}
+		case inSingleQuotes || inDoubleQuotes:
+			writeQuoted(c)
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	if sb.Len() > 0 || hasQuotes {
+		args = append(args, sb.String())
+	}
+	return
+}
+
+// Quote returns s quoted so that it lexes as one word, which Split reads
+// back as s.
+func Quote(s string) string {
+	if s != "" && !strings.ContainsFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(`'"\$;&|<>()#`+"`", r)
+	}) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// IsNameChar reports whether c may appear in the name of a variable.
+func IsNameChar(c rune) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// ValidName reports whether name can name a shell variable.
+func ValidName(name string) bool {
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for _, c := range name {
+		if !IsNameChar(c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,37 @@
+package lexer
+
+import "strings"
+
+// ParamRef returns the name of the parameter referenced at the start of s,
+// which follows a '$', and the number of bytes of s the reference spans, or
+// 0 if s doesn't start with one. The name is that of a variable, a digit for
+// a positional parameter, one of $ ? # @ * for a special parameter, or
+// name[sub] for the element of an array written ${name[sub]}.
+func ParamRef(s string) (name string, n int) {
+	switch {
+	case s == "":
+		return "", 0
+	case s[0] >= '0' && s[0] <= '9', strings.IndexByte("$?#@*", s[0]) >= 0:
+		return s[:1], 1
+	case s[0] == '{':
+		end := strings.IndexByte(s, '}')
+		if end < 0 {
+			return "", 0
+		}
+		name := s[1:end]
+		if i := strings.IndexByte(name, '['); i > 0 && strings.HasSuffix(name, "]") && ValidName(name[:i]) {
+			return name, end + 1
+		}
+		if !ValidName(name) {
+			return "", 0
+		}
+		return name, end + 1
+	}
+	for n < len(s) && IsNameChar(rune(s[n])) {
+		n++
+	}
+	if n == 0 || !ValidName(s[:n]) {
+		return "", 0
+	}
+	return s[:n], n
+}
@@ -0,0 +1,90 @@
+package lexer
+
+// MatchPattern reports whether the glob pattern matches all of s. Unlike
+// filename patterns, * and ? also match /. A backslash makes the character
+// after it match literally.
+func MatchPattern(pattern, s string) bool {
+	p, str := []rune(pattern), []rune(s)
+	// star and next record the last * seen and where its match would
+	// resume, to backtrack to when the rest of the pattern fails.
+	star, next := -1, 0
+	pi, si := 0, 0
+	for si < len(str) {
+		if pi < len(p) {
+			switch p[pi] {
+			case '*':
+				star, next = pi, si
+				pi++
+				continue
+			case '?':
+				pi++
+				si++
+				continue
+			case '[':
+				if n, ok := matchClass(p[pi:], str[si]); n > 0 {
+					if ok {
+						pi += n
+						si++
+						continue
+					}
+					break
+				}
+				fallthrough
+			default:
+				lit := p[pi]
+				n := 1
+				if lit == '\\' && pi+1 < len(p) {
+					lit, n = p[pi+1], 2
+				}
+				if lit == str[si] {
+					pi += n
+					si++
+					continue
+				}
+			}
+		}
+		if star < 0 {
+			return false
+		}
+		next++
+		pi, si = star+1, next
+	}
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
+
+// matchClass matches c against the bracket expression at the start of p,
+// like [a-z] or [!0-9], returning its length, or 0 if it isn't closed.
+func matchClass(p []rune, c rune) (n int, ok bool) {
+	i := 1
+	negate := i < len(p) && (p[i] == '!' || p[i] == '^')
+	if negate {
+		i++
+	}
+	for first := true; i < len(p); first = false {
+		if p[i] == ']' && !first {
+			return i + 1, ok != negate
+		}
+		lo := p[i]
+		if lo == '\\' && i+1 < len(p) {
+			i++
+			lo = p[i]
+		}
+		hi := lo
+		if i+2 < len(p) && p[i+1] == '-' && p[i+2] != ']' {
+			hi = p[i+2]
+			i += 2
+			if hi == '\\' && i+1 < len(p) {
+				i++
+				hi = p[i]
+			}
+		}
+		if lo <= c && c <= hi {
+			ok = true
+		}
+		i++
+	}
+	return 0, false
+}
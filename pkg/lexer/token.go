@@ -0,0 +1,366 @@
+package lexer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind is the kind of a token.
+type Kind int
+
+// The kinds of token. Operators are only recognized outside quotes, and
+// between [[ and ]] only ; is, with whitespace separating the rest of the
+// words there.
+const (
+	EOF      Kind = iota
+	Word          // a word, as written
+	Newline       // \n
+	Semi          // ;
+	DSemi         // ;;
+	Amp           // &
+	Pipe          // |
+	AndIf         // &&
+	OrIf          // ||
+	LParen        // (
+	RParen        // )
+	Redirect      // <, > or >>, after the file descriptor redirected, if given
+)
+
+var kindNames = [...]string{
+	EOF:      "EOF",
+	Word:     "WORD",
+	Newline:  "NEWLINE",
+	Semi:     "SEMI",
+	DSemi:    "DSEMI",
+	Amp:      "AMP",
+	Pipe:     "PIPE",
+	AndIf:    "AND_IF",
+	OrIf:     "OR_IF",
+	LParen:   "LPAREN",
+	RParen:   "RPAREN",
+	Redirect: "REDIRECT",
+}
+
+func (k Kind) String() string {
+	if k >= 0 && int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// operators holds the operators other than redirections with a file
+// descriptor, longest first so that && isn't read as two &s.
+var operators = []struct {
+	text string
+	kind Kind
+}{
+	{";;", DSemi},
+	{"&&", AndIf},
+	{"||", OrIf},
+	{">>", Redirect},
+	{";", Semi},
+	{"&", Amp},
+	{"|", Pipe},
+	{"(", LParen},
+	{")", RParen},
+	{"<", Redirect},
+	{">", Redirect},
+}
+
+// commandWords holds the reserved words after which a command starts.
+var commandWords = map[string]bool{
+	"if": true, "then": true, "elif": true, "else": true, "while": true,
+	"until": true, "do": true, "!": true, "{": true, "time": true,
+}
+
+// Pos is a position in source text: the byte offset from its start, and
+// the line and column, counting from 1. Columns count characters, not
+// bytes.
+type Pos struct {
+	Offset, Line, Col int
+}
+
+func (p Pos) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Col)
+}
+
+// Token is a word or operator read from source text.
+type Token struct {
+	Kind Kind
+	// Text is the token as written. A word keeps its quotes and the
+	// parameters in it unexpanded, for Split to take care of.
+	Text string
+	Pos  Pos
+}
+
+// Error is an error reading the tokens of source text.
+type Error struct {
+	Pos Pos
+	Msg string
+	// Incomplete is set if the text ended within quotes or a substitution,
+	// so that more input could complete it.
+	Incomplete bool
+}
+
+func (e *Error) Error() string {
+	return e.Msg
+}
+
+// Lexer reads the tokens of source text one at a time. Blanks, and
+// comments from a # at the start of a word to the end of the line, are
+// skipped.
+type Lexer struct {
+	src string
+	off int
+	// lineStarts holds the offset of the start of each line of src.
+	lineStarts []int
+	// commandStart is set where a word would start a command, and inTest
+	// between the [[ starting a command and the ]] ending it.
+	commandStart, inTest bool
+}
+
+// New returns a Lexer reading the tokens of src.
+func New(src string) *Lexer {
+	l := &Lexer{src: src, lineStarts: []int{0}, commandStart: true}
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			l.lineStarts = append(l.lineStarts, i+1)
+		}
+	}
+	return l
+}
+
+// Lex returns all the tokens of src, ending with an EOF token.
+func Lex(src string) ([]Token, error) {
+	l := New(src)
+	var tokens []Token
+	for {
+		tok, err := l.Next()
+		if err != nil {
+			return tokens, err
+		}
+		tokens = append(tokens, tok)
+		if tok.Kind == EOF {
+			return tokens, nil
+		}
+	}
+}
+
+// Pos returns the position of the byte at offset in the source text.
+func (l *Lexer) Pos(offset int) Pos {
+	line := sort.Search(len(l.lineStarts), func(i int) bool { return l.lineStarts[i] > offset })
+	start := l.lineStarts[line-1]
+	return Pos{Offset: offset, Line: line, Col: utf8.RuneCountInString(l.src[start:offset]) + 1}
+}
+
+// Next returns the next token, or an EOF token at the end of the text.
+func (l *Lexer) Next() (Token, error) {
+	l.skipBlanks()
+	start := l.off
+	if start >= len(l.src) {
+		return Token{Kind: EOF, Pos: l.Pos(start)}, nil
+	}
+	token := func(kind Kind) Token {
+		return Token{Kind: kind, Text: l.src[start:l.off], Pos: l.Pos(start)}
+	}
+	if l.src[start] == '\n' {
+		l.off++
+		l.commandStart = true
+		return token(Newline), nil
+	}
+	if !l.inTest || l.src[start] == ';' {
+		if kind, n := l.operator(); n > 0 {
+			l.off += n
+			l.commandStart = kind != Redirect
+			return token(kind), nil
+		}
+	}
+	if err := l.word(); err != nil {
+		return Token{}, err
+	}
+	tok := token(Word)
+	switch {
+	case l.inTest:
+		l.inTest = tok.Text != "]]"
+		l.commandStart = false
+	case l.commandStart && tok.Text == "[[":
+		l.inTest = true
+		l.commandStart = false
+	default:
+		l.commandStart = commandWords[tok.Text]
+	}
+	return tok, nil
+}
+
+// skipBlanks skips the blanks, escaped newlines and comment ahead.
+func (l *Lexer) skipBlanks() {
+	for l.off < len(l.src) {
+		c, size := utf8.DecodeRuneInString(l.src[l.off:])
+		switch {
+		case c == '\n':
+			return
+		case unicode.IsSpace(c):
+			l.off += size
+		case strings.HasPrefix(l.src[l.off:], "\\\n"):
+			l.off += 2
+		case c == '#':
+			for l.off < len(l.src) && l.src[l.off] != '\n' {
+				l.off++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// operator returns the kind and length of the operator ahead, if any.
+func (l *Lexer) operator() (Kind, int) {
+	rest := l.src[l.off:]
+	digits := 0
+	for digits < len(rest) && rest[digits] >= '0' && rest[digits] <= '9' {
+		digits++
+	}
+	if digits > 0 {
+		switch {
+		case strings.HasPrefix(rest[digits:], ">>"):
+			return Redirect, digits + 2
+		case strings.HasPrefix(rest[digits:], ">"), strings.HasPrefix(rest[digits:], "<"):
+			return Redirect, digits + 1
+		}
+		return EOF, 0
+	}
+	for _, op := range operators {
+		if strings.HasPrefix(rest, op.text) {
+			return op.kind, len(op.text)
+		}
+	}
+	return EOF, 0
+}
+
+// word reads the word ahead, up to unquoted whitespace or an operator.
+func (l *Lexer) word() error {
+	for l.off < len(l.src) {
+		c, size := utf8.DecodeRuneInString(l.src[l.off:])
+		switch {
+		case unicode.IsSpace(c):
+			return nil
+		case c == ';' || !l.inTest && strings.ContainsRune("&|()<>", c):
+			return nil
+		case c == '\\':
+			l.skipEscaped()
+		case c == '\'':
+			if err := l.singleQuotes(); err != nil {
+				return err
+			}
+		case c == '"':
+			if err := l.doubleQuotes(); err != nil {
+				return err
+			}
+		case c == '$':
+			if err := l.dollar(); err != nil {
+				return err
+			}
+		default:
+			l.off += size
+		}
+	}
+	return nil
+}
+
+// skipEscaped skips the backslash ahead and the character it escapes.
+func (l *Lexer) skipEscaped() {
+	l.off++
+	if l.off < len(l.src) {
+		_, size := utf8.DecodeRuneInString(l.src[l.off:])
+		l.off += size
+	}
+}
+
+// singleQuotes reads the single-quoted string ahead.
+func (l *Lexer) singleQuotes() error {
+	end := strings.IndexByte(l.src[l.off+1:], '\'')
+	if end < 0 {
+		return l.unterminated(l.off, "'")
+	}
+	l.off += end + 2
+	return nil
+}
+
+// doubleQuotes reads the double-quoted string ahead, with the escapes and
+// substitutions in it.
+func (l *Lexer) doubleQuotes() error {
+	start := l.off
+	l.off++
+	for l.off < len(l.src) {
+		switch l.src[l.off] {
+		case '"':
+			l.off++
+			return nil
+		case '\\':
+			l.skipEscaped()
+		case '$':
+			if err := l.dollar(); err != nil {
+				return err
+			}
+		default:
+			l.off++
+		}
+	}
+	return l.unterminated(start, `"`)
+}
+
+// dollar reads the $ ahead, along with the ${ } parameter or $( ) command
+// substitution it starts.
+func (l *Lexer) dollar() error {
+	start := l.off
+	l.off++
+	switch {
+	case strings.HasPrefix(l.src[l.off:], "{"):
+		if end := strings.IndexByte(l.src[l.off:], '}'); end >= 0 {
+			l.off += end + 1
+		}
+	case strings.HasPrefix(l.src[l.off:], "("):
+		// The command inside starts out of quotes, and ends at the )
+		// matching the (.
+		l.off++
+		for depth := 1; depth > 0; {
+			if l.off >= len(l.src) {
+				return l.unterminated(start+1, ")")
+			}
+			switch l.src[l.off] {
+			case '\\':
+				l.skipEscaped()
+			case '\'':
+				if err := l.singleQuotes(); err != nil {
+					return err
+				}
+			case '"':
+				if err := l.doubleQuotes(); err != nil {
+					return err
+				}
+			case '(':
+				depth++
+				l.off++
+			case ')':
+				depth--
+				l.off++
+			default:
+				l.off++
+			}
+		}
+	}
+	return nil
+}
+
+// unterminated returns the error for text ending before the match of the
+// opening quote or parenthesis at offset.
+func (l *Lexer) unterminated(offset int, match string) error {
+	return &Error{
+		Pos:        l.Pos(offset),
+		Msg:        fmt.Sprintf("unexpected EOF while looking for matching `%s'", match),
+		Incomplete: true,
+	}
+}
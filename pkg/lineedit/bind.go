@@ -0,0 +1,306 @@
+package lineedit
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// binding is what a key sequence runs in the line editor: the editing
+// function named function, or if macro is set, the keys in it as if typed.
+type binding struct {
+	function string
+	macro    string
+}
+
+// keymapMu guards keymap, which bind can change from a command running in
+// the background while the line editor reads keys.
+var keymapMu sync.RWMutex
+
+// keymap maps each key sequence bound in the line editor, as the characters
+// the terminal sends for it, to its binding.
+var keymap = map[string]binding{
+	"\x01":      {function: "beginning-of-line"}, // Ctrl+A
+	"\x03":      {function: "interrupt"},         // Ctrl+C
+	"\x04":      {function: "end-of-file"},       // Ctrl+D
+	"\x05":      {function: "end-of-line"},       // Ctrl+E
+	"\t":        {function: "complete"},          // Tab
+	"\n":        {function: "accept-line"},
+	"\x0b":      {function: "kill-line"},            // Ctrl+K
+	"\x0c":      {function: "clear-screen"},         // Ctrl+L
+	"\r":        {function: "accept-line"},          // Enter
+	"\x15":      {function: "unix-line-discard"},    // Ctrl+U
+	"\x17":      {function: "unix-word-rubout"},     // Ctrl+W
+	"\x18u":     {function: "undo"},                 // Ctrl+X u
+	"\x18\x15":  {function: "undo"},                 // Ctrl+X Ctrl+U
+	"\x18r":     {function: "redo"},                 // Ctrl+X r
+	"\x18\x12":  {function: "redo"},                 // Ctrl+X Ctrl+R
+	"\x18\x0b":  {function: "kill-to-clipboard"},    // Ctrl+X Ctrl+K
+	"\x18\x19":  {function: "paste-from-clipboard"}, // Ctrl+X Ctrl+Y
+	"\x18\x05":  {function: "edit-command-line"},    // Ctrl+X Ctrl+E
+	"\x19":      {function: "yank"},                 // Ctrl+Y
+	"\x1f":      {function: "undo"},                 // Ctrl+_
+	"\x7f":      {function: "backward-delete-char"},
+	"\x1b[A":    {function: "previous-history"}, // Up
+	"\x1bOA":    {function: "previous-history"},
+	"\x1b[B":    {function: "next-history"}, // Down
+	"\x1bOB":    {function: "next-history"},
+	"\x1b[C":    {function: "forward-char"}, // Right
+	"\x1bOC":    {function: "forward-char"},
+	"\x1b[D":    {function: "backward-char"}, // Left
+	"\x1bOD":    {function: "backward-char"},
+	"\x1b[3~":   {function: "delete-char"},       // Delete
+	"\x1b[H":    {function: "beginning-of-line"}, // Home
+	"\x1bOH":    {function: "beginning-of-line"},
+	"\x1b[1~":   {function: "beginning-of-line"},
+	"\x1b[7~":   {function: "beginning-of-line"},
+	"\x1b[F":    {function: "end-of-line"}, // End
+	"\x1bOF":    {function: "end-of-line"},
+	"\x1b[4~":   {function: "end-of-line"},
+	"\x1b[8~":   {function: "end-of-line"},
+	"\x1bb":     {function: "backward-word"}, // Alt+B
+	"\x1b[1;5D": {function: "backward-word"}, // Ctrl+Left
+	"\x1b[1;3D": {function: "backward-word"},
+	"\x1b[5D":   {function: "backward-word"},
+	"\x1bOd":    {function: "backward-word"},
+	"\x1bf":     {function: "forward-word"}, // Alt+F
+	"\x1b[1;5C": {function: "forward-word"}, // Ctrl+Right
+	"\x1b[1;3C": {function: "forward-word"},
+	"\x1b[5C":   {function: "forward-word"},
+	"\x1bOc":    {function: "forward-word"},
+	"\x1by":     {function: "yank-pop"}, // Alt+Y
+}
+
+// isKeyPrefix reports whether key is the start of a longer bound sequence.
+func isKeyPrefix(key string) bool {
+	keymapMu.RLock()
+	defer keymapMu.RUnlock()
+	for seq := range keymap {
+		if len(seq) > len(key) && strings.HasPrefix(seq, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupKey returns the binding of the key sequence key and whether it is
+// bound.
+func lookupKey(key string) (binding, bool) {
+	keymapMu.RLock()
+	defer keymapMu.RUnlock()
+	b, ok := keymap[key]
+	return b, ok
+}
+
+// Bindings returns the bindings of the keymap, sorted by key sequence, in
+// the form Bind reads.
+func Bindings() []string {
+	keymapMu.RLock()
+	defer keymapMu.RUnlock()
+	keys := make([]string, 0, len(keymap))
+	for key := range keymap {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	bindings := make([]string, len(keys))
+	for i, key := range keys {
+		bindings[i] = formatBinding(key, keymap[key])
+	}
+	return bindings
+}
+
+// Functions returns the names of the editing functions keys can be bound
+// to, sorted.
+func Functions() []string {
+	names := make([]string, 0, len(editFunctions))
+	for name := range editFunctions {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Unbind removes the binding of the key sequence keys, written with
+// readline's escapes.
+func Unbind(keys string) error {
+	key, err := parseKeys(keys)
+	if err != nil {
+		return err
+	}
+	keymapMu.Lock()
+	defer keymapMu.Unlock()
+	if _, ok := keymap[key]; !ok {
+		return errors.New("not bound")
+	}
+	delete(keymap, key)
+	return nil
+}
+
+// Bind adds the binding in line, given in the form of readline's inputrc:
+//
+//	"\C-a": beginning-of-line
+//	"\C-g": "git status\n"
+func Bind(line string) error {
+	if !strings.HasPrefix(line, `"`) {
+		return errors.New(`key sequence must be quoted, as in "\C-a": function-name`)
+	}
+	end := closingQuote(line)
+	if end < 0 {
+		return errors.New("unterminated key sequence")
+	}
+	key, err := parseKeys(line[1:end])
+	if err != nil {
+		return err
+	}
+	if key == "" {
+		return errors.New("empty key sequence")
+	}
+	rest, ok := strings.CutPrefix(strings.TrimSpace(line[end+1:]), ":")
+	if !ok {
+		return errors.New(`missing ":" after key sequence`)
+	}
+	rest = strings.TrimSpace(rest)
+	if strings.HasPrefix(rest, `"`) {
+		end := closingQuote(rest)
+		if end < 0 {
+			return errors.New("unterminated macro")
+		}
+		macro, err := parseKeys(rest[1:end])
+		if err != nil {
+			return err
+		}
+		if macro == "" {
+			return errors.New("empty macro")
+		}
+		setKey(key, binding{macro: macro})
+		return nil
+	}
+	if _, ok := editFunctions[rest]; !ok {
+		return errors.New("unknown function name")
+	}
+	setKey(key, binding{function: rest})
+	return nil
+}
+
+// setKey binds the key sequence key to b.
+func setKey(key string, b binding) {
+	keymapMu.Lock()
+	defer keymapMu.Unlock()
+	keymap[key] = b
+}
+
+// closingQuote returns the index of the double quote closing the string s
+// opens, or -1 if it isn't closed.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// keyEscapes maps the letters after a backslash in a key sequence to the
+// characters they stand for.
+var keyEscapes = map[rune]rune{
+	'a': '\a', 'b': '\b', 'd': '\x7f', 'e': '\x1b', 'f': '\f',
+	'n': '\n', 'r': '\r', 't': '\t', 'v': '\v',
+	'\\': '\\', '"': '"', '\'': '\'',
+}
+
+// parseKeys turns a key sequence written with readline's escapes, such as
+// \C-x for Ctrl+X and \M-b or \eb for Alt+B, into the characters the
+// terminal sends for it.
+func parseKeys(s string) (string, error) {
+	rs := []rune(s)
+	var keys strings.Builder
+	for i := 0; i < len(rs); {
+		key, n, err := parseKey(rs[i:])
+		if err != nil {
+			return "", err
+		}
+		keys.WriteString(key)
+		i += n
+	}
+	return keys.String(), nil
+}
+
+// parseKey parses the key at the start of rs, returning its characters and
+// how many of rs it takes.
+func parseKey(rs []rune) (string, int, error) {
+	if rs[0] != '\\' {
+		return string(rs[0]), 1, nil
+	}
+	if len(rs) < 2 {
+		return "", 0, errors.New("trailing backslash in key sequence")
+	}
+	if len(rs) >= 3 && rs[2] == '-' && (rs[1] == 'C' || rs[1] == 'M') {
+		if len(rs) < 4 {
+			return "", 0, fmt.Errorf(`missing key after \%c-`, rs[1])
+		}
+		key, n, err := parseKey(rs[3:])
+		if err != nil {
+			return "", 0, err
+		}
+		if rs[1] == 'M' {
+			return "\x1b" + key, 3 + n, nil
+		}
+		k := []rune(key)
+		if len(k) != 1 {
+			return "", 0, fmt.Errorf(`\C- needs a single key`)
+		}
+		if k[0] == '?' {
+			return "\x7f", 3 + n, nil
+		}
+		return string(k[0] & 0x1f), 3 + n, nil
+	}
+	if c, ok := keyEscapes[rs[1]]; ok {
+		return string(c), 2, nil
+	}
+	return "", 0, fmt.Errorf(`\%c: unknown escape`, rs[1])
+}
+
+// formatBinding formats the binding of key in the form bind reads.
+func formatBinding(key string, b binding) string {
+	if b.macro != "" {
+		return fmt.Sprintf(`"%s": "%s"`, formatKeys(key, false), formatKeys(b.macro, true))
+	}
+	return fmt.Sprintf(`"%s": %s`, formatKeys(key, false), b.function)
+}
+
+// macroEscapes maps the characters formatKeys spells with an escape in
+// macros to that escape.
+var macroEscapes = map[rune]string{'\n': `\n`, '\r': `\r`, '\t': `\t`}
+
+// formatKeys writes the characters of a key sequence with readline's
+// escapes, spelling control characters as \C-x, or for a macro the usual
+// whitespace ones as \n, \r and \t.
+func formatKeys(s string, macro bool) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch {
+		case macro && macroEscapes[c] != "":
+			b.WriteString(macroEscapes[c])
+		case c == '\x1b':
+			b.WriteString(`\e`)
+		case c == '\x7f':
+			b.WriteString(`\C-?`)
+		case c == '\\' || c == '"':
+			b.WriteString(`\` + string(c))
+		case c < 0x20:
+			ctrl := strings.ToLower(string(c + 0x40))
+			if ctrl == `\` {
+				ctrl = `\\`
+			}
+			b.WriteString(`\C-` + ctrl)
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
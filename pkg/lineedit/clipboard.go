@@ -0,0 +1,49 @@
+package lineedit
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// The clipboard is reached through the terminal with OSC 52 escape
+// sequences, so it is the clipboard of the machine the terminal runs on,
+// even over SSH.
+
+// copyToClipboard sets the clipboard to text.
+func (e *lineEditor) copyToClipboard(text string) {
+	fmt.Fprintf(e.tty, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text)))
+}
+
+// killToClipboard kills the line, copying it to the clipboard as well as
+// the kill ring.
+func (e *lineEditor) killToClipboard() {
+	e.kill(0, len(e.buf))
+	if e.action == "kill" {
+		e.copyToClipboard(e.killRing[len(e.killRing)-1])
+	}
+}
+
+// pasteFromClipboard asks the terminal for the contents of the clipboard,
+// which it sends back as keys for insertClipboard to insert. Terminals
+// that don't allow reading the clipboard don't reply.
+func (e *lineEditor) pasteFromClipboard() {
+	e.clipboardRequested = true
+	fmt.Fprint(e.tty, "\x1b]52;c;?\a")
+}
+
+// insertClipboard inserts the contents of the clipboard from reply, the
+// terminal's reply to pasteFromClipboard without its ESC ] and terminator.
+func (e *lineEditor) insertClipboard(reply string) {
+	if !e.clipboardRequested {
+		return
+	}
+	e.clipboardRequested = false
+	_, data, _ := strings.Cut(strings.TrimPrefix(reply, "52;"), ";")
+	text, err := base64.StdEncoding.DecodeString(data)
+	if err != nil || len(text) == 0 {
+		e.bell()
+		return
+	}
+	e.insert(strings.ReplaceAll(string(text), "\r\n", "\n"))
+}
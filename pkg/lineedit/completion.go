@@ -0,0 +1,164 @@
+package lineedit
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Completion holds the candidates for the word before the cursor.
+type Completion struct {
+	// Word is the text being completed, which every name starts with
+	// unless the completion-ignore-case or completion-fuzzy option is set.
+	Word  string
+	Names []string
+	// Annotate, if set, returns a short note shown next to a candidate
+	// when the candidates are listed.
+	Annotate func(name string) string
+	// Descriptions holds what some of the candidates are, listed in a
+	// column beside them.
+	Descriptions map[string]string
+	// Expand, if set, returns the text the word is replaced with when name
+	// is the only candidate, in place of completing it.
+	Expand func(name string) string
+	// Raw is the word as typed, with its quotes and escapes, and Quote the
+	// quote left open in it, if any. Names are escaped to replace Raw
+	// unless Verbatim is set, as for variables, when they replace Word.
+	Raw      string
+	Quote    rune
+	Verbatim bool
+}
+
+// Typed returns the text of the line that completing replaces.
+func (comp Completion) Typed() string {
+	if comp.Verbatim {
+		return comp.Word
+	}
+	return comp.Raw
+}
+
+// Text returns the text that replaces the word as typed to complete it to
+// name: name escaped, and if closed is set, with the quote left open closed.
+func (comp Completion) Text(name string, closed bool) string {
+	if comp.Verbatim {
+		return name
+	}
+	text := escapeCompletion(name, comp.Quote)
+	if closed && comp.Quote != 0 {
+		text += string(comp.Quote)
+	}
+	return text
+}
+
+// specialChars are the characters escaped in completions outside quotes.
+const specialChars = " \t\n'\"\\$`;|&<>()*?[]{}#!~"
+
+// escapeCompletion writes name so that the shell reads it back as it is:
+// inside the quote the word opened, or outside quotes, with a backslash
+// before each special character.
+func escapeCompletion(name string, quote rune) string {
+	if quote == '\'' {
+		return "'" + strings.ReplaceAll(name, "'", `'\''`)
+	}
+	var b strings.Builder
+	special := specialChars
+	if quote == '"' {
+		special = "\"\\$`"
+		b.WriteByte('"')
+	}
+	for _, c := range name {
+		if strings.ContainsRune(special, c) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// entries returns the candidates as listed: each with its annotation if
+// any, and with its description lined up in a second column beside those
+// of the others.
+func (comp Completion) entries() []string {
+	entries := make([]string, 0, len(comp.Names))
+	width := 0
+	for _, name := range comp.Names {
+		// Paths are listed by their last element.
+		entry := filepath.Base(name)
+		if strings.HasSuffix(name, "/") {
+			entry += "/"
+		}
+		if comp.Annotate != nil {
+			if note := comp.Annotate(name); note != "" {
+				entry += " (" + note + ")"
+			}
+		}
+		entries = append(entries, entry)
+		width = max(width, displayWidth(entry))
+	}
+	for i, name := range comp.Names {
+		if desc := comp.Descriptions[name]; desc != "" {
+			entries[i] += strings.Repeat(" ", width-displayWidth(entries[i])) + "  -- " + desc
+		}
+	}
+	return entries
+}
+
+// layoutRows lays out the entries of candidates as listed in rows that fit
+// in width columns: one per row if they have descriptions, and otherwise in
+// columns as ls -C does.
+func layoutRows(entries []string, width int, described bool) []string {
+	if described {
+		return entries
+	}
+	return columnRows(entries, width)
+}
+
+// columnRows lays entries out in as many columns as fit in width, each as
+// wide as its widest entry and two spaces apart, filled top to bottom.
+func columnRows(entries []string, width int) []string {
+	n := len(entries)
+	for cols := n; cols > 0; cols-- {
+		nrows := (n + cols - 1) / cols
+		if cols > 1 && (cols-1)*nrows >= n {
+			// Fewer columns would hold as many rows.
+			continue
+		}
+		widths := make([]int, cols)
+		total := 2 * (cols - 1)
+		for i, entry := range entries {
+			widths[i/nrows] = max(widths[i/nrows], displayWidth(entry))
+		}
+		for _, w := range widths {
+			total += w
+		}
+		if total > width && cols > 1 {
+			continue
+		}
+		rows := make([]string, nrows)
+		for i, entry := range entries {
+			row, col := i%nrows, i/nrows
+			if col > 0 {
+				rows[row] += "  "
+			}
+			rows[row] += entry
+			if i+nrows < n {
+				rows[row] += strings.Repeat(" ", widths[col]-displayWidth(entry))
+			}
+		}
+		return rows
+	}
+	return nil
+}
+
+func findLongestCommonPrefix(names []string) (longestCommonPrefix string, found bool) {
+	if len(names) == 0 {
+		return
+	}
+	longestCommonPrefix = slices.Min(names)
+	for _, v := range names {
+		if !strings.HasPrefix(v, longestCommonPrefix) {
+			return "", false
+		}
+	}
+	return longestCommonPrefix, true
+}
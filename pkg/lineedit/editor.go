@@ -0,0 +1,1008 @@
+// Package lineedit reads the command lines typed at a terminal, with
+// emacs-style editing keys that can be rebound, completion, a history to
+// browse and suggestions from it.
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/terminal"
+)
+
+// Editor reads lines at the terminal on standard input and output. What
+// it completes, suggests and highlights comes from the shell it reads lines
+// for, through the functions it is given; any of them may be left nil.
+type Editor struct {
+	// History returns the lines entered before, oldest first, to browse
+	// and suggest.
+	History func() []string
+	// Complete returns the candidates for completing the word at the end
+	// of line, the line up to the cursor, and whether there are any.
+	Complete func(line string) (Completion, bool)
+	// Highlight returns line with escape sequences coloring its parts,
+	// with the syntax-highlighting option on.
+	Highlight func(line []rune) string
+	// Abbreviation returns what word expands to if it is an abbreviation,
+	// expanded when typed in command position.
+	Abbreviation func(word string) (string, bool)
+	// Option reports whether the shell option name is on. Editing heeds
+	// accessible, autopair-brackets, autopair-quotes, autosuggestions and
+	// syntax-highlighting.
+	Option func(name string) bool
+	// Color returns the escape sequence for the foreground color name, or
+	// "" if colors are off.
+	Color func(name string) string
+	// Getenv and LookPath find the editor edit-command-line runs, and
+	// Environ gives its environment; they default to os.Getenv,
+	// exec.LookPath and the environment of the process.
+	Getenv   func(name string) string
+	LookPath func(file string) (string, error)
+	Environ  func() []string
+	// Terminal is where lines are read from and drawn, the terminal of the
+	// process if nil.
+	Terminal terminal.Terminal
+	// Timeout, if set, is how long ReadLine waits for a key before giving
+	// up on the line.
+	Timeout time.Duration
+	// Continuation is set while the line read continues a command begun
+	// on the lines before it, which Ctrl+D then gives up rather than end
+	// the input.
+	Continuation bool
+	// Focus, if set, is called with whether the terminal has focus each
+	// time it reports so. Terminals are asked to while a line is read, and
+	// many report at once.
+	Focus func(focused bool)
+
+	// killRing holds the text most recently killed, newest last, for
+	// yanking back.
+	killRing []string
+	// clipboardRequested is set while a paste from the clipboard waits for
+	// the terminal to reply with its contents.
+	clipboardRequested bool
+}
+
+// ErrInterrupt is returned by ReadLine when the line is given up with
+// Ctrl+C. Ctrl+D on an empty line returns io.EOF, as does Ctrl+D pressed
+// twice on one that isn't.
+var ErrInterrupt = errors.New("interrupted")
+
+// ErrTimeout is returned by ReadLine when no key is typed for the
+// Editor's Timeout.
+var ErrTimeout = errors.New("timed out waiting for input")
+
+// lineEditor holds the line being typed at the prompt and the cursor
+// position within it.
+type lineEditor struct {
+	*Editor
+	buf []rune
+	pos int
+	// history holds the lines entered before, histIndex the entry shown,
+	// len(history) while composing a new line, and stash holds that new
+	// line while browsing history.
+	history   []string
+	histIndex int
+	stash     []rune
+	// prompt is printed before the line, and rprompt, if set, at the
+	// right edge of the terminal while the line leaves room for it.
+	prompt, rprompt string
+	rpromptShown    bool
+	// suggestion is the rest of the history entry suggested for the line,
+	// shown dimmed after it.
+	suggestion string
+	// action names what the current key did, for the keys that behave
+	// differently right after a kill or a yank, and prevAction what the
+	// previous key did.
+	action, prevAction string
+	// yankStart is where the text last yanked starts, and yankIndex its
+	// entry in the kill ring.
+	yankStart, yankIndex int
+	// undos holds the states of the line before each edit, newest last,
+	// and redos those undone.
+	undos, redos []editState
+	// r reads the keys typed, and pending holds the rest of a macro
+	// being replayed, read before them. key is the key being handled.
+	r       *bufio.Reader
+	pending []rune
+	key     string
+	// comp holds the completion candidates while Tab is pressed
+	// repeatedly, and wasTab whether the last press rang the bell.
+	comp   Completion
+	wasTab bool
+	// menu is the menu of completion candidates, while it is shown.
+	menu *completionMenu
+	// tty is the terminal the line is edited on. cooked takes it out of
+	// raw mode.
+	tty    terminal.Terminal
+	cooked func() error
+	// done is set once the line is accepted or given up, with err the
+	// reason for the latter.
+	done bool
+	err  error
+}
+
+// editState is the line and cursor position at some point while editing.
+type editState struct {
+	buf []rune
+	pos int
+}
+
+// resetColor ends the colors set by Editor.Color and the menu's highlight.
+const resetColor = "\x1b[0m"
+
+// focusReportingOn and focusReportingOff have the terminal start and stop
+// sending focusIn and focusOut as it gains and loses focus.
+const (
+	focusReportingOn  = "\x1b[?1004h"
+	focusReportingOff = "\x1b[?1004l"
+	focusIn           = "\x1b[I"
+	focusOut          = "\x1b[O"
+)
+
+// killRingSize limits how many kills the kill ring remembers.
+const killRingSize = 16
+
+// autopairs maps the option enabling each character class to the opening
+// characters of that class and the closing character inserted after them.
+var autopairs = map[string]map[rune]rune{
+	"autopair-quotes":   {'"': '"', '\'': '\'', '`': '`'},
+	"autopair-brackets": {'(': ')', '[': ']', '{': '}'},
+}
+
+// ReadLine prints prompt, and rprompt on the right if set, and reads a
+// line typed after it. Each key is looked up in the keymap and runs the
+// editing function or macro bound to it.
+func (ed *Editor) ReadLine(prompt, rprompt string) (string, error) {
+	tty := ed.Terminal
+	if tty == nil {
+		tty = terminal.Std()
+	}
+	restore, err := tty.MakeRaw()
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+	if ed.Focus != nil {
+		fmt.Fprint(tty, focusReportingOn)
+		defer fmt.Fprint(tty, focusReportingOff)
+	}
+	e := &lineEditor{
+		Editor:  ed,
+		prompt:  prompt,
+		rprompt: rprompt,
+		r:       bufio.NewReader(tty),
+		tty:     tty,
+		cooked:  restore,
+	}
+	if ed.History != nil {
+		e.history = ed.History()
+	}
+	e.histIndex = len(e.history)
+	fmt.Fprint(tty, "\r"+prompt)
+	e.updateRPrompt()
+	for {
+		key, err := e.readKey()
+		if err == io.EOF {
+			// The terminal is gone, as when a virtual one runs out of
+			// keys.
+			return "", err
+		}
+		if err == ErrTimeout {
+			fmt.Fprint(tty, "\r\n")
+			return "", err
+		}
+		if err != nil {
+			fmt.Fprintln(tty, err)
+			continue
+		}
+		e.prevAction, e.action = e.action, ""
+		e.dispatch(key)
+		if e.done {
+			break
+		}
+		if e.action != "complete" {
+			e.comp, e.wasTab = Completion{}, false
+		}
+		e.updateRPrompt()
+	}
+	if e.err != nil {
+		return "", e.err
+	}
+	return string(e.buf), nil
+}
+
+// option reports whether the shell option name is on.
+func (ed *Editor) option(name string) bool {
+	return ed.Option != nil && ed.Option(name)
+}
+
+// accessible reports whether accessible mode is on. In accessible mode the
+// editor avoids bells and redrawing text in place, writing plain sequential
+// output that screen readers can follow.
+func (ed *Editor) accessible() bool {
+	return ed.option("accessible")
+}
+
+// color returns the escape sequence for the foreground color name.
+func (ed *Editor) color(name string) string {
+	if ed.Color == nil {
+		return ""
+	}
+	return ed.Color(name)
+}
+
+// editFunctions maps the name of each editing function keys can be bound
+// to with bind to what it does.
+var editFunctions = map[string]func(e *lineEditor){
+	"accept-line":          (*lineEditor).acceptLine,
+	"backward-char":        (*lineEditor).backwardChar,
+	"backward-delete-char": (*lineEditor).backspace,
+	"backward-word":        func(e *lineEditor) { e.moveTo(e.wordStart()) },
+	"beginning-of-line":    func(e *lineEditor) { e.moveTo(0) },
+	"clear-screen":         (*lineEditor).clearScreen,
+	"complete":             (*lineEditor).complete,
+	"delete-char":          (*lineEditor).deleteForward,
+	"edit-command-line":    (*lineEditor).editCommandLine,
+	"end-of-file":          (*lineEditor).endOfFile,
+	"end-of-line":          (*lineEditor).moveToEnd,
+	"forward-char":         (*lineEditor).forwardChar,
+	"forward-word":         func(e *lineEditor) { e.moveTo(e.wordEnd()) },
+	"interrupt":            (*lineEditor).interrupt,
+	"kill-line":            func(e *lineEditor) { e.kill(e.pos, len(e.buf)) },
+	"kill-to-clipboard":    (*lineEditor).killToClipboard,
+	"next-history":         (*lineEditor).historyNext,
+	"paste-from-clipboard": (*lineEditor).pasteFromClipboard,
+	"previous-history":     (*lineEditor).historyPrev,
+	"redo":                 (*lineEditor).redo,
+	"self-insert":          (*lineEditor).selfInsert,
+	"undo":                 (*lineEditor).undo,
+	"unix-line-discard":    func(e *lineEditor) { e.kill(0, e.pos) },
+	"unix-word-rubout":     (*lineEditor).killWordBackward,
+	"yank":                 (*lineEditor).yank,
+	"yank-pop":             (*lineEditor).yankPop,
+}
+
+// readRune reads the next character typed, or of a macro being replayed.
+func (e *lineEditor) readRune() (rune, error) {
+	if len(e.pending) > 0 {
+		c := e.pending[0]
+		e.pending = e.pending[1:]
+		return c, nil
+	}
+	c, _, err := e.r.ReadRune()
+	return c, err
+}
+
+// waitRune reads the next character like readRune, but gives up with
+// ErrTimeout if none comes within the Timeout. The read it gives up on
+// goes on, and takes the character typed next.
+func (e *lineEditor) waitRune() (rune, error) {
+	if len(e.pending) > 0 || e.r.Buffered() > 0 {
+		return e.readRune()
+	}
+	type result struct {
+		c   rune
+		err error
+	}
+	read := make(chan result, 1)
+	go func() {
+		c, err := e.readRune()
+		read <- result{c, err}
+	}()
+	select {
+	case r := <-read:
+		return r.c, r.err
+	case <-time.After(e.Timeout):
+		return 0, ErrTimeout
+	}
+}
+
+// readKey reads the sequence of characters one key sends, reading on while
+// what it has read so far is only the start of a bound sequence, as with
+// Ctrl+X followed by another key.
+func (e *lineEditor) readKey() (string, error) {
+	var key string
+	for {
+		read := e.readRune
+		if key == "" && e.Timeout > 0 {
+			read = e.waitRune
+		}
+		c, err := read()
+		if err != nil {
+			if key == "" {
+				return "", err
+			}
+			return key, nil
+		}
+		key += string(c)
+		if c == '\x1b' {
+			key += e.readEscape()
+		}
+		if _, ok := lookupKey(key); ok || !isKeyPrefix(key) {
+			return key, nil
+		}
+	}
+}
+
+// readEscape reads the rest of an escape sequence after ESC and returns it
+// without the ESC, e.g. "[A" for the Up arrow key. An operating system
+// command the terminal replies with, ESC ] up to BEL or ESC \, is read
+// whole.
+func (e *lineEditor) readEscape() string {
+	c, err := e.readRune()
+	if err != nil {
+		return ""
+	}
+	seq := string(c)
+	if c == ']' {
+		for {
+			c, err := e.readRune()
+			if err != nil || c == '\a' || (c == '\\' && strings.HasSuffix(seq, "\x1b")) {
+				return strings.TrimSuffix(seq, "\x1b")
+			}
+			seq += string(c)
+		}
+	}
+	if c != '[' && c != 'O' {
+		return seq
+	}
+	for {
+		c, err := e.readRune()
+		if err != nil {
+			return seq
+		}
+		seq += string(c)
+		if c >= 0x40 && c <= 0x7E {
+			return seq
+		}
+	}
+}
+
+// dispatch runs what key is bound to. Unbound printable characters insert
+// themselves; other unbound keys ring the bell, except for escape sequences,
+// which are ignored.
+func (e *lineEditor) dispatch(key string) {
+	e.key = key
+	if e.menu != nil && e.menuKey(key) {
+		return
+	}
+	b, ok := lookupKey(key)
+	switch {
+	case ok && b.macro != "":
+		// Replay the macro as if typed, ahead of anything left of the
+		// previous one.
+		e.pending = append([]rune(b.macro), e.pending...)
+	case ok:
+		editFunctions[b.function](e)
+	case strings.HasPrefix(key, "\x1b]52;"):
+		e.insertClipboard(key[2:])
+	case key == focusIn || key == focusOut:
+		if e.Focus != nil {
+			e.Focus(key == focusIn)
+		}
+	case utf8.RuneCountInString(key) == 1 && !unicode.IsControl([]rune(key)[0]):
+		e.selfInsert()
+	case !strings.HasPrefix(key, "\x1b"):
+		e.bell()
+	}
+}
+
+// selfInsert types the last character of the key that ran it.
+func (e *lineEditor) selfInsert() {
+	rs := []rune(e.key)
+	c := rs[len(rs)-1]
+	if c == ' ' {
+		e.expandAbbreviation()
+	}
+	e.typeRune(c)
+}
+
+// expandAbbreviation replaces the word before the cursor with its expansion
+// if it is an abbreviation in command position.
+func (e *lineEditor) expandAbbreviation() {
+	if e.Abbreviation == nil {
+		return
+	}
+	start := e.pos
+	for start > 0 && !unicode.IsSpace(e.buf[start-1]) {
+		start--
+	}
+	if start == e.pos || strings.TrimSpace(string(e.buf[:start])) != "" {
+		return
+	}
+	expansion, ok := e.Abbreviation(string(e.buf[start:e.pos]))
+	if !ok {
+		return
+	}
+	e.replace(start, e.pos, expansion)
+}
+
+// acceptLine finishes the line, expanding an abbreviation before the cursor
+// at its end.
+func (e *lineEditor) acceptLine() {
+	if e.pos == len(e.buf) {
+		e.expandAbbreviation()
+	}
+	if e.suggestion != "" {
+		// Clear the suggestion after the cursor.
+		fmt.Fprint(e.tty, "\x1b[K")
+		e.suggestion = ""
+	}
+	e.updateRPrompt()
+	fmt.Fprint(e.tty, "\r\n")
+	e.done = true
+}
+
+// interrupt gives up the line, for ReadLine to return ErrInterrupt.
+func (e *lineEditor) interrupt() {
+	e.done, e.err = true, ErrInterrupt
+}
+
+// endOfFile ends the input, or gives up the command being continued, for
+// ReadLine to return io.EOF. With a command in the buffer it takes a
+// second press so that a composed command isn't lost by accident.
+func (e *lineEditor) endOfFile() {
+	if len(e.buf) > 0 && e.prevAction != "eof" {
+		e.action = "eof"
+		if e.Continuation {
+			fmt.Fprint(e.tty, "\r\nThere is an unsubmitted command; press Ctrl+D again to give it up.\r\n")
+		} else {
+			fmt.Fprint(e.tty, "\r\nThere is an unsubmitted command; press Ctrl+D again to exit.\r\n")
+		}
+		e.reprint()
+		return
+	}
+	if e.Continuation {
+		fmt.Fprint(e.tty, "\r\n")
+	} else {
+		fmt.Fprint(e.tty, "\r\nexit\r\n")
+	}
+	e.done, e.err = true, io.EOF
+}
+
+// forwardChar moves the cursor right, or at the end of the line accepts the
+// suggestion.
+func (e *lineEditor) forwardChar() {
+	if e.pos < len(e.buf) {
+		e.moveTo(e.pos + 1)
+	} else if !e.acceptSuggestion() {
+		e.bell()
+	}
+}
+
+// backwardChar moves the cursor left.
+func (e *lineEditor) backwardChar() {
+	if e.pos == 0 {
+		e.bell()
+		return
+	}
+	e.moveTo(e.pos - 1)
+}
+
+// killWordBackward kills the whitespace-delimited word before the cursor.
+func (e *lineEditor) killWordBackward() {
+	start := e.pos
+	for start > 0 && unicode.IsSpace(e.buf[start-1]) {
+		start--
+	}
+	for start > 0 && !unicode.IsSpace(e.buf[start-1]) {
+		start--
+	}
+	e.kill(start, e.pos)
+}
+
+// clearScreen clears the screen and redraws the line at the top.
+func (e *lineEditor) clearScreen() {
+	if e.accessible() {
+		e.reprintBelow()
+		return
+	}
+	fmt.Fprint(e.tty, "\x1b[H\x1b[2J")
+	e.reprint()
+}
+
+// complete completes the word before the cursor. With several candidates it
+// inserts their longest common prefix, or if there is none rings the bell
+// and lists them on the next press.
+func (e *lineEditor) complete() {
+	e.action = "complete"
+	if len(e.comp.Names) == 0 {
+		var found bool
+		if e.Complete != nil {
+			e.comp, found = e.Complete(string(e.buf[:e.pos]))
+		}
+		if !found {
+			e.bell()
+			return
+		}
+	}
+	switch {
+	case len(e.comp.Names) == 1 && e.comp.Expand != nil:
+		e.replace(e.pos-len([]rune(e.comp.Typed())), e.pos, e.comp.Expand(e.comp.Names[0]))
+		e.comp = Completion{}
+	case len(e.comp.Names) == 1:
+		name := e.comp.Names[0]
+		if strings.HasSuffix(name, "/") {
+			e.insertCompletion(e.comp.Text(name, false))
+		} else {
+			e.insertCompletion(e.comp.Text(name, true) + " ")
+		}
+		e.comp = Completion{}
+	case len(e.comp.Names) > 1:
+		longestCommonPrefix, found := findLongestCommonPrefix(e.comp.Names)
+		if found {
+			e.insertCompletion(e.comp.Text(longestCommonPrefix, false))
+			e.comp, e.wasTab = Completion{}, false
+			return
+		}
+		if !e.wasTab {
+			e.bell()
+			e.wasTab = true
+			return
+		}
+		if len(e.comp.Names) > completionQueryItems {
+			e.listCandidates(e.comp)
+			return
+		}
+		if !e.accessible() {
+			e.openMenu(e.comp)
+			return
+		}
+		width, _ := e.terminalSize()
+		rows := layoutRows(e.comp.entries(), width, len(e.comp.Descriptions) > 0)
+		fmt.Fprintf(e.tty, "\r\n%s\r\n", strings.Join(rows, "\r\n"))
+		e.reprint()
+	}
+}
+
+// insertCompletion replaces the word before the cursor with text, or when
+// text starts with the word as typed, inserts the rest of it.
+func (e *lineEditor) insertCompletion(text string) {
+	typed := e.comp.Typed()
+	if suffix, ok := strings.CutPrefix(text, typed); ok {
+		e.insert(suffix)
+		return
+	}
+	e.replace(e.pos-len([]rune(typed)), e.pos, text)
+}
+
+// typeRune handles a printable character typed by the user, applying
+// auto-pairing of quotes and brackets when it is enabled.
+func (e *lineEditor) typeRune(c rune) {
+	e.action = "type"
+	if e.pos < len(e.buf) && e.buf[e.pos] == c && e.isAutoClosing(c) {
+		// Type over the closing character instead of doubling it.
+		e.pos++
+		if e.fullRedraw() {
+			e.redraw()
+			return
+		}
+		fmt.Fprint(e.tty, string(c))
+		return
+	}
+	if closing, ok := e.autoPair(c); ok && e.shouldPair(c) {
+		e.insert(string(c) + string(closing))
+		e.pos--
+		if e.fullRedraw() {
+			e.redraw()
+			return
+		}
+		e.moveBack(1)
+		return
+	}
+	e.insert(string(c))
+}
+
+// insert inserts s at the cursor and redraws the rest of the line.
+func (e *lineEditor) insert(s string) {
+	e.saveUndo()
+	rs := []rune(s)
+	e.buf = slices.Insert(e.buf, e.pos, rs...)
+	e.pos += len(rs)
+	tail := e.buf[e.pos:]
+	if e.fullRedraw() {
+		e.redraw()
+		return
+	}
+	if e.accessible() && len(tail) > 0 {
+		e.reprintBelow()
+		return
+	}
+	fmt.Fprint(e.tty, string(rs)+string(tail))
+	e.moveBack(textWidth(tail))
+}
+
+// replace replaces the characters from start to end with s and leaves the
+// cursor after it. start must not lie after the cursor.
+func (e *lineEditor) replace(start, end int, s string) {
+	e.saveUndo()
+	rs := []rune(s)
+	if e.accessible() || e.fullRedraw() {
+		e.buf = slices.Replace(e.buf, start, end, rs...)
+		e.pos = start + len(rs)
+		if e.fullRedraw() {
+			e.redraw()
+		} else {
+			e.reprintBelow()
+		}
+		return
+	}
+	e.moveBack(textWidth(e.buf[start:e.pos]))
+	pad := max(textWidth(e.buf[start:end])-textWidth(rs), 0)
+	e.buf = slices.Replace(e.buf, start, end, rs...)
+	e.pos = start + len(rs)
+	tail := e.buf[e.pos:]
+	fmt.Fprint(e.tty, s+string(tail)+strings.Repeat(" ", pad))
+	e.moveBack(textWidth(tail) + pad)
+}
+
+// backspace deletes the character before the cursor. An empty pair left by
+// auto-pairing is deleted as a whole.
+func (e *lineEditor) backspace() {
+	if e.pos == 0 {
+		return
+	}
+	e.saveUndo()
+	n := 1
+	if closing, ok := e.autoPair(e.buf[e.pos-1]); ok && e.pos < len(e.buf) && e.buf[e.pos] == closing {
+		n = 2
+	}
+	back := runeWidth(e.buf[e.pos-1])
+	width := textWidth(e.buf[e.pos-1 : e.pos-1+n])
+	e.buf = slices.Delete(e.buf, e.pos-1, e.pos-1+n)
+	e.pos--
+	tail := e.buf[e.pos:]
+	if e.fullRedraw() {
+		e.redraw()
+		return
+	}
+	if e.accessible() && len(tail) > 0 {
+		e.reprintBelow()
+		return
+	}
+	e.moveBack(back)
+	fmt.Fprint(e.tty, string(tail)+strings.Repeat(" ", width))
+	e.moveBack(textWidth(tail) + width)
+}
+
+// deleteForward deletes the character under the cursor.
+func (e *lineEditor) deleteForward() {
+	if e.pos == len(e.buf) {
+		e.bell()
+		return
+	}
+	e.saveUndo()
+	width := runeWidth(e.buf[e.pos])
+	e.buf = slices.Delete(e.buf, e.pos, e.pos+1)
+	tail := e.buf[e.pos:]
+	if e.fullRedraw() {
+		e.redraw()
+		return
+	}
+	if e.accessible() {
+		e.reprintBelow()
+		return
+	}
+	fmt.Fprint(e.tty, string(tail)+strings.Repeat(" ", width))
+	e.moveBack(textWidth(tail) + width)
+}
+
+// isWordRune reports whether c is part of a word for word-wise movement.
+func isWordRune(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+// wordStart returns the start of the word before the cursor.
+func (e *lineEditor) wordStart() int {
+	pos := e.pos
+	for pos > 0 && !isWordRune(e.buf[pos-1]) {
+		pos--
+	}
+	for pos > 0 && isWordRune(e.buf[pos-1]) {
+		pos--
+	}
+	return pos
+}
+
+// wordEnd returns the end of the word after the cursor.
+func (e *lineEditor) wordEnd() int {
+	pos := e.pos
+	for pos < len(e.buf) && !isWordRune(e.buf[pos]) {
+		pos++
+	}
+	for pos < len(e.buf) && isWordRune(e.buf[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// saveUndo records the line before an edit, so that it can be undone.
+// Characters typed in a row are undone together, as are candidates
+// selected in a row from the completion menu. Kills save the line
+// themselves, before moving the cursor.
+func (e *lineEditor) saveUndo() {
+	if e.action == "undo" || e.action == "kill" || (e.action == e.prevAction && (e.action == "type" || e.action == "menu")) {
+		return
+	}
+	e.undos = append(e.undos, editState{slices.Clone(e.buf), e.pos})
+	e.redos = nil
+}
+
+// undo reverts the line to before the last edit.
+func (e *lineEditor) undo() {
+	if len(e.undos) == 0 {
+		e.bell()
+		return
+	}
+	e.redos = append(e.redos, editState{slices.Clone(e.buf), e.pos})
+	e.restore(e.undos[len(e.undos)-1])
+	e.undos = e.undos[:len(e.undos)-1]
+}
+
+// redo makes the last edit undone again.
+func (e *lineEditor) redo() {
+	if len(e.redos) == 0 {
+		e.bell()
+		return
+	}
+	redos := e.redos
+	e.undos = append(e.undos, editState{slices.Clone(e.buf), e.pos})
+	e.restore(redos[len(redos)-1])
+	e.redos = redos[:len(redos)-1]
+}
+
+// restore shows the line and cursor of st.
+func (e *lineEditor) restore(st editState) {
+	e.action = "undo"
+	e.moveTo(0)
+	e.replace(0, len(e.buf), string(st.buf))
+	e.moveTo(st.pos)
+}
+
+// moveToEnd moves the cursor to the end of the line, or accepts the
+// suggestion if it is already there.
+func (e *lineEditor) moveToEnd() {
+	if e.pos < len(e.buf) {
+		e.moveTo(len(e.buf))
+	} else if !e.acceptSuggestion() {
+		e.bell()
+	}
+}
+
+// kill deletes the text from start to end, one of which must be the
+// cursor, and saves it in the kill ring. Consecutive kills are saved
+// together, so that one yank brings them all back.
+func (e *lineEditor) kill(start, end int) {
+	if start == end {
+		e.bell()
+		return
+	}
+	text := string(e.buf[start:end])
+	switch {
+	case e.prevAction == "kill" && len(e.killRing) > 0 && start < e.pos:
+		e.killRing[len(e.killRing)-1] = text + e.killRing[len(e.killRing)-1]
+	case e.prevAction == "kill" && len(e.killRing) > 0:
+		e.killRing[len(e.killRing)-1] += text
+	default:
+		e.killRing = append(e.killRing, text)
+		if len(e.killRing) > killRingSize {
+			e.killRing = e.killRing[1:]
+		}
+	}
+	e.saveUndo()
+	e.action = "kill"
+	if start < e.pos {
+		e.moveTo(start)
+	}
+	e.replace(start, end, "")
+}
+
+// yank inserts the text most recently killed at the cursor.
+func (e *lineEditor) yank() {
+	if len(e.killRing) == 0 {
+		e.bell()
+		return
+	}
+	e.yankStart, e.yankIndex = e.pos, len(e.killRing)-1
+	e.insert(e.killRing[e.yankIndex])
+	e.action = "yank"
+}
+
+// yankPop replaces the text just yanked with the kill before it, cycling
+// through the kill ring.
+func (e *lineEditor) yankPop() {
+	if e.prevAction != "yank" {
+		e.bell()
+		return
+	}
+	e.yankIndex = (e.yankIndex - 1 + len(e.killRing)) % len(e.killRing)
+	e.replace(e.yankStart, e.pos, e.killRing[e.yankIndex])
+	e.action = "yank"
+}
+
+// moveTo moves the cursor to pos in the line.
+func (e *lineEditor) moveTo(pos int) {
+	if e.fullRedraw() {
+		e.pos = pos
+		e.redraw()
+		return
+	}
+	if pos < e.pos {
+		e.moveBack(textWidth(e.buf[pos:e.pos]))
+	} else if n := textWidth(e.buf[e.pos:pos]); n > 0 {
+		fmt.Fprintf(e.tty, "\x1b[%dC", n)
+	}
+	e.pos = pos
+}
+
+// reprint prints the prompt and the line again, for when output has been
+// written below the line being edited.
+func (e *lineEditor) reprint() {
+	fmt.Fprint(e.tty, e.prompt)
+	e.drawLine()
+	e.updateRPrompt()
+}
+
+// fullRedraw reports whether edits redraw the whole line rather than just
+// the characters they change, as highlighting and suggestions need.
+// Redrawing in place is avoided in accessible mode.
+func (e *lineEditor) fullRedraw() bool {
+	return (e.option("syntax-highlighting") || e.option("autosuggestions")) && !e.accessible()
+}
+
+// render returns the line as drawn, highlighted if enabled.
+func (e *lineEditor) render() string {
+	if e.option("syntax-highlighting") && e.Highlight != nil {
+		return e.Highlight(e.buf)
+	}
+	return string(e.buf)
+}
+
+// redraw draws the last line of the prompt and the whole line again in
+// place, clearing whatever followed it.
+func (e *lineEditor) redraw() {
+	prompt := e.prompt[strings.LastIndex(e.prompt, "\n")+1:]
+	fmt.Fprint(e.tty, "\r"+prompt)
+	e.drawLine()
+}
+
+// drawLine draws the line and its suggestion from the cursor on, which
+// must be at the start of the line, and leaves the cursor at e.pos.
+func (e *lineEditor) drawLine() {
+	e.suggestion = e.suggest()
+	fmt.Fprint(e.tty, e.render())
+	if e.suggestion != "" {
+		fmt.Fprint(e.tty, e.color("bright-black")+e.suggestion+resetColor)
+	}
+	fmt.Fprint(e.tty, "\x1b[K")
+	e.moveBack(textWidth(e.buf[e.pos:]) + textWidth([]rune(e.suggestion)))
+	e.rpromptShown = false
+}
+
+// suggest returns the rest of the most recent history entry that starts
+// with the line, when the autosuggestions option is on and the cursor is
+// at the end of the line.
+func (e *lineEditor) suggest() string {
+	if !e.option("autosuggestions") || e.accessible() || len(e.buf) == 0 || e.pos != len(e.buf) {
+		return ""
+	}
+	line := string(e.buf)
+	for i := len(e.history) - 1; i >= 0; i-- {
+		if rest, ok := strings.CutPrefix(e.history[i], line); ok && rest != "" && !strings.Contains(rest, "\n") {
+			return rest
+		}
+	}
+	return ""
+}
+
+// acceptSuggestion appends the suggestion to the line and reports whether
+// there was one.
+func (e *lineEditor) acceptSuggestion() bool {
+	if e.suggestion == "" {
+		return false
+	}
+	e.insert(e.suggestion)
+	return true
+}
+
+// updateRPrompt shows the right prompt if the line leaves a column free
+// before it, and erases it once the line reaches it.
+func (e *lineEditor) updateRPrompt() {
+	if e.rprompt == "" || e.accessible() {
+		return
+	}
+	width, _, err := e.tty.Size()
+	if err != nil {
+		return
+	}
+	promptWidth := displayWidth(e.prompt[strings.LastIndexAny(e.prompt, "\n")+1:])
+	col := width - displayWidth(e.rprompt) + 1
+	fits := promptWidth+textWidth(e.buf)+1 < col
+	switch {
+	case fits && !e.rpromptShown:
+		// Save the cursor, print at the right edge and restore it.
+		fmt.Fprintf(e.tty, "\x1b7\x1b[%dG%s\x1b8", col, e.rprompt)
+	case !fits && e.rpromptShown:
+		fmt.Fprintf(e.tty, "\x1b7\x1b[%dG\x1b[K\x1b8", col)
+	}
+	e.rpromptShown = fits
+}
+
+// reprintBelow prints the prompt and the line on a new line. Accessible
+// mode uses it in place of redrawing the current line.
+func (e *lineEditor) reprintBelow() {
+	fmt.Fprint(e.tty, "\r\n")
+	e.reprint()
+}
+
+// bell rings the terminal bell unless in accessible mode.
+func (e *lineEditor) bell() {
+	if !e.accessible() {
+		fmt.Fprint(e.tty, "\a")
+	}
+}
+
+// moveBack moves the terminal cursor n columns to the left.
+func (e *lineEditor) moveBack(n int) {
+	if n > 0 {
+		fmt.Fprintf(e.tty, "\x1b[%dD", n)
+	}
+}
+
+// shouldPair reports whether typing the opening character c at the cursor
+// should also insert its closing character. Quotes are not paired inside
+// words, so apostrophes and escaped quotes are typed as is.
+func (e *lineEditor) shouldPair(c rune) bool {
+	if e.pos < len(e.buf) && !unicode.IsSpace(e.buf[e.pos]) && !e.isAutoClosing(e.buf[e.pos]) {
+		return false
+	}
+	if e.pos == 0 {
+		return true
+	}
+	prev := e.buf[e.pos-1]
+	if prev == '\\' {
+		return false
+	}
+	if _, isQuote := autopairs["autopair-quotes"][c]; isQuote {
+		return !unicode.IsLetter(prev) && !unicode.IsDigit(prev)
+	}
+	return true
+}
+
+// autoPair returns the closing character for c if auto-pairing is enabled
+// for its character class. Pairing moves the cursor in place, so it is off
+// in accessible mode.
+func (ed *Editor) autoPair(c rune) (closing rune, ok bool) {
+	if ed.accessible() {
+		return 0, false
+	}
+	for option, pairs := range autopairs {
+		if closing, ok := pairs[c]; ok && ed.option(option) {
+			return closing, true
+		}
+	}
+	return 0, false
+}
+
+// isAutoClosing reports whether c is a closing character of a character
+// class with auto-pairing enabled.
+func (ed *Editor) isAutoClosing(c rune) bool {
+	for option, pairs := range autopairs {
+		if !ed.option(option) {
+			continue
+		}
+		for _, closing := range pairs {
+			if closing == c {
+				return true
+			}
+		}
+	}
+	return false
+}
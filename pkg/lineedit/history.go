@@ -0,0 +1,33 @@
+package lineedit
+
+import "slices"
+
+// historyPrev replaces the line with the previous history entry. Leaving
+// the line being composed stashes it so that historyNext can restore it.
+func (e *lineEditor) historyPrev() {
+	if e.histIndex == 0 {
+		e.bell()
+		return
+	}
+	if e.histIndex == len(e.history) {
+		e.stash = slices.Clone(e.buf)
+	}
+	e.histIndex--
+	e.replace(0, len(e.buf), e.history[e.histIndex])
+}
+
+// historyNext replaces the line with the next history entry, or with the
+// stashed line when moving past the newest entry.
+func (e *lineEditor) historyNext() {
+	if e.histIndex >= len(e.history) {
+		e.bell()
+		return
+	}
+	e.histIndex++
+	if e.histIndex == len(e.history) {
+		e.replace(0, len(e.buf), string(e.stash))
+		e.stash = nil
+		return
+	}
+	e.replace(0, len(e.buf), e.history[e.histIndex])
+}
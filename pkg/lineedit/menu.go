@@ -0,0 +1,158 @@
+package lineedit
+
+import (
+	"fmt"
+	"strings"
+)
+
+// completionQueryItems is how many candidates there can be before Tab asks
+// whether to list them all, rather than showing the menu.
+const completionQueryItems = 100
+
+// completionMenu is the menu of completion candidates shown below the line
+// after Tab is pressed twice, with the candidate selected inserted in the
+// line.
+type completionMenu struct {
+	comp Completion
+	// selected is the index of the candidate selected, and start where in
+	// the line the text inserted for it starts.
+	selected, start int
+	// rows is how many rows of the terminal the menu takes up.
+	rows int
+}
+
+// openMenu shows the menu of the candidates in comp and selects the first.
+func (e *lineEditor) openMenu(comp Completion) {
+	e.menu = &completionMenu{comp: comp, start: e.pos - len([]rune(comp.Typed()))}
+	e.selectCandidate(0)
+}
+
+// selectCandidate selects candidate i of the menu, inserting it in place
+// of the one selected before, and redraws the menu.
+func (e *lineEditor) selectCandidate(i int) {
+	m := e.menu
+	n := len(m.comp.Names)
+	m.selected = (i%n + n) % n
+	e.action = "menu"
+	e.replace(m.start, e.pos, m.comp.Text(m.comp.Names[m.selected], false))
+	e.drawMenu()
+}
+
+// menuKey handles key while the menu is shown and reports whether it did.
+// Tab and the arrow keys move the selection, Enter accepts it and Ctrl+G
+// takes it back; any other key accepts it and goes on to do what it does.
+func (e *lineEditor) menuKey(key string) bool {
+	switch key {
+	case "\t", "\x1b[B", "\x1bOB", "\x1b[C", "\x1bOC":
+		e.selectCandidate(e.menu.selected + 1)
+	case "\x1b[Z", "\x1b[A", "\x1bOA", "\x1b[D", "\x1bOD":
+		e.selectCandidate(e.menu.selected - 1)
+	case "\r", "\n":
+		e.closeMenu()
+	case "\x07":
+		e.replace(e.menu.start, e.pos, e.menu.comp.Typed())
+		e.closeMenu()
+	default:
+		e.closeMenu()
+		return false
+	}
+	return true
+}
+
+// closeMenu erases the menu and leaves the selection in the line.
+func (e *lineEditor) closeMenu() {
+	e.clearBelow()
+	e.menu = nil
+	e.comp, e.wasTab = Completion{}, false
+}
+
+// drawMenu draws the menu below the line, with the selection highlighted,
+// and puts the cursor back. Only the rows around the selection that fit on
+// the terminal are drawn.
+func (e *lineEditor) drawMenu() {
+	m := e.menu
+	width, height := e.terminalSize()
+	entries := m.comp.entries()
+	entries[m.selected] = "\x1b[7m" + entries[m.selected] + resetColor
+	rows := layoutRows(entries, width, len(m.comp.Descriptions) > 0)
+	row := 0
+	for i, r := range rows {
+		if strings.Contains(r, "\x1b[7m") {
+			row = i
+		}
+	}
+	if limit := max(height-2, 1); len(rows) > limit {
+		first := row / limit * limit
+		rows = rows[first:min(first+limit, len(rows))]
+	}
+	fmt.Fprint(e.tty, "\r\n\x1b[J"+strings.Join(rows, "\r\n"))
+	m.rows = len(rows)
+	e.returnToLine(m.rows)
+}
+
+// clearBelow erases the rows below the line.
+func (e *lineEditor) clearBelow() {
+	fmt.Fprint(e.tty, "\r\n\x1b[J")
+	e.returnToLine(1)
+}
+
+// returnToLine moves the cursor up rows rows, back to where it is in the
+// line.
+func (e *lineEditor) returnToLine(rows int) {
+	prompt := e.prompt[strings.LastIndex(e.prompt, "\n")+1:]
+	fmt.Fprintf(e.tty, "\x1b[%dA\r", rows)
+	if col := displayWidth(prompt) + textWidth(e.buf[:e.pos]); col > 0 {
+		fmt.Fprintf(e.tty, "\x1b[%dC", col)
+	}
+}
+
+// terminalSize returns the width and height of the terminal, or 80 by 24 if
+// they can't be told.
+func (e *lineEditor) terminalSize() (width, height int) {
+	width, height, err := e.tty.Size()
+	if err != nil {
+		return 80, 24
+	}
+	return width, height
+}
+
+// listCandidates asks whether to list the many candidates in comp and if so
+// lists them a page at a time, as more does: space shows the next page,
+// Enter the next row and q stops.
+func (e *lineEditor) listCandidates(comp Completion) {
+	fmt.Fprintf(e.tty, "\r\nDisplay all %d possibilities? (y or n)", len(comp.Names))
+	for {
+		c, err := e.readRune()
+		if err != nil || c == 'n' || c == 'N' || c == '\x03' || c == '\x07' {
+			fmt.Fprint(e.tty, "\r\n")
+			e.reprint()
+			return
+		}
+		if c == 'y' || c == 'Y' || c == ' ' {
+			break
+		}
+	}
+	width, height := e.terminalSize()
+	rows := layoutRows(comp.entries(), width, len(comp.Descriptions) > 0)
+	fmt.Fprint(e.tty, "\r\n")
+	page := max(height-1, 1)
+	for i, row := range rows {
+		fmt.Fprint(e.tty, row+"\r\n")
+		if page--; page > 0 || i+1 == len(rows) {
+			continue
+		}
+		fmt.Fprint(e.tty, "--More--")
+		c, err := e.readRune()
+		fmt.Fprint(e.tty, "\r\x1b[K")
+		switch {
+		case err != nil || c == 'q' || c == 'Q' || c == '\x03' || c == '\x07':
+			e.reprint()
+			return
+		case c == '\r' || c == '\n':
+			page = 1
+		default:
+			page = max(height-1, 1)
+		}
+	}
+	e.reprint()
+}
@@ -0,0 +1,69 @@
+package lineedit
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/terminal"
+)
+
+// editCommandLine opens the line in $VISUAL or $EDITOR, vi if neither is
+// set, and loads what is saved back into the line editor.
+func (e *lineEditor) editCommandLine() {
+	getenv, lookPath := e.Getenv, e.LookPath
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	if lookPath == nil {
+		lookPath = exec.LookPath
+	}
+	editor := strings.Fields(getenv("VISUAL"))
+	if len(editor) == 0 {
+		editor = strings.Fields(getenv("EDITOR"))
+	}
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+	path, err := lookPath(editor[0])
+	if err != nil {
+		e.bell()
+		return
+	}
+	f, err := os.CreateTemp("", "myshell-*.sh")
+	if err != nil {
+		e.bell()
+		return
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(string(e.buf) + "\n")
+	f.Close()
+	if err != nil {
+		e.bell()
+		return
+	}
+	// The editor runs with the terminal out of raw mode, below the line.
+	fmt.Fprint(e.tty, "\r\n")
+	e.cooked()
+	command := exec.Command(path, append(editor[1:], f.Name())...)
+	command.Args[0] = editor[0]
+	if e.Environ != nil {
+		command.Env = e.Environ()
+	}
+	if f, ok := e.tty.(*terminal.File); ok {
+		command.Stdin, command.Stdout, command.Stderr = f.In, f.Out, f.Out
+	} else {
+		command.Stdin, command.Stdout, command.Stderr = e.tty, e.tty, e.tty
+	}
+	err = command.Run()
+	e.tty.MakeRaw()
+	if err == nil {
+		if text, readErr := os.ReadFile(f.Name()); readErr == nil {
+			e.saveUndo()
+			e.buf = []rune(strings.TrimRight(string(text), "\n"))
+			e.pos = len(e.buf)
+		}
+	}
+	e.reprint()
+}
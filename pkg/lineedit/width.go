@@ -0,0 +1,131 @@
+package lineedit
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// wide holds the characters terminals draw two columns wide: those of East
+// Asian width Wide or Fullwidth, such as CJK ideographs, kana, Hangul
+// syllables and most emoji.
+var wide = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x1100, 0x115f, 1},
+		{0x231a, 0x231b, 1},
+		{0x2329, 0x232a, 1},
+		{0x23e9, 0x23ec, 1},
+		{0x23f0, 0x23f0, 1},
+		{0x23f3, 0x23f3, 1},
+		{0x25fd, 0x25fe, 1},
+		{0x2614, 0x2615, 1},
+		{0x2648, 0x2653, 1},
+		{0x267f, 0x267f, 1},
+		{0x2693, 0x2693, 1},
+		{0x26a1, 0x26a1, 1},
+		{0x26aa, 0x26ab, 1},
+		{0x26bd, 0x26be, 1},
+		{0x26c4, 0x26c5, 1},
+		{0x26ce, 0x26ce, 1},
+		{0x26d4, 0x26d4, 1},
+		{0x26ea, 0x26ea, 1},
+		{0x26f2, 0x26f3, 1},
+		{0x26f5, 0x26f5, 1},
+		{0x26fa, 0x26fa, 1},
+		{0x26fd, 0x26fd, 1},
+		{0x2705, 0x2705, 1},
+		{0x270a, 0x270b, 1},
+		{0x2728, 0x2728, 1},
+		{0x274c, 0x274c, 1},
+		{0x274e, 0x274e, 1},
+		{0x2753, 0x2755, 1},
+		{0x2757, 0x2757, 1},
+		{0x2795, 0x2797, 1},
+		{0x27b0, 0x27b0, 1},
+		{0x27bf, 0x27bf, 1},
+		{0x2b1b, 0x2b1c, 1},
+		{0x2b50, 0x2b50, 1},
+		{0x2b55, 0x2b55, 1},
+		{0x2e80, 0x303e, 1},
+		{0x3041, 0x33ff, 1},
+		{0x3400, 0x4dbf, 1},
+		{0x4e00, 0x9fff, 1},
+		{0xa000, 0xa4cf, 1},
+		{0xa960, 0xa97f, 1},
+		{0xac00, 0xd7a3, 1},
+		{0xf900, 0xfaff, 1},
+		{0xfe10, 0xfe19, 1},
+		{0xfe30, 0xfe6f, 1},
+		{0xff00, 0xff60, 1},
+		{0xffe0, 0xffe6, 1},
+	},
+	R32: []unicode.Range32{
+		{0x16fe0, 0x16fe4, 1},
+		{0x17000, 0x18cff, 1},
+		{0x1b000, 0x1b2ff, 1},
+		{0x1f004, 0x1f004, 1},
+		{0x1f0cf, 0x1f0cf, 1},
+		{0x1f18e, 0x1f18e, 1},
+		{0x1f191, 0x1f19a, 1},
+		{0x1f200, 0x1f251, 1},
+		{0x1f300, 0x1f64f, 1},
+		{0x1f680, 0x1f6ff, 1},
+		{0x1f7e0, 0x1f7eb, 1},
+		{0x1f900, 0x1f9ff, 1},
+		{0x1fa70, 0x1faff, 1},
+		{0x20000, 0x2fffd, 1},
+		{0x30000, 0x3fffd, 1},
+	},
+}
+
+// runeWidth returns how many columns a terminal draws c across: two for
+// wide characters, none for combining marks and other characters that
+// modify the one before, and one for the rest.
+func runeWidth(c rune) int {
+	switch {
+	case (c >= 0x1160 && c <= 0x11ff) || unicode.In(c, unicode.Mn, unicode.Me, unicode.Cf):
+		// Cf includes zero-width spaces and joiners; U+1160 to U+11FF are
+		// Hangul vowels and final consonants joining the syllable before.
+		return 0
+	case unicode.Is(wide, c):
+		return 2
+	}
+	return 1
+}
+
+// textWidth returns how many columns a terminal draws rs across.
+func textWidth(rs []rune) int {
+	width := 0
+	for _, c := range rs {
+		width += runeWidth(c)
+	}
+	return width
+}
+
+// displayWidth returns the number of columns s takes up on the terminal,
+// not counting escape sequences.
+func displayWidth(s string) int {
+	width := 0
+	for i := 0; i < len(s); i++ {
+		if strings.HasPrefix(s[i:], "\x1b]") {
+			// An operating system command, such as one marking where a
+			// prompt starts, ends with BEL or ESC \.
+			for i += 2; i < len(s) && s[i] != '\a' && !strings.HasPrefix(s[i:], "\x1b\\"); i++ {
+			}
+			if i < len(s) && s[i] == '\x1b' {
+				i++
+			}
+			continue
+		}
+		if s[i] == '\x1b' {
+			// Skip to the final byte of the sequence.
+			for i++; i < len(s) && (s[i] < 0x40 || s[i] > 0x7E || s[i] == '['); i++ {
+			}
+			continue
+		}
+		c, n := utf8.DecodeRuneInString(s[i:])
+		width += runeWidth(c)
+		i += n - 1
+	}
+	return width
+}
@@ -0,0 +1,125 @@
+package parser
+
+import "github.com/codecrafters-io/shell-starter-go/pkg/lexer"
+
+// Command is a command in the syntax tree: a *SimpleCommand, or one of the
+// compound commands *If, *For, *While, *Group and *FunctionDef.
+type Command interface {
+	Pos() lexer.Pos
+	command()
+}
+
+// Word is a word as written, with its quotes and parameters left for the
+// shell to expand when it runs the command.
+type Word struct {
+	Text     string
+	Position lexer.Pos
+}
+
+// List is a sequence of commands, each run after the one before it ends
+// unless it was put in the background.
+type List struct {
+	Items []*AndOr
+}
+
+// AndOr is a sequence of pipelines joined by && or ||, each run depending
+// on the status of the ones before it.
+type AndOr struct {
+	Pipelines []*Pipeline
+	// Ops holds the operator before each pipeline after the first, lexer.AndIf
+	// or lexer.OrIf.
+	Ops []lexer.Kind
+	// Background is set for an AndOr ended by &, which runs without being
+	// waited for.
+	Background bool
+}
+
+// Pipeline is a sequence of commands joined by |, each with its standard
+// output going to the standard input of the next.
+type Pipeline struct {
+	Commands []Command
+	// Negated is set for a pipeline after a !, whose status is inverted.
+	Negated bool
+	// Timed is set for a pipeline after time, whose run time is reported.
+	Timed bool
+}
+
+// SimpleCommand is a command name and its arguments, preceded by variable
+// assignments, with the redirections of its input and output.
+type SimpleCommand struct {
+	// Assignments holds the NAME=value assignments preceding the command.
+	Assignments []*Word
+	// Words holds the command name and its arguments, and is empty for a
+	// command of assignments only.
+	Words []*Word
+	// Redirects holds the redirections of the command's input and output,
+	// in order.
+	Redirects []*Redirect
+	Position  lexer.Pos
+}
+
+// Redirect is the redirection of a file descriptor to or from a file.
+type Redirect struct {
+	// Fd is the file descriptor redirected: 0 for standard input, 1 for
+	// standard output or 2 for standard error.
+	Fd int
+	// Append is set to append to File rather than truncate it.
+	Append bool
+	File   *Word
+	// Op is the operator as written, such as 2>>.
+	Op string
+}
+
+// If is an if command, with the clause of each elif chained after it.
+type If struct {
+	Cond, Then *List
+	// Elif is the clause of the elif following Then, if any, and Else the
+	// commands after else.
+	Elif     *If
+	Else     *List
+	Position lexer.Pos
+}
+
+// For is a for loop running Body with Name set to each item in turn. The
+// items are the positional parameters for a loop without in.
+type For struct {
+	Name     string
+	In       bool
+	Items    []*Word
+	Body     *List
+	Position lexer.Pos
+}
+
+// While is a while loop, or an until loop if Until is set.
+type While struct {
+	Until      bool
+	Cond, Body *List
+	Position   lexer.Pos
+}
+
+// Group is a list of commands grouped in { }.
+type Group struct {
+	Body     *List
+	Position lexer.Pos
+}
+
+// FunctionDef defines a function running Body.
+type FunctionDef struct {
+	Name     string
+	Body     Command
+	Position lexer.Pos
+}
+
+func (c *SimpleCommand) Pos() lexer.Pos { return c.Position }
+func (c *If) Pos() lexer.Pos            { return c.Position }
+func (c *For) Pos() lexer.Pos           { return c.Position }
+func (c *While) Pos() lexer.Pos         { return c.Position }
+func (c *Group) Pos() lexer.Pos         { return c.Position }
+func (c *FunctionDef) Pos() lexer.Pos   { return c.Position }
+
+func (*SimpleCommand) command() {}
+func (*If) command()            {}
+func (*For) command()           {}
+func (*While) command()         {}
+func (*Group) command()         {}
+func (*FunctionDef) command()   {}
@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/lexer"
+)
+
+// JoinLines joins the next line of a command to the ones before it. A
+// backslash continuing the line is removed along with the newline.
+func JoinLines(line, next string) string {
+	if LineContinued(line) {
+		return line[:len(line)-1] + next
+	}
+	return line + "\n" + next
+}
+
+// LineContinued reports whether line ends in a backslash that isn't escaped
+// or in single quotes, continuing the command on the next line.
+func LineContinued(line string) bool {
+	inSingleQuotes, inDoubleQuotes, escaped := false, false, false
+	for _, c := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\' && !inSingleQuotes:
+			escaped = true
+		case c == '\'' && !inDoubleQuotes:
+			inSingleQuotes = !inSingleQuotes
+		case c == '"' && !inSingleQuotes:
+			inDoubleQuotes = !inDoubleQuotes
+		}
+	}
+	return escaped
+}
+
+// SyntaxError is an error in the syntax of a command line, at Pos in it.
+type SyntaxError struct {
+	Msg string
+	Pos lexer.Pos
+	// incomplete is set for an error at the end of the line, which more
+	// input could fix.
+	incomplete bool
+}
+
+func (e *SyntaxError) Error() string {
+	return e.Msg
+}
+
+// Is reports an error more input could fix as matching ErrIncomplete.
+func (e *SyntaxError) Is(target error) bool {
+	return target == ErrIncomplete && e.incomplete
+}
+
+// ErrIncomplete matches the errors for a command that needs more input, and
+// is the error reported for one that still does at the end of a script.
+var ErrIncomplete = &SyntaxError{Msg: "syntax error: unexpected end of file", incomplete: true}
+
+// Incomplete reports whether line needs more input to be a complete
+// command.
+func Incomplete(line string) bool {
+	return errors.Is(Check(line), ErrIncomplete)
+}
+
+// Check checks that line is a complete command. It returns an error
+// matching ErrIncomplete if it ends in a backslash continuing it, within quotes or
+// a substitution, after |, && or ||, or in a compound command it doesn't
+// close, and a syntax error if it has a token out of place.
+func Check(line string) error {
+	if LineContinued(line) {
+		return ErrIncomplete
+	}
+	_, err := Parse(line)
+	return err
+}
+
+// unexpectedToken returns the syntax error for a token out of place, which
+// more input could fix if it is the end of the text.
+func unexpectedToken(tok lexer.Token) error {
+	switch tok.Kind {
+	case lexer.EOF:
+		return &SyntaxError{Msg: ErrIncomplete.Msg, Pos: tok.Pos, incomplete: true}
+	case lexer.Newline:
+		return unexpected("newline", tok.Pos)
+	}
+	return unexpected(tok.Text, tok.Pos)
+}
+
+// unexpected returns the syntax error for the token text out of place at
+// pos.
+func unexpected(text string, pos lexer.Pos) error {
+	return &SyntaxError{Msg: fmt.Sprintf("syntax error near unexpected token `%s'", text), Pos: pos}
+}
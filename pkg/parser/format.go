@@ -0,0 +1,157 @@
+package parser
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/lexer"
+)
+
+// Format returns cmd written out as source that parses back to it: a
+// command per line, with the bodies of compound commands indented, and
+// the words as they were written.
+func Format(cmd Command) string {
+	var f formatter
+	f.command(cmd)
+	return f.sb.String()
+}
+
+// FormatAndOr returns andOr written out as Format writes commands, ending
+// in & if it runs in the background.
+func FormatAndOr(andOr *AndOr) string {
+	var f formatter
+	f.andOr(andOr)
+	return f.sb.String()
+}
+
+// formatter writes out commands for Format.
+type formatter struct {
+	sb     strings.Builder
+	indent int
+}
+
+// newline starts a line at the current indentation.
+func (f *formatter) newline() {
+	f.sb.WriteByte('\n')
+	f.sb.WriteString(strings.Repeat("    ", f.indent))
+}
+
+// body writes list a command per line, indented, then end on a line of
+// its own.
+func (f *formatter) body(list *List, end string) {
+	f.indent++
+	for _, andOr := range list.Items {
+		f.newline()
+		f.andOr(andOr)
+	}
+	f.indent--
+	f.newline()
+	f.sb.WriteString(end)
+}
+
+// inline writes list on the current line followed by word, as the
+// condition of if, while and until is followed by then or do.
+func (f *formatter) inline(list *List, word string) {
+	for _, andOr := range list.Items {
+		f.andOr(andOr)
+		if andOr.Background {
+			f.sb.WriteString(" ")
+		} else {
+			f.sb.WriteString("; ")
+		}
+	}
+	f.sb.WriteString(word)
+}
+
+func (f *formatter) andOr(andOr *AndOr) {
+	for i, pipeline := range andOr.Pipelines {
+		if i > 0 {
+			if andOr.Ops[i-1] == lexer.AndIf {
+				f.sb.WriteString(" && ")
+			} else {
+				f.sb.WriteString(" || ")
+			}
+		}
+		if pipeline.Timed {
+			f.sb.WriteString("time ")
+		}
+		if pipeline.Negated {
+			f.sb.WriteString("! ")
+		}
+		for j, cmd := range pipeline.Commands {
+			if j > 0 {
+				f.sb.WriteString(" | ")
+			}
+			f.command(cmd)
+		}
+	}
+	if andOr.Background {
+		f.sb.WriteString(" &")
+	}
+}
+
+func (f *formatter) command(cmd Command) {
+	switch cmd := cmd.(type) {
+	case *SimpleCommand:
+		// The words are written in the order they were, for a word such
+		// as { after a redirection not to become the first.
+		type piece struct {
+			text string
+			pos  int
+		}
+		var pieces []piece
+		for _, w := range slices.Concat(cmd.Assignments, cmd.Words) {
+			pieces = append(pieces, piece{w.Text, w.Position.Offset})
+		}
+		for _, r := range cmd.Redirects {
+			pieces = append(pieces, piece{r.Op + " " + r.File.Text, r.File.Position.Offset})
+		}
+		slices.SortStableFunc(pieces, func(a, b piece) int { return a.pos - b.pos })
+		for i, p := range pieces {
+			if i > 0 {
+				f.sb.WriteString(" ")
+			}
+			f.sb.WriteString(p.text)
+		}
+	case *If:
+		f.sb.WriteString("if ")
+		for clause := cmd; ; clause = clause.Elif {
+			f.inline(clause.Cond, "then")
+			if clause.Elif != nil {
+				f.body(clause.Then, "elif ")
+				continue
+			}
+			if clause.Else != nil {
+				f.body(clause.Then, "else")
+				f.body(clause.Else, "fi")
+			} else {
+				f.body(clause.Then, "fi")
+			}
+			break
+		}
+	case *For:
+		f.sb.WriteString("for " + cmd.Name)
+		if cmd.In {
+			f.sb.WriteString(" in")
+			for _, w := range cmd.Items {
+				f.sb.WriteString(" " + w.Text)
+			}
+		}
+		f.sb.WriteString("; do")
+		f.body(cmd.Body, "done")
+	case *While:
+		if cmd.Until {
+			f.sb.WriteString("until ")
+		} else {
+			f.sb.WriteString("while ")
+		}
+		f.inline(cmd.Cond, "do")
+		f.body(cmd.Body, "done")
+	case *Group:
+		f.sb.WriteString("{")
+		f.body(cmd.Body, "}")
+	case *FunctionDef:
+		f.sb.WriteString(cmd.Name + "() ")
+		f.command(cmd.Body)
+	}
+}
@@ -0,0 +1,31 @@
+package parser
+
+import "testing"
+
+// TestFormat checks how Format writes out functions, as set prints them.
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{"f() { echo $1; }", "f() {\n    echo $1\n}"},
+		{
+			"f() { if [[ -d $1 ]]; then cd $1 && ls | wc -l; elif x; then :; else echo no >> log 2> err; fi; }",
+			"f() {\n    if [[ -d $1 ]]; then\n        cd $1 && ls | wc -l\n    elif x; then\n        :\n    else\n        echo no >> log 2> err\n    fi\n}",
+		},
+		{
+			"function f { for x in a 'b c'; do until ! false; do break; done; done; sleep 1 & }",
+			"f() {\n    for x in a 'b c'; do\n        until ! false; do\n            break\n        done\n    done\n    sleep 1 &\n}",
+		},
+		{"f() { for x\ndo time echo $x; done; }", "f() {\n    for x; do\n        time echo $x\n    done\n}"},
+		{"f() while a & b; do :; done", "f() while a & b; do\n    :\ndone"},
+	}
+	for _, tt := range tests {
+		list, err := Parse(tt.src)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.src, err)
+		}
+		if got := Format(list.Items[0].Pipelines[0].Commands[0]); got != tt.want {
+			t.Errorf("Format(%q) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
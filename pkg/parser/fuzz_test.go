@@ -0,0 +1,157 @@
+package parser
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/lexer"
+)
+
+// FuzzParse checks that parsing any text either gives a syntax error or a
+// tree whose words are all pieces of the text and which Format writes out
+// as source parsing back to it, and that Check agrees.
+func FuzzParse(f *testing.F) {
+	for _, s := range []string{
+		"",
+		"echo hello | tr a-z A-Z && echo ok || echo failed; sleep 1 &",
+		"x=1 y=2 env > out 2>> err < in",
+		"if a; then b; elif c; then d; else e; fi",
+		"for x in a 'b c'; do echo $x; done",
+		"for x\ndo\n  echo $x\ndone",
+		"while false; do :; done; until true; do :; done",
+		"f() { echo $1; }; function g { f a; }",
+		"! [[ $a == b* ]] | cat",
+		"time ! sleep 1 | cat",
+		"{ echo a; echo b; } > out",
+		"echo )",
+		"if true; then",
+		"echo >",
+		"fi",
+	} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		list, err := Parse(src)
+		if err != nil {
+			if _, ok := err.(*SyntaxError); !ok {
+				t.Fatalf("Parse(%q) error %T, want *SyntaxError", src, err)
+			}
+		} else {
+			checkList(t, src, list)
+			checkFormat(t, src, list)
+		}
+		if LineContinued(src) {
+			return
+		}
+		if checkErr := Check(src); (checkErr == nil) != (err == nil) || errors.Is(checkErr, ErrIncomplete) != errors.Is(err, ErrIncomplete) {
+			t.Fatalf("Check(%q) = %v, but Parse gives %v", src, checkErr, err)
+		}
+	})
+}
+
+// checkList checks that the words of the commands in list are pieces of
+// src at the positions they claim.
+func checkList(t *testing.T, src string, list *List) {
+	t.Helper()
+	checkWord := func(w *Word) {
+		off := w.Position.Offset
+		if w.Text == "" || off+len(w.Text) > len(src) || src[off:off+len(w.Text)] != w.Text {
+			t.Fatalf("Parse(%q): word %q isn't at %d", src, w.Text, off)
+		}
+	}
+	for _, andOr := range list.Items {
+		if len(andOr.Ops) != len(andOr.Pipelines)-1 {
+			t.Fatalf("Parse(%q): %d pipelines joined by %d operators", src, len(andOr.Pipelines), len(andOr.Ops))
+		}
+		for _, p := range andOr.Pipelines {
+			for _, cmd := range p.Commands {
+				switch cmd := cmd.(type) {
+				case *SimpleCommand:
+					for _, w := range append(cmd.Assignments, cmd.Words...) {
+						checkWord(w)
+					}
+					for _, r := range cmd.Redirects {
+						checkWord(r.File)
+					}
+				case *If:
+					for c := cmd; c != nil; c = c.Elif {
+						checkList(t, src, c.Cond)
+						checkList(t, src, c.Then)
+						if c.Else != nil {
+							checkList(t, src, c.Else)
+						}
+					}
+				case *For:
+					for _, w := range cmd.Items {
+						checkWord(w)
+					}
+					checkList(t, src, cmd.Body)
+				case *While:
+					checkList(t, src, cmd.Cond)
+					checkList(t, src, cmd.Body)
+				case *Group:
+					checkList(t, src, cmd.Body)
+				case *FunctionDef:
+					checkList(t, src, &List{Items: []*AndOr{{Pipelines: []*Pipeline{{Commands: []Command{cmd.Body}}}}}})
+				}
+			}
+		}
+	}
+}
+
+// checkFormat checks that list, parsed from src, written out the way
+// Format writes commands parses back to a list written out the same.
+func checkFormat(t *testing.T, src string, list *List) {
+	t.Helper()
+	// A backslash ending src escapes nothing, but would escape what is
+	// written after its word. No function's body ends with one.
+	if strings.HasSuffix(src, `\`) {
+		return
+	}
+	var f formatter
+	f.inline(list, "")
+	formatted := f.sb.String()
+	again, err := Parse(formatted)
+	if err != nil {
+		t.Fatalf("Parse(%q) formatted as %q: %v", src, formatted, err)
+	}
+	f = formatter{}
+	f.inline(again, "")
+	if f.sb.String() != formatted {
+		t.Fatalf("Parse(%q) formatted as %q, which formats as %q", src, formatted, f.sb.String())
+	}
+}
+
+// FuzzRedirect checks that a redirection parses into the file descriptor,
+// mode and file it was written with.
+func FuzzRedirect(f *testing.F) {
+	f.Add(uint16(1), false, false, "out.txt")
+	f.Add(uint16(2), true, false, "/dev/null")
+	f.Add(uint16(0), false, true, "in file")
+	f.Add(uint16(3), true, false, "$HOME/log")
+	f.Fuzz(func(t *testing.T, fd uint16, appending, input bool, file string) {
+		op := ">"
+		switch {
+		case input:
+			op = "<"
+		case appending:
+			op = ">>"
+		}
+		file = lexer.Quote(file)
+		src := "cmd " + strconv.Itoa(int(fd)) + op + file
+		list, err := Parse(src)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", src, err)
+		}
+		cmd := list.Items[0].Pipelines[0].Commands[0].(*SimpleCommand)
+		want := Redirect{Fd: int(fd), Append: appending && !input, File: &Word{Text: file}}
+		if len(cmd.Redirects) != 1 || len(cmd.Words) != 1 {
+			t.Fatalf("Parse(%q) = %d words and %d redirects, want 1 and 1", src, len(cmd.Words), len(cmd.Redirects))
+		}
+		if r := cmd.Redirects[0]; r.Fd != want.Fd || r.Append != want.Append || r.File.Text != want.File.Text {
+			t.Fatalf("Parse(%q) redirects %d (append %t) to %q, want %d (append %t) to %q", src, r.Fd, r.Append, r.File.Text, want.Fd, want.Append, want.File.Text)
+		}
+	})
+}
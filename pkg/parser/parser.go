@@ -0,0 +1,358 @@
+// Package parser parses command lines into the syntax tree of the commands
+// they run, and checks them for syntax errors and for needing more input.
+package parser
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/shell-starter-go/pkg/lexer"
+)
+
+// Parse parses src into the list of commands it runs. Its errors are
+// *SyntaxErrors, matching ErrIncomplete if src ends in the middle of a
+// command.
+func Parse(src string) (*List, error) {
+	tokens, err := lexer.Lex(src)
+	if err != nil {
+		lexErr := err.(*lexer.Error)
+		return nil, &SyntaxError{Msg: lexErr.Msg, Pos: lexErr.Pos, incomplete: lexErr.Incomplete}
+	}
+	p := &parser{tokens: tokens}
+	list, err := p.list()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.Kind != lexer.EOF {
+		return nil, unexpectedToken(tok)
+	}
+	return list, nil
+}
+
+// parser parses a command line from its tokens.
+type parser struct {
+	tokens []lexer.Token
+	pos    int
+}
+
+// peek returns the token ahead, and next returns it and moves past it.
+func (p *parser) peek() lexer.Token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() lexer.Token {
+	tok := p.tokens[p.pos]
+	if tok.Kind != lexer.EOF {
+		p.pos++
+	}
+	return tok
+}
+
+// reserved reports whether the token ahead is one of the reserved words.
+func (p *parser) reserved(words ...string) bool {
+	tok := p.peek()
+	return tok.Kind == lexer.Word && slices.Contains(words, tok.Text)
+}
+
+// expect moves past the reserved word ahead, or fails if there is another
+// token there.
+func (p *parser) expect(word string) error {
+	if !p.reserved(word) {
+		return unexpectedToken(p.peek())
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) skipNewlines() {
+	for p.peek().Kind == lexer.Newline {
+		p.next()
+	}
+}
+
+// list parses commands up to the end of the line, a ) or one of the
+// reserved words in ends.
+func (p *parser) list(ends ...string) (*List, error) {
+	list := &List{}
+	for {
+		p.skipNewlines()
+		if tok := p.peek(); tok.Kind == lexer.EOF || tok.Kind == lexer.RParen || p.reserved(ends...) {
+			return list, nil
+		}
+		andOr, err := p.andOr()
+		if err != nil {
+			return nil, err
+		}
+		list.Items = append(list.Items, andOr)
+		switch p.peek().Kind {
+		case lexer.Semi, lexer.Newline:
+			p.next()
+		case lexer.Amp:
+			p.next()
+			andOr.Background = true
+		default:
+			return list, nil
+		}
+	}
+}
+
+// body parses the commands of a compound command up to one of the reserved
+// words in ends, and needs there to be at least one command.
+func (p *parser) body(ends ...string) (*List, error) {
+	list, err := p.list(ends...)
+	if err != nil {
+		return nil, err
+	}
+	if len(list.Items) == 0 || !p.reserved(ends...) {
+		return nil, unexpectedToken(p.peek())
+	}
+	return list, nil
+}
+
+func (p *parser) andOr() (*AndOr, error) {
+	andOr := &AndOr{}
+	for {
+		pipeline, err := p.pipeline()
+		if err != nil {
+			return nil, err
+		}
+		andOr.Pipelines = append(andOr.Pipelines, pipeline)
+		op := p.peek().Kind
+		if op != lexer.AndIf && op != lexer.OrIf {
+			return andOr, nil
+		}
+		p.next()
+		p.skipNewlines()
+		andOr.Ops = append(andOr.Ops, op)
+	}
+}
+
+func (p *parser) pipeline() (*Pipeline, error) {
+	pipeline := &Pipeline{}
+	if p.reserved("time") {
+		p.next()
+		pipeline.Timed = true
+	}
+	if p.reserved("!") {
+		p.next()
+		pipeline.Negated = true
+	}
+	for {
+		cmd, err := p.command()
+		if err != nil {
+			return nil, err
+		}
+		pipeline.Commands = append(pipeline.Commands, cmd)
+		if p.peek().Kind != lexer.Pipe {
+			return pipeline, nil
+		}
+		p.next()
+		p.skipNewlines()
+	}
+}
+
+func (p *parser) command() (Command, error) {
+	tok := p.peek()
+	switch tok.Kind {
+	case lexer.Word:
+	case lexer.Redirect:
+		return p.simpleCommand()
+	default:
+		return nil, unexpectedToken(tok)
+	}
+	switch tok.Text {
+	case "if":
+		return p.ifClause()
+	case "for":
+		return p.forLoop()
+	case "while", "until":
+		return p.whileLoop()
+	case "{":
+		return p.group()
+	case "function":
+		p.next()
+		name := p.next()
+		if name.Kind != lexer.Word {
+			return nil, unexpectedToken(name)
+		}
+		if p.peek().Kind == lexer.LParen {
+			p.next()
+			if tok := p.next(); tok.Kind != lexer.RParen {
+				return nil, unexpectedToken(tok)
+			}
+		}
+		return p.functionBody(name)
+	case "then", "elif", "else", "fi", "do", "done", "}", "!":
+		return nil, unexpectedToken(tok)
+	}
+	if p.tokens[p.pos+1].Kind == lexer.LParen {
+		p.next()
+		p.next()
+		if tok := p.next(); tok.Kind != lexer.RParen {
+			return nil, unexpectedToken(tok)
+		}
+		return p.functionBody(tok)
+	}
+	return p.simpleCommand()
+}
+
+func (p *parser) simpleCommand() (*SimpleCommand, error) {
+	cmd := &SimpleCommand{Position: p.peek().Pos}
+	for {
+		tok := p.peek()
+		switch tok.Kind {
+		case lexer.Word:
+			p.next()
+			word := &Word{Text: tok.Text, Position: tok.Pos}
+			if _, _, ok := ParseAssignment(tok.Text); ok && len(cmd.Words) == 0 {
+				cmd.Assignments = append(cmd.Assignments, word)
+			} else {
+				cmd.Words = append(cmd.Words, word)
+			}
+		case lexer.Redirect:
+			p.next()
+			file := p.next()
+			switch file.Kind {
+			case lexer.Word:
+			case lexer.EOF:
+				// There is no more input that could be the file.
+				return nil, unexpected("newline", file.Pos)
+			default:
+				return nil, unexpectedToken(file)
+			}
+			redirect := parseRedirect(tok.Text)
+			redirect.File = &Word{Text: file.Text, Position: file.Pos}
+			cmd.Redirects = append(cmd.Redirects, redirect)
+		default:
+			return cmd, nil
+		}
+	}
+}
+
+// parseRedirect returns the redirection made by a redirection operator,
+// without its file.
+func parseRedirect(op string) *Redirect {
+	digits := strings.TrimRight(op, "<>")
+	r := &Redirect{Fd: 1, Append: strings.HasSuffix(op, ">>"), Op: op}
+	if strings.HasSuffix(op, "<") {
+		r.Fd = 0
+	}
+	if digits != "" {
+		fd, err := strconv.Atoi(digits)
+		if err != nil {
+			fd = -1
+		}
+		r.Fd = fd
+	}
+	return r
+}
+
+// ifClause parses an if command, or what follows an elif.
+func (p *parser) ifClause() (*If, error) {
+	cmd := &If{Position: p.next().Pos}
+	var err error
+	if cmd.Cond, err = p.body("then"); err != nil {
+		return nil, err
+	}
+	p.next()
+	if cmd.Then, err = p.body("elif", "else", "fi"); err != nil {
+		return nil, err
+	}
+	switch p.peek().Text {
+	case "elif":
+		// The elif clause ends with the fi of the whole command.
+		cmd.Elif, err = p.ifClause()
+		return cmd, err
+	case "else":
+		p.next()
+		if cmd.Else, err = p.body("fi"); err != nil {
+			return nil, err
+		}
+	}
+	p.next()
+	return cmd, nil
+}
+
+func (p *parser) forLoop() (*For, error) {
+	cmd := &For{Position: p.next().Pos}
+	name := p.next()
+	if name.Kind != lexer.Word {
+		return nil, unexpectedToken(name)
+	}
+	if !lexer.ValidName(name.Text) {
+		return nil, &SyntaxError{Msg: "`" + name.Text + "': not a valid identifier", Pos: name.Pos}
+	}
+	cmd.Name = name.Text
+	p.skipNewlines()
+	if p.reserved("in") {
+		p.next()
+		cmd.In = true
+		for p.peek().Kind == lexer.Word {
+			tok := p.next()
+			cmd.Items = append(cmd.Items, &Word{Text: tok.Text, Position: tok.Pos})
+		}
+	}
+	if kind := p.peek().Kind; kind == lexer.Semi || kind == lexer.Newline {
+		p.next()
+	}
+	p.skipNewlines()
+	if err := p.expect("do"); err != nil {
+		return nil, err
+	}
+	var err error
+	if cmd.Body, err = p.body("done"); err != nil {
+		return nil, err
+	}
+	p.next()
+	return cmd, nil
+}
+
+func (p *parser) whileLoop() (*While, error) {
+	tok := p.next()
+	cmd := &While{Until: tok.Text == "until", Position: tok.Pos}
+	var err error
+	if cmd.Cond, err = p.body("do"); err != nil {
+		return nil, err
+	}
+	p.next()
+	if cmd.Body, err = p.body("done"); err != nil {
+		return nil, err
+	}
+	p.next()
+	return cmd, nil
+}
+
+func (p *parser) group() (*Group, error) {
+	cmd := &Group{Position: p.next().Pos}
+	var err error
+	if cmd.Body, err = p.body("}"); err != nil {
+		return nil, err
+	}
+	p.next()
+	return cmd, nil
+}
+
+// functionBody parses the compound command that is the body of the
+// function name.
+func (p *parser) functionBody(name lexer.Token) (*FunctionDef, error) {
+	if !lexer.ValidName(name.Text) {
+		return nil, &SyntaxError{Msg: "`" + name.Text + "': not a valid identifier", Pos: name.Pos}
+	}
+	p.skipNewlines()
+	if !p.reserved("if", "for", "while", "until", "{") {
+		return nil, unexpectedToken(p.peek())
+	}
+	body, err := p.command()
+	if err != nil {
+		return nil, err
+	}
+	return &FunctionDef{Name: name.Text, Body: body, Position: name.Pos}, nil
+}
+
+// ParseAssignment splits word into a variable name and value if it is an
+// assignment of the form NAME=value.
+func ParseAssignment(word string) (name, value string, ok bool) {
+	name, value, ok = strings.Cut(word, "=")
+	return name, value, ok && lexer.ValidName(name)
+}
@@ -0,0 +1,87 @@
+package terminal
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Recorder is a Terminal passing everything on to another, that records
+// the keys read from it and what is written to it, with the time since it
+// started, as an asciicast that asciinema can play back.
+type Recorder struct {
+	Terminal
+	mu    sync.Mutex
+	w     io.Writer
+	start time.Time
+}
+
+// NewRecorder returns a Recorder on t that records to w, starting with the
+// header giving t's size.
+func NewRecorder(t Terminal, w io.Writer) (*Recorder, error) {
+	width, height, err := t.Size()
+	if err != nil {
+		width, height = 80, 24
+	}
+	r := &Recorder{Terminal: t, w: w, start: time.Now()}
+	header, err := json.Marshal(map[string]any{
+		"version":   2,
+		"width":     width,
+		"height":    height,
+		"timestamp": r.start.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(append(header, '\n')); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Recorder) Read(p []byte) (int, error) {
+	n, err := r.Terminal.Read(p)
+	r.record("i", p[:n])
+	return n, err
+}
+
+func (r *Recorder) Write(p []byte) (int, error) {
+	r.record("o", p)
+	return r.Terminal.Write(p)
+}
+
+// Tee returns a writer passing what is written to it on to w and
+// recording it as output, for output that reaches the terminal some other
+// way, such as the standard error of the shell.
+func (r *Recorder) Tee(w io.Writer) io.Writer {
+	return &tee{r, w}
+}
+
+type tee struct {
+	r *Recorder
+	w io.Writer
+}
+
+func (t *tee) Write(p []byte) (int, error) {
+	// Out of raw mode, the terminal shows each newline as a carriage
+	// return and a line feed, and a player needs both.
+	t.r.record("o", bytes.ReplaceAll(p, []byte("\n"), []byte("\r\n")))
+	return t.w.Write(p)
+}
+
+// record writes an event of kind "i" for input or "o" for output with p
+// as its data. Bytes that aren't valid UTF-8 are recorded as U+FFFD.
+func (r *Recorder) record(kind string, p []byte) {
+	if len(p) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	event, err := json.Marshal([]any{time.Since(r.start).Seconds(), kind, string(p)})
+	if err != nil {
+		return
+	}
+	r.w.Write(append(event, '\n'))
+}
@@ -0,0 +1,106 @@
+// Package terminal abstracts the terminal the shell reads keys from and
+// draws its prompt on, so that the line editor and the REPL can run on
+// something other than the process's own terminal: a recording in a test,
+// or another frontend altogether.
+package terminal
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// Terminal is where keys are read from and the line being edited is drawn.
+type Terminal interface {
+	io.Reader
+	io.Writer
+	// IsTerminal reports whether it is interactive, rather than a file or
+	// pipe input is read from as a script.
+	IsTerminal() bool
+	// MakeRaw puts it in raw mode, passing keys on as they are typed
+	// without echoing them, and returns a function restoring the mode it
+	// was in.
+	MakeRaw() (restore func() error, err error)
+	// Size returns its width in columns and height in rows.
+	Size() (width, height int, err error)
+}
+
+// File is a Terminal on files of the process, such as its standard input
+// and output.
+type File struct {
+	In, Out *os.File
+}
+
+// Std returns the Terminal on the standard input and output of the process.
+func Std() *File {
+	return &File{In: os.Stdin, Out: os.Stdout}
+}
+
+func (f *File) Read(p []byte) (int, error) {
+	return f.In.Read(p)
+}
+
+func (f *File) Write(p []byte) (int, error) {
+	return f.Out.Write(p)
+}
+
+func (f *File) IsTerminal() bool {
+	return term.IsTerminal(int(f.In.Fd()))
+}
+
+// MakeRaw puts the terminal in raw mode, and on Windows has the console
+// process the escape sequences written to it too.
+func (f *File) MakeRaw() (restore func() error, err error) {
+	fd := int(f.In.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, err
+	}
+	restoreOut, err := enableVT(f.Out)
+	if err != nil {
+		term.Restore(fd, state)
+		return nil, err
+	}
+	return func() error {
+		outErr := restoreOut()
+		if err := term.Restore(fd, state); err != nil {
+			return err
+		}
+		return outErr
+	}, nil
+}
+
+func (f *File) Size() (width, height int, err error) {
+	return term.GetSize(int(f.Out.Fd()))
+}
+
+// Virtual is a Terminal of a fixed size that reads keys from In and draws
+// on Out, with no mode to change, for tests and frontends that aren't a
+// terminal themselves.
+type Virtual struct {
+	In            io.Reader
+	Out           io.Writer
+	Width, Height int
+}
+
+func (v *Virtual) Read(p []byte) (int, error) {
+	return v.In.Read(p)
+}
+
+func (v *Virtual) Write(p []byte) (int, error) {
+	return v.Out.Write(p)
+}
+
+// IsTerminal reports true: a Virtual terminal is always interactive.
+func (v *Virtual) IsTerminal() bool {
+	return true
+}
+
+func (v *Virtual) MakeRaw() (restore func() error, err error) {
+	return func() error { return nil }, nil
+}
+
+func (v *Virtual) Size() (width, height int, err error) {
+	return v.Width, v.Height, nil
+}
@@ -0,0 +1,28 @@
+package terminal
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// enableVT has the console out process the escape sequences written to
+// it, which consoles older than Windows Terminal print as they are
+// unless asked to, and returns a function restoring the mode it was in. It
+// does nothing if out isn't a console.
+//
+// Input needs no translating: in raw mode the console is put in virtual
+// terminal input mode, and reads give keys as the escape sequences the
+// line editor decodes everywhere else rather than as input records.
+func enableVT(out *os.File) (restore func() error, err error) {
+	h := windows.Handle(out.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(h, &mode); err != nil {
+		return func() error { return nil }, nil
+	}
+	vt := mode | windows.ENABLE_PROCESSED_OUTPUT | windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING
+	if err := windows.SetConsoleMode(h, vt); err != nil {
+		return nil, err
+	}
+	return func() error { return windows.SetConsoleMode(h, mode) }, nil
+}